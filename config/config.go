@@ -23,6 +23,32 @@ type envConfig struct {
 	AllowedOrigins []string `mapstructure:"CORS_ALLOWED_ORIGINS"`
 	// UploadMaxBytes configures the maximum allowed uploaded file size in bytes. If unset, defaults to 10MB.
 	UploadMaxBytes int64 `mapstructure:"UPLOAD_MAX_BYTES"`
+	// DockerHost is the address of the container engine API, e.g. unix:///var/run/docker.sock.
+	DockerHost string `mapstructure:"DOCKER_HOST"`
+	// ContainerRuntime selects the container engine backend: "docker" (default) or "podman".
+	ContainerRuntime string `mapstructure:"CONTAINER_RUNTIME"`
+	// ContainerHost is the Podman API socket. If empty, the rootless or rootful default socket is used.
+	ContainerHost string `mapstructure:"CONTAINER_HOST"`
+	// DefaultTTLMinutes is the lifetime of containers started without an explicit TTL. 0 disables expiry.
+	DefaultTTLMinutes int `mapstructure:"DEFAULT_TTL_MINUTES"`
+	// BuildTimeoutSeconds limits how long building a custom image may take.
+	BuildTimeoutSeconds int `mapstructure:"BUILD_TIMEOUT_SECONDS"`
+	// PullTimeoutSeconds limits how long pulling an image may take.
+	PullTimeoutSeconds int `mapstructure:"PULL_TIMEOUT_SECONDS"`
+	// PortRangeStart and PortRangeEnd limit automatically allocated host ports. If unset, the
+	// container port is used when it is free and a random free port otherwise.
+	PortRangeStart int `mapstructure:"PORT_RANGE_START"`
+	PortRangeEnd   int `mapstructure:"PORT_RANGE_END"`
+	// MaxMemoryMB, MaxCPUs and MaxPids cap the resource limits of started containers. Containers
+	// without an explicit limit get the maximum. 0 means no maximum.
+	MaxMemoryMB int64   `mapstructure:"MAX_MEMORY_MB"`
+	MaxCPUs     float64 `mapstructure:"MAX_CPUS"`
+	MaxPids     int64   `mapstructure:"MAX_PIDS"`
+	// CatalogDir holds YAML server type definitions loaded in addition to the built-in types.
+	CatalogDir string `mapstructure:"CATALOG_DIR"`
+	// SelfContainer is the name or id of the container this application runs in. It is attached
+	// to managed networks so started servers can be reached by their DNS alias.
+	SelfContainer string `mapstructure:"SELF_CONTAINER"`
 }
 
 func loadEnvVariables() (config *envConfig) {
@@ -36,6 +62,19 @@ func loadEnvVariables() (config *envConfig) {
 	viper.SetDefault("CORS_ALLOWED_ORIGINS", nil)
 	// default upload max bytes: 10 MB
 	viper.SetDefault("UPLOAD_MAX_BYTES", 10<<20)
+	viper.SetDefault("DOCKER_HOST", "unix:///var/run/docker.sock")
+	viper.SetDefault("CONTAINER_RUNTIME", "docker")
+	viper.SetDefault("CONTAINER_HOST", "")
+	viper.SetDefault("DEFAULT_TTL_MINUTES", 0)
+	viper.SetDefault("SELF_CONTAINER", "")
+	viper.SetDefault("CATALOG_DIR", "./catalog")
+	viper.SetDefault("BUILD_TIMEOUT_SECONDS", 60)
+	viper.SetDefault("PULL_TIMEOUT_SECONDS", 180)
+	viper.SetDefault("PORT_RANGE_START", 0)
+	viper.SetDefault("PORT_RANGE_END", 0)
+	viper.SetDefault("MAX_MEMORY_MB", 0)
+	viper.SetDefault("MAX_CPUS", 0)
+	viper.SetDefault("MAX_PIDS", 0)
 
 	viper.AddConfigPath(".")
 	viper.SetConfigName("app")
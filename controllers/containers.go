@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tim0-12432/simple-test-server/db/dtos"
@@ -13,7 +15,7 @@ func InitializeContainerRoutes(root *gin.RouterGroup) {
 	path := root.Group("/containers")
 
 	path.GET("", func(c *gin.Context) {
-		result, err := services.ListRunningContainers()
+		result, err := services.ListActiveContainers()
 		if err != nil {
 			c.Status(http.StatusInternalServerError)
 			return
@@ -34,10 +36,61 @@ func InitializeContainerRoutes(root *gin.RouterGroup) {
 	path.DELETE("/:id", func(c *gin.Context) {
 		containerId := c.Param("id")
 
-		docker.StopContainer(containerId)
-
-		services.UpdateContainerStatus(containerId, dtos.Discarded)
+		// discarding an already discarded container is not an error
+		if err := docker.DiscardContainer(containerId); err != nil && !errors.Is(err, services.ErrInvalidTransition) {
+			respondLifecycleError(c, err)
+			return
+		}
 
 		c.Status(http.StatusNoContent)
 	})
+
+	path.POST("/:id/stop", lifecycleHandler(docker.StopContainer))
+	path.POST("/:id/start", lifecycleHandler(docker.StartContainer))
+	path.POST("/:id/restart", lifecycleHandler(docker.RestartContainer))
+	path.POST("/:id/pause", lifecycleHandler(docker.PauseContainer))
+	path.POST("/:id/unpause", lifecycleHandler(docker.UnpauseContainer))
+
+	path.POST("/:id/extend", func(c *gin.Context) {
+		var body struct {
+			Minutes int `json:"minutes"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Minutes <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minutes must be a positive number"})
+			return
+		}
+		container, err := docker.ExtendContainerLease(c.Param("id"), time.Duration(body.Minutes)*time.Minute)
+		if err != nil {
+			if errors.Is(err, docker.ErrNoExpiry) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			respondLifecycleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, container)
+	})
+}
+
+// lifecycleHandler runs a state change on the container :id and returns the updated container.
+func lifecycleHandler(change func(containerId string) (*dtos.Container, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		container, err := change(c.Param("id"))
+		if err != nil {
+			respondLifecycleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, container)
+	}
+}
+
+func respondLifecycleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrContainerNotFound), errors.Is(err, docker.ErrContainerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+	case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, docker.ErrContainerNotRunning), errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
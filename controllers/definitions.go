@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// maxDefinitionSize bounds the size of an imported definition document.
+const maxDefinitionSize = 1 << 20
+
+func InitializeDefinitionRoutes(root *gin.RouterGroup) {
+	path := root.Group("/definitions")
+
+	// exports the containers given as ?containers=id1,id2 or all containers as YAML
+	path.GET("/export", func(c *gin.Context) {
+		ids := []string{}
+		for _, value := range c.QueryArray("containers") {
+			for _, id := range strings.Split(value, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					ids = append(ids, id)
+				}
+			}
+		}
+		def, err := docker.ExportContainers(ids)
+		if err != nil {
+			respondDefinitionError(c, err)
+			return
+		}
+		content, err := docker.MarshalDefinition(def)
+		if err != nil {
+			respondDefinitionError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="simple-test-server.yaml"`)
+		c.Data(http.StatusOK, "application/yaml", content)
+	})
+
+	// starts the servers of a YAML or JSON definition document; every server has its own
+	// progress stream at /servers/progress/:reqId
+	path.POST("/import", func(c *gin.Context) {
+		content, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDefinitionSize+1))
+		if err != nil || len(content) > maxDefinitionSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		def, err := docker.ParseDefinition(content)
+		if err != nil {
+			respondDefinitionError(c, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		started, err := docker.ImportDefinition(ctx, def)
+		if err != nil {
+			respondDefinitionError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"servers": started})
+	})
+}
+
+func respondDefinitionError(c *gin.Context, err error) {
+	var configErr *servers.ConfigError
+	switch {
+	case errors.Is(err, services.ErrContainerNotFound), errors.Is(err, docker.ErrContainerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+	case errors.As(err, &configErr):
+		respondConfigurationError(c, err)
+	case errors.Is(err, docker.ErrInvalidDefinition),
+		errors.Is(err, docker.ErrInvalidLimits),
+		errors.Is(err, docker.ErrInvalidContainerName),
+		errors.Is(err, docker.ErrInvalidNetworkName),
+		errors.Is(err, docker.ErrInvalidVolumeName),
+		errors.Is(err, docker.ErrImageNotFound),
+		errors.Is(err, services.ErrSnapshotNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+func InitializeEnvironmentRoutes(root *gin.RouterGroup) {
+	path := root.Group("/environments")
+
+	path.GET("", func(c *gin.Context) {
+		result, err := docker.ListEnvironments()
+		if err != nil {
+			respondEnvironmentError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.GET("/:id", func(c *gin.Context) {
+		result, err := docker.GetEnvironment(c.Param("id"))
+		if err != nil {
+			respondEnvironmentError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	// starts all members in the background; the progress of the whole environment is
+	// streamed from /servers/progress/:reqId
+	path.POST("", func(c *gin.Context) {
+		var spec docker.EnvironmentSpec
+		if err := c.ShouldBindJSON(&spec); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		env, err := docker.CreateEnvironment(ctx, spec)
+		if err != nil {
+			respondEnvironmentError(c, err)
+			return
+		}
+		reqId := uuid.New().String()
+		go func() {
+			docker.StartEnvironmentWithProgress(reqId, env.ID)
+		}()
+
+		c.JSON(http.StatusAccepted, gin.H{"id": env.ID, "reqId": reqId})
+	})
+
+	// removes the containers and the network of all members
+	path.DELETE("/:id", func(c *gin.Context) {
+		result, err := docker.TeardownEnvironment(c.Param("id"))
+		if err != nil {
+			respondEnvironmentError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+}
+
+func respondEnvironmentError(c *gin.Context, err error) {
+	var configErr *servers.ConfigError
+	switch {
+	case errors.Is(err, services.ErrEnvironmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "environment not found"})
+	case errors.As(err, &configErr):
+		respondConfigurationError(c, err)
+	case errors.Is(err, docker.ErrInvalidEnvironment),
+		errors.Is(err, docker.ErrInvalidLimits),
+		errors.Is(err, docker.ErrInvalidContainerName),
+		errors.Is(err, docker.ErrInvalidPreset),
+		errors.Is(err, services.ErrPresetNotFound),
+		errors.Is(err, services.ErrSnapshotNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
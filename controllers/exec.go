@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/tim0-12432/simple-test-server/docker"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
+)
+
+// execMessage is a control message sent by the client as a text frame. Binary frames are
+// forwarded to stdin unchanged.
+type execMessage struct {
+	Type string `json:"type"` // "stdin" or "resize"
+	Data string `json:"data,omitempty"`
+	Rows uint   `json:"rows,omitempty"`
+	Cols uint   `json:"cols,omitempty"`
+}
+
+// registers the interactive exec WebSocket, e.g. /containers/:id/exec?cmd=sh
+func InitializeExecRoutes(root *gin.RouterGroup) {
+	root.GET("/containers/:id/exec", func(c *gin.Context) {
+		// the command runs before the upgrade, so errors can be returned as a response; the
+		// upgrade checks are made first, so a cross-site request cannot run it
+		if !websocket.IsWebSocketUpgrade(c.Request) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "websocket upgrade required"})
+			return
+		}
+		if !common.CheckOrigin(c.Request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "origin not allowed"})
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		session, err := docker.AttachShell(ctx, c.Param("id"), c.QueryArray("cmd"))
+		if err != nil {
+			if errors.Is(err, docker.ErrCommandNotAllowed) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			respondLifecycleError(c, err)
+			return
+		}
+		defer session.Close()
+
+		conn, err := common.Upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var writeMutex sync.Mutex
+
+		// terminal output to the client
+		go func() {
+			defer cancel()
+			buf := make([]byte, 32<<10)
+			for {
+				n, err := session.Read(buf)
+				if n > 0 {
+					writeMutex.Lock()
+					werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n])
+					writeMutex.Unlock()
+					if werr != nil {
+						return
+					}
+				}
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						log.Printf("exec read error: %v", err)
+					}
+					writeMutex.Lock()
+					_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session ended"))
+					writeMutex.Unlock()
+					return
+				}
+			}
+		}()
+
+		// client input and resize events to the session
+		go func() {
+			defer cancel()
+			for {
+				kind, data, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				if kind == websocket.BinaryMessage {
+					if _, err := session.Write(data); err != nil {
+						return
+					}
+					continue
+				}
+				var msg execMessage
+				if err := json.Unmarshal(data, &msg); err != nil {
+					continue
+				}
+				switch msg.Type {
+				case "stdin":
+					if _, err := session.Write([]byte(msg.Data)); err != nil {
+						return
+					}
+				case "resize":
+					if msg.Rows > 0 && msg.Cols > 0 {
+						if err := session.Resize(ctx, msg.Rows, msg.Cols); err != nil {
+							log.Printf("exec resize error: %v", err)
+						}
+					}
+				}
+			}
+		}()
+
+		<-ctx.Done()
+	})
+}
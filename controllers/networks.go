@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/docker"
+)
+
+func InitializeNetworkRoutes(root *gin.RouterGroup) {
+	path := root.Group("/networks")
+
+	path.GET("", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		result, err := docker.ListNetworks(ctx)
+		if err != nil {
+			respondNetworkError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.GET("/:name", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		result, err := docker.GetNetwork(ctx, c.Param("name"))
+		if err != nil {
+			respondNetworkError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.POST("", func(c *gin.Context) {
+		var body struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		result, err := docker.CreateNetwork(ctx, body.Name)
+		if err != nil {
+			respondNetworkError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, result)
+	})
+
+	path.DELETE("/:name", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		if err := docker.DeleteNetwork(ctx, c.Param("name")); err != nil {
+			respondNetworkError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+func respondNetworkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, docker.ErrNetworkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "network not found"})
+	case errors.Is(err, docker.ErrInvalidNetworkName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
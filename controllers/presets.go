@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+func InitializePresetRoutes(root *gin.RouterGroup) {
+	path := root.Group("/presets")
+
+	path.GET("", func(c *gin.Context) {
+		result, err := docker.ListPresets(c.Query("type"))
+		if err != nil {
+			respondPresetError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.GET("/:id", func(c *gin.Context) {
+		result, err := docker.GetPreset(c.Param("id"))
+		if err != nil {
+			respondPresetError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.POST("", func(c *gin.Context) {
+		var preset dtos.Preset
+		if err := c.ShouldBindJSON(&preset); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		result, err := docker.CreatePreset(&preset)
+		if err != nil {
+			respondPresetError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, result)
+	})
+
+	path.PUT("/:id", func(c *gin.Context) {
+		var preset dtos.Preset
+		if err := c.ShouldBindJSON(&preset); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		result, err := docker.UpdatePreset(c.Param("id"), &preset)
+		if err != nil {
+			respondPresetError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.DELETE("/:id", func(c *gin.Context) {
+		if err := docker.DeletePreset(c.Param("id")); err != nil {
+			respondPresetError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	path.POST("/:id/start", func(c *gin.Context) {
+		var overrides docker.ServerConfiguration
+		// the body is optional; without it the preset is started as stored
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&overrides); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration"})
+				return
+			}
+		}
+		serverType, configuration, err := docker.PresetConfiguration(c.Param("id"), overrides)
+		if err != nil {
+			respondPresetError(c, err)
+			return
+		}
+		startServer(c, serverType, configuration)
+	})
+}
+
+func respondPresetError(c *gin.Context, err error) {
+	var configErr *servers.ConfigError
+	switch {
+	case errors.Is(err, services.ErrPresetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "preset not found"})
+	case errors.Is(err, docker.ErrReadOnlyPreset):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, docker.ErrInvalidPreset), errors.Is(err, docker.ErrInvalidLimits), errors.As(err, &configErr):
+		respondConfigurationError(c, err)
+	case errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/docker"
 	"github.com/tim0-12432/simple-test-server/progress"
 )
 
@@ -33,9 +35,12 @@ func InitializeProgressRoutes(root *gin.RouterGroup) {
 					c.Writer.Flush()
 					return
 				}
-				sanitizedMessage := strings.ReplaceAll(ev.Message, "\"", "\\\"")
-				// send event as JSON
-				fmt.Fprintf(c.Writer, "data: {\"percent\":%d,\"message\":\"%s\",\"error\":%v}\n\n", ev.Percent, sanitizedMessage, ev.Error)
+				// send event as JSON; build output may contain quotes and backslashes
+				data, err := json.Marshal(ev)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(c.Writer, "data: %s\n\n", data)
 				c.Writer.Flush()
 			case <-notify:
 				return
@@ -46,4 +51,13 @@ func InitializeProgressRoutes(root *gin.RouterGroup) {
 			}
 		}
 	})
+
+	// cancels a start request that is still pulling its image
+	root.DELETE("/servers/progress/:reqId", func(c *gin.Context) {
+		if !docker.CancelStart(c.Param("reqId")) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "start request not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/tim0-12432/simple-test-server/config"
 	"github.com/tim0-12432/simple-test-server/protocols"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
 )
 
 var Router = gin.Default()
@@ -16,18 +17,8 @@ var Router = gin.Default()
 func InitializeRoutes() {
 	Router.Use(gin.Recovery())
 
-	allowedOrigins := []string{
-		"http://" + config.EnvConfig.Host + ":" + config.EnvConfig.Port,
-	}
-	if config.EnvConfig.AllowedOrigins != nil {
-		allowedOrigins = append(allowedOrigins, config.EnvConfig.AllowedOrigins...)
-	}
-	if config.EnvConfig.Env == "DEV" {
-		allowedOrigins = append(allowedOrigins, "http://localhost:5173")
-	}
-
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = allowedOrigins
+	corsConfig.AllowOrigins = common.AllowedOrigins()
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"}
 	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}
 	Router.Use(cors.New(corsConfig))
@@ -44,6 +35,14 @@ func InitializeApiRoutes(root *gin.RouterGroup) {
 
 	InitializeServerRoutes(api)
 	InitializeContainerRoutes(api)
+	InitializeExecRoutes(api)
+	InitializeStatsRoutes(api)
+	InitializeVolumeRoutes(api)
+	InitializeSnapshotRoutes(api)
+	InitializePresetRoutes(api)
+	InitializeEnvironmentRoutes(api)
+	InitializeDefinitionRoutes(api)
+	InitializeNetworkRoutes(api)
 	InitializeProgressRoutes(api)
 	protocols.InitializeProtocolRoutes(api)
 }
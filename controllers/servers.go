@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -33,12 +34,60 @@ func InitializeServerRoutes(root *gin.RouterGroup) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration"})
 			return
 		}
-		serverType := c.Param("type")
-		reqId := uuid.New().String()
-		go func() {
-			docker.StartServerWithProgress(reqId, serverType, configuration)
-		}()
-
-		c.JSON(http.StatusAccepted, gin.H{"reqId": reqId})
+		startServer(c, c.Param("type"), configuration)
 	})
 }
+
+// startServer validates configuration and starts a server of serverType in the background.
+// The response carries the id of the progress stream.
+func startServer(c *gin.Context, serverType string, configuration docker.ServerConfiguration) {
+	if _, err := servers.GetServerByType(serverType); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err := docker.ValidateConfiguration(serverType, configuration); err != nil {
+		respondConfigurationError(c, err)
+		return
+	}
+	if configuration.Name != "" {
+		if err := docker.CheckContainerName(c.Request.Context(), configuration.Name); err != nil {
+			switch {
+			case errors.Is(err, docker.ErrInvalidContainerName):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, docker.ErrConflict):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+	}
+	if configuration.Snapshot != "" {
+		snapshot, err := docker.GetSnapshot(configuration.Snapshot)
+		if err != nil {
+			respondSnapshotError(c, err)
+			return
+		}
+		if snapshot.Type != serverType {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "snapshot " + snapshot.Name + " was taken of a " + snapshot.Type + " server"})
+			return
+		}
+	}
+	reqId := uuid.New().String()
+	go func() {
+		docker.StartServerWithProgress(reqId, serverType, configuration)
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"reqId": reqId})
+}
+
+// respondConfigurationError reports an invalid configuration, with the field errors of the
+// schema validation if there are any.
+func respondConfigurationError(c *gin.Context, err error) {
+	var configErr *servers.ConfigError
+	if errors.As(err, &configErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration", "fields": configErr.Fields})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
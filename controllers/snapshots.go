@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker"
+)
+
+func InitializeSnapshotRoutes(root *gin.RouterGroup) {
+	path := root.Group("/snapshots")
+
+	path.GET("", func(c *gin.Context) {
+		result, err := docker.ListSnapshots()
+		if err != nil {
+			respondSnapshotError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.GET("/:name", func(c *gin.Context) {
+		result, err := docker.GetSnapshot(c.Param("name"))
+		if err != nil {
+			respondSnapshotError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.DELETE("/:name", func(c *gin.Context) {
+		if err := docker.DeleteSnapshot(c.Param("name")); err != nil {
+			respondSnapshotError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	root.POST("/containers/:id/snapshot", func(c *gin.Context) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+		defer cancel()
+		result, err := docker.CreateSnapshot(ctx, c.Param("id"), body.Name)
+		if err != nil {
+			if errors.Is(err, services.ErrContainerNotFound) || errors.Is(err, docker.ErrContainerNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+				return
+			}
+			respondSnapshotError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, result)
+	})
+}
+
+func respondSnapshotError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSnapshotNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "snapshot not found"})
+	case errors.Is(err, docker.ErrInvalidSnapshotName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/docker"
+)
+
+// registers resource statistics of single containers and of all managed containers
+func InitializeStatsRoutes(root *gin.RouterGroup) {
+	root.GET("/stats", func(c *gin.Context) {
+		snapshot, err := docker.GetAggregatedStats(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, snapshot)
+	})
+
+	root.GET("/stats/history", func(c *gin.Context) {
+		c.JSON(http.StatusOK, docker.GetAggregatedStatsHistory())
+	})
+
+	root.GET("/stats/stream", func(c *gin.Context) {
+		streamStats(c, func(snapshot docker.StatsSnapshot) (any, bool) {
+			return snapshot, true
+		})
+	})
+
+	root.GET("/containers/:id/stats", func(c *gin.Context) {
+		stats, err := docker.GetContainerStats(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondLifecycleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	})
+
+	root.GET("/containers/:id/stats/history", func(c *gin.Context) {
+		history, err := docker.GetStatsHistory(c.Param("id"))
+		if err != nil {
+			respondLifecycleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, history)
+	})
+
+	root.GET("/containers/:id/stats/stream", func(c *gin.Context) {
+		containerId := c.Param("id")
+		if _, err := docker.GetStatsHistory(containerId); err != nil {
+			respondLifecycleError(c, err)
+			return
+		}
+		streamStats(c, func(snapshot docker.StatsSnapshot) (any, bool) {
+			for _, stats := range snapshot.Containers {
+				if stats.ID == containerId {
+					return stats, true
+				}
+			}
+			return nil, false
+		})
+	})
+}
+
+// streamStats sends every snapshot of the stats collector as a server-sent event until the
+// client disconnects. pick selects what is sent from a snapshot.
+func streamStats(c *gin.Context, pick func(docker.StatsSnapshot) (any, bool)) {
+	snapshots, unsubscribe := docker.SubscribeStats()
+	defer unsubscribe()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Flush()
+
+	notify := c.Request.Context().Done()
+	for {
+		select {
+		case snapshot := <-snapshots:
+			value, ok := pick(snapshot)
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+			c.Writer.Flush()
+		case <-notify:
+			return
+		case <-time.After(30 * time.Second):
+			// keepalive comment
+			fmt.Fprintf(c.Writer, ": keepalive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
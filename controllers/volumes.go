@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/docker"
+)
+
+func InitializeVolumeRoutes(root *gin.RouterGroup) {
+	path := root.Group("/volumes")
+
+	path.GET("", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		result, err := docker.ListVolumes(ctx)
+		if err != nil {
+			respondVolumeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.GET("/:name", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		result, err := docker.GetVolume(ctx, c.Param("name"))
+		if err != nil {
+			respondVolumeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	})
+
+	path.POST("/:name/clone", func(c *gin.Context) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		// the body is optional; without a name the clone is named after the source
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+		defer cancel()
+		result, err := docker.CloneVolume(ctx, c.Param("name"), body.Name)
+		if err != nil {
+			respondVolumeError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, result)
+	})
+
+	path.DELETE("/:name", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		if err := docker.DeleteVolume(ctx, c.Param("name")); err != nil {
+			respondVolumeError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+func respondVolumeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, docker.ErrVolumeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "volume not found"})
+	case errors.Is(err, docker.ErrInvalidVolumeName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, docker.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,13 +2,18 @@ package dtos
 
 type Status int
 
+// New statuses are appended so the numeric values stored by clients stay stable.
 const (
 	Running Status = iota
 	Discarded
+	Failed
+	Starting
+	Stopped
+	Paused
 )
 
 func (s Status) String() string {
-	return [...]string{"running", "discarded"}[s]
+	return [...]string{"running", "discarded", "failed", "starting", "stopped", "paused"}[s]
 }
 
 func ToStatus(v any) Status {
@@ -23,6 +28,14 @@ func ToStatus(v any) Status {
 			return Running
 		case "discarded":
 			return Discarded
+		case "failed":
+			return Failed
+		case "starting":
+			return Starting
+		case "stopped":
+			return Stopped
+		case "paused":
+			return Paused
 		default:
 			return Discarded
 		}
@@ -44,6 +57,21 @@ type Container struct {
 	Networks    []string          `json:"networks"`
 	Status      Status            `json:"status"`
 	Type        string            `json:"type"`
+	ExitCode    int               `json:"exit_code"`
+	FinishedAt  int64             `json:"finished_at"`
+	Orphaned    bool              `json:"orphaned"`
+	ExpiresAt   int64             `json:"expires_at"` // unix milliseconds, 0 if the container never expires
+	Limits      *ResourceLimits   `json:"limits,omitempty"`
+	Aliases     []string          `json:"aliases,omitempty"`  // requested DNS names in addition to the server name
+	Snapshot    string            `json:"snapshot,omitempty"` // snapshot the container was started from, empty for the server image
+}
+
+// ContainerState is the part of a container record that follows the container runtime.
+type ContainerState struct {
+	Status     Status
+	ExitCode   int
+	FinishedAt int64
+	Orphaned   bool
 }
 
 func (c *Container) GetID() string {
@@ -85,3 +113,23 @@ func (c *Container) GetStatus() Status {
 func (c *Container) GetType() string {
 	return c.Type
 }
+
+func (c *Container) GetExitCode() int {
+	return c.ExitCode
+}
+
+func (c *Container) GetFinishedAt() int64 {
+	return c.FinishedAt
+}
+
+func (c *Container) IsOrphaned() bool {
+	return c.Orphaned
+}
+
+func (c *Container) GetExpiresAt() int64 {
+	return c.ExpiresAt
+}
+
+func (c *Container) GetLimits() *ResourceLimits {
+	return c.Limits
+}
@@ -0,0 +1,51 @@
+package dtos
+
+import "encoding/json"
+
+// Environment is a named set of servers that share a network and are started and torn down
+// together.
+type Environment struct {
+	ID        string              `json:"id"`
+	Name      string              `json:"name"`
+	Network   string              `json:"network"`
+	Members   []EnvironmentMember `json:"members"`
+	Status    Status              `json:"status"`
+	Error     string              `json:"error,omitempty"`
+	CreatedAt int64               `json:"created_at"`
+}
+
+// EnvironmentMember is a server of an environment and the container started for it.
+type EnvironmentMember struct {
+	// Name is unique in the environment and the DNS alias of the server in its network.
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// DependsOn names the members that must be ready before this one is started.
+	DependsOn []string `json:"depends_on"`
+	// Configuration is the ServerConfiguration of the member as JSON.
+	Configuration json.RawMessage `json:"configuration"`
+	ContainerID   string          `json:"container_id"`
+}
+
+func (e *Environment) GetID() string {
+	return e.ID
+}
+
+func (e *Environment) GetName() string {
+	return e.Name
+}
+
+func (e *Environment) GetNetwork() string {
+	return e.Network
+}
+
+func (e *Environment) GetMembers() []EnvironmentMember {
+	return e.Members
+}
+
+func (e *Environment) GetStatus() Status {
+	return e.Status
+}
+
+func (e *Environment) GetCreatedAt() int64 {
+	return e.CreatedAt
+}
@@ -0,0 +1,19 @@
+package dtos
+
+// ResourceLimits restricts the resources a container may use. Zero values mean unlimited.
+type ResourceLimits struct {
+	MemoryMB int64 `json:"memory_mb,omitempty"`
+	// CPUs is the number of CPUs the container may use, e.g. 0.5 (CPU quota).
+	CPUs float64 `json:"cpus,omitempty"`
+	// CPUShares is the relative CPU weight under contention (default 1024).
+	CPUShares int64    `json:"cpu_shares,omitempty"`
+	PidsLimit int64    `json:"pids_limit,omitempty"`
+	Ulimits   []Ulimit `json:"ulimits,omitempty"`
+}
+
+// Ulimit is a process resource limit such as nofile or nproc.
+type Ulimit struct {
+	Name string `json:"name"`
+	Soft int64  `json:"soft"`
+	Hard int64  `json:"hard"`
+}
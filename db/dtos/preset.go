@@ -0,0 +1,33 @@
+package dtos
+
+import "encoding/json"
+
+// Preset is a named server configuration template of a server type.
+type Preset struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Configuration is the stored ServerConfiguration as JSON.
+	Configuration json.RawMessage `json:"configuration"`
+	// BuiltIn presets are derived from the server catalog and cannot be changed.
+	BuiltIn   bool  `json:"built_in"`
+	CreatedAt int64 `json:"created_at"`
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+func (p *Preset) GetID() string {
+	return p.ID
+}
+
+func (p *Preset) GetName() string {
+	return p.Name
+}
+
+func (p *Preset) GetType() string {
+	return p.Type
+}
+
+func (p *Preset) GetConfiguration() json.RawMessage {
+	return p.Configuration
+}
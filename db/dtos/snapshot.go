@@ -0,0 +1,28 @@
+package dtos
+
+// Snapshot is a committed image of a managed container together with copies of its volumes.
+type Snapshot struct {
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	Type          string            `json:"type"`
+	ContainerID   string            `json:"container_id"`
+	ContainerName string            `json:"container_name"`
+	Volumes       map[string]string `json:"volumes"` // volume name -> container path
+	CreatedAt     int64             `json:"created_at"`
+}
+
+func (s *Snapshot) GetName() string {
+	return s.Name
+}
+
+func (s *Snapshot) GetImage() string {
+	return s.Image
+}
+
+func (s *Snapshot) GetType() string {
+	return s.Type
+}
+
+func (s *Snapshot) GetVolumes() map[string]string {
+	return s.Volumes
+}
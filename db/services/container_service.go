@@ -14,6 +14,32 @@ import (
 
 const containersCollectionName = "containers"
 
+var (
+	ErrContainerNotFound = errors.New("container not found")
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+// allowedTransitions lists the statuses a container may move to from each status.
+// Discarded is final.
+var allowedTransitions = map[dtos.Status][]dtos.Status{
+	dtos.Starting:  {dtos.Running, dtos.Failed, dtos.Stopped, dtos.Discarded},
+	dtos.Running:   {dtos.Starting, dtos.Stopped, dtos.Paused, dtos.Failed, dtos.Discarded},
+	dtos.Paused:    {dtos.Running, dtos.Stopped, dtos.Discarded},
+	dtos.Stopped:   {dtos.Starting, dtos.Running, dtos.Failed, dtos.Discarded},
+	dtos.Failed:    {dtos.Starting, dtos.Running, dtos.Stopped, dtos.Discarded},
+	dtos.Discarded: {},
+}
+
+// ValidateTransition returns ErrInvalidTransition if a container cannot move from one status to the other.
+func ValidateTransition(from dtos.Status, to dtos.Status) error {
+	for _, s := range allowedTransitions[from] {
+		if s == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
+
 func CreateContainer(c *dtos.Container) (string, error) {
 	if db.DB == nil {
 		return "", errors.New("pocketbase not initialized")
@@ -31,16 +57,7 @@ func CreateContainer(c *dtos.Container) (string, error) {
 
 		rec := core.NewRecord(coll)
 		// Do not set the system 'id' field manually; PocketBase manages it.
-		rec.Set("container_id", c.ID)
-		rec.Set("name", c.Name)
-		rec.Set("image", c.Image)
-		rec.Set("created_at", c.CreatedAt)
-		rec.Set("environment", c.Environment)
-		rec.Set("ports", c.Ports)
-		rec.Set("volumes", c.Volumes)
-		rec.Set("networks", c.Networks)
-		rec.Set("status", c.Status)
-		rec.Set("type", c.Type)
+		setContainerFields(rec, c)
 
 		if err := txApp.SaveWithContext(context.Background(), rec); err != nil {
 			return err
@@ -80,33 +97,27 @@ func ListContainers() ([]*dtos.Container, error) {
 
 	out := make([]*dtos.Container, 0, len(recs))
 	for _, r := range recs {
-		c := &dtos.Container{}
-		c.ID = db.ToString(r.Get("container_id"))
-		c.Type = db.ToString(r.Get("type"))
-		c.Name = db.ToString(r.Get("name"))
-		c.Image = db.ToString(r.Get("image"))
-		c.CreatedAt = db.ToInt64(r.Get("created_at"))
-		c.Status = dtos.ToStatus(db.ToString(r.Get("status")))
-		c.Networks = r.GetStringSlice("networks")
-
-		var environment map[string]string
-		r.UnmarshalJSONField("environment", &environment)
-		c.Environment = db.ToStringMap(environment)
-
-		var volumes map[string]string
-		r.UnmarshalJSONField("volumes", &volumes)
-		c.Volumes = db.ToStringMap(volumes)
-
-		var ports map[string]int
-		r.UnmarshalJSONField("ports", &ports)
-		c.Ports = db.ToIntMap(ports)
-
-		out = append(out, c)
+		out = append(out, containerFromRecord(r))
 	}
 
 	return out, nil
 }
 
+// ListActiveContainers returns all containers that have not been discarded.
+func ListActiveContainers() ([]*dtos.Container, error) {
+	all, err := ListContainers()
+	if err != nil {
+		return nil, err
+	}
+	active := make([]*dtos.Container, 0)
+	for _, c := range all {
+		if c.Status != dtos.Discarded {
+			active = append(active, c)
+		}
+	}
+	return active, nil
+}
+
 func ListRunningContainers() ([]*dtos.Container, error) {
 	all, err := ListContainers()
 	if err != nil {
@@ -137,7 +148,7 @@ func GetContainerIdByDockerId(id string) (string, error) {
 	rec, err := db.DB.App.FindFirstRecordByData(coll, "container_id", id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("container with id %s not found", id)
+			return "", fmt.Errorf("%w: %s", ErrContainerNotFound, id)
 		}
 		return "", err
 	}
@@ -167,28 +178,7 @@ func GetContainer(id string) (*dtos.Container, error) {
 		return nil, err
 	}
 
-	c := &dtos.Container{}
-	c.ID = db.ToString(rec.Get("container_id"))
-	c.Type = db.ToString(rec.Get("type"))
-	c.Name = db.ToString(rec.Get("name"))
-	c.Image = db.ToString(rec.Get("image"))
-	c.CreatedAt = db.ToInt64(rec.Get("created_at"))
-	c.Status = dtos.ToStatus(db.ToString(rec.Get("status")))
-	c.Networks = rec.GetStringSlice("networks")
-
-	var environment map[string]string
-	rec.UnmarshalJSONField("environment", &environment)
-	c.Environment = db.ToStringMap(environment)
-
-	var volumes map[string]string
-	rec.UnmarshalJSONField("volumes", &volumes)
-	c.Volumes = db.ToStringMap(volumes)
-
-	var ports map[string]int
-	rec.UnmarshalJSONField("ports", &ports)
-	c.Ports = db.ToIntMap(ports)
-
-	return c, nil
+	return containerFromRecord(rec), nil
 }
 
 func UpdateContainer(id string, updates *dtos.Container) error {
@@ -215,16 +205,7 @@ func UpdateContainer(id string, updates *dtos.Container) error {
 		}
 
 		// set fields from updates (overwrite)
-		rec.Set("container_id", updates.ID)
-		rec.Set("name", updates.Name)
-		rec.Set("image", updates.Image)
-		rec.Set("created_at", updates.CreatedAt)
-		rec.Set("environment", updates.Environment)
-		rec.Set("ports", updates.Ports)
-		rec.Set("volumes", updates.Volumes)
-		rec.Set("networks", updates.Networks)
-		rec.Set("status", updates.Status)
-		rec.Set("type", updates.Type)
+		setContainerFields(rec, updates)
 
 		if err := txApp.SaveWithContext(context.Background(), rec); err != nil {
 			log.Printf("Update SaveWithContext error for id=%s: %v", id, err)
@@ -235,28 +216,122 @@ func UpdateContainer(id string, updates *dtos.Container) error {
 	})
 }
 
+// UpdateContainerStatus moves a container to status after validating the transition. The
+// record is read in the same transaction, so concurrent status changes cannot be lost.
 func UpdateContainerStatus(id string, status dtos.Status) error {
-	container, err := GetContainer(id)
-	if err != nil {
-		return err
-	}
-	container.Status = status
-	return UpdateContainer(id, container)
+	return updateContainerRecord(id, func(rec *core.Record) error {
+		if err := ValidateTransition(dtos.ToStatus(rec.GetString("status")), status); err != nil {
+			return err
+		}
+		rec.Set("status", status)
+		return nil
+	})
 }
 
-func DiscardAllOldContainers() {
-	containers, err := ListContainers()
-	if err != nil {
-		log.Printf("Error listing containers: %v", err)
-		return
-	}
-	for _, c := range containers {
-		if c.Status != dtos.Running {
-			log.Printf("Discarding old container id=%s name=%ss", c.ID, c.Name)
-			c.Status = dtos.Discarded
-			if err := UpdateContainer(c.ID, c); err != nil {
-				log.Printf("Error discarding container id=%s: %v", c.ID, err)
+// UpdateContainerState writes the runtime state of a container and leaves all other fields
+// alone. A status change is validated against the stored status, so a container discarded
+// in the meantime stays discarded.
+func UpdateContainerState(id string, state dtos.ContainerState) error {
+	return updateContainerRecord(id, func(rec *core.Record) error {
+		if current := dtos.ToStatus(rec.GetString("status")); current != state.Status {
+			if err := ValidateTransition(current, state.Status); err != nil {
+				return err
 			}
 		}
+		rec.Set("status", state.Status)
+		rec.Set("exit_code", state.ExitCode)
+		rec.Set("finished_at", state.FinishedAt)
+		rec.Set("orphaned", state.Orphaned)
+		return nil
+	})
+}
+
+// updateContainerRecord reads the record of container id, applies update and saves it in
+// one transaction.
+func updateContainerRecord(id string, update func(rec *core.Record) error) error {
+	if db.DB == nil {
+		return errors.New("pocketbase not initialized")
 	}
+
+	return db.DB.App.RunInTransaction(func(txApp core.App) error {
+		coll, err := txApp.FindCollectionByNameOrId(containersCollectionName)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("collection %s not found", containersCollectionName)
+			}
+			return err
+		}
+
+		rec, err := txApp.FindFirstRecordByData(coll, "container_id", id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrContainerNotFound, id)
+			}
+			return err
+		}
+		if err := update(rec); err != nil {
+			return err
+		}
+		return txApp.SaveWithContext(context.Background(), rec)
+	})
+}
+
+// setContainerFields copies all container fields onto rec.
+func setContainerFields(rec *core.Record, c *dtos.Container) {
+	rec.Set("container_id", c.ID)
+	rec.Set("name", c.Name)
+	rec.Set("image", c.Image)
+	rec.Set("created_at", c.CreatedAt)
+	rec.Set("environment", c.Environment)
+	rec.Set("ports", c.Ports)
+	rec.Set("volumes", c.Volumes)
+	rec.Set("networks", c.Networks)
+	rec.Set("status", c.Status)
+	rec.Set("type", c.Type)
+	rec.Set("exit_code", c.ExitCode)
+	rec.Set("finished_at", c.FinishedAt)
+	rec.Set("orphaned", c.Orphaned)
+	rec.Set("expires_at", c.ExpiresAt)
+	rec.Set("limits", c.Limits)
+	rec.Set("aliases", c.Aliases)
+	rec.Set("snapshot", c.Snapshot)
+}
+
+// containerFromRecord converts a containers record into its DTO.
+func containerFromRecord(rec *core.Record) *dtos.Container {
+	c := &dtos.Container{}
+	c.ID = db.ToString(rec.Get("container_id"))
+	c.Type = db.ToString(rec.Get("type"))
+	c.Name = db.ToString(rec.Get("name"))
+	c.Image = db.ToString(rec.Get("image"))
+	c.CreatedAt = db.ToInt64(rec.Get("created_at"))
+	c.Status = dtos.ToStatus(db.ToString(rec.Get("status")))
+	c.Networks = rec.GetStringSlice("networks")
+	c.ExitCode = rec.GetInt("exit_code")
+	c.FinishedAt = db.ToInt64(rec.Get("finished_at"))
+	c.Orphaned = rec.GetBool("orphaned")
+	c.ExpiresAt = db.ToInt64(rec.Get("expires_at"))
+	c.Snapshot = db.ToString(rec.Get("snapshot"))
+
+	var environment map[string]string
+	rec.UnmarshalJSONField("environment", &environment)
+	c.Environment = db.ToStringMap(environment)
+
+	var volumes map[string]string
+	rec.UnmarshalJSONField("volumes", &volumes)
+	c.Volumes = db.ToStringMap(volumes)
+
+	var ports map[string]int
+	rec.UnmarshalJSONField("ports", &ports)
+	c.Ports = db.ToIntMap(ports)
+
+	var limits *dtos.ResourceLimits
+	rec.UnmarshalJSONField("limits", &limits)
+	c.Limits = limits
+
+	var aliases []string
+	rec.UnmarshalJSONField("aliases", &aliases)
+	c.Aliases = aliases
+
+	return c
 }
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+func TestValidateTransition(t *testing.T) {
+	cases := []struct {
+		from, to dtos.Status
+		ok       bool
+	}{
+		{dtos.Starting, dtos.Running, true},
+		{dtos.Running, dtos.Paused, true},
+		{dtos.Paused, dtos.Running, true},
+		{dtos.Running, dtos.Stopped, true},
+		{dtos.Stopped, dtos.Starting, true},
+		{dtos.Failed, dtos.Starting, true},
+		{dtos.Stopped, dtos.Discarded, true},
+		{dtos.Stopped, dtos.Paused, false},
+		{dtos.Paused, dtos.Paused, false},
+		{dtos.Discarded, dtos.Running, false},
+		{dtos.Discarded, dtos.Discarded, false},
+	}
+
+	for _, tc := range cases {
+		err := ValidateTransition(tc.from, tc.to)
+		if tc.ok && err != nil {
+			t.Errorf("%s -> %s: unexpected error %v", tc.from, tc.to, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%s -> %s: expected ErrInvalidTransition, got %v", tc.from, tc.to, err)
+		}
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	for s := dtos.Running; s <= dtos.Paused; s++ {
+		if got := dtos.ToStatus(s.String()); got != s {
+			t.Errorf("ToStatus(%q) = %v, want %v", s.String(), got, s)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/tim0-12432/simple-test-server/db"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+const environmentsCollectionName = "environments"
+
+var (
+	ErrEnvironmentNotFound = errors.New("environment not found")
+	ErrEnvironmentExists   = errors.New("environment already exists")
+)
+
+// CreateEnvironment stores e and returns its generated id.
+func CreateEnvironment(e *dtos.Environment) (string, error) {
+	if db.DB == nil {
+		return "", errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, environmentsCollectionName)
+	if err != nil {
+		return "", err
+	}
+	rec := core.NewRecord(coll)
+	setEnvironmentFields(rec, e)
+	// the names of environments that are not discarded have a unique index
+	if err := db.DB.App.SaveWithContext(context.Background(), rec); isUniqueViolation(err) {
+		return "", fmt.Errorf("%w: %s", ErrEnvironmentExists, e.Name)
+	} else if err != nil {
+		return "", err
+	}
+	return rec.Id, nil
+}
+
+func ListEnvironments() ([]*dtos.Environment, error) {
+	if db.DB == nil {
+		return nil, errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, environmentsCollectionName)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]*core.Record, 0)
+	if err := db.DB.App.RecordQuery(coll).OrderBy("created_at DESC").All(&recs); err != nil {
+		return nil, err
+	}
+
+	out := make([]*dtos.Environment, 0, len(recs))
+	for _, r := range recs {
+		out = append(out, environmentFromRecord(r))
+	}
+	return out, nil
+}
+
+func GetEnvironment(id string) (*dtos.Environment, error) {
+	rec, err := findEnvironmentRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	return environmentFromRecord(rec), nil
+}
+
+// UpdateEnvironment overwrites the environment id with e.
+func UpdateEnvironment(id string, e *dtos.Environment) error {
+	rec, err := findEnvironmentRecord(id)
+	if err != nil {
+		return err
+	}
+	setEnvironmentFields(rec, e)
+	return db.DB.App.SaveWithContext(context.Background(), rec)
+}
+
+func findEnvironmentRecord(id string) (*core.Record, error) {
+	if db.DB == nil {
+		return nil, errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, environmentsCollectionName)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := db.DB.App.FindRecordById(coll, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrEnvironmentNotFound, id)
+		}
+		return nil, err
+	}
+	return rec, nil
+}
+
+func setEnvironmentFields(rec *core.Record, e *dtos.Environment) {
+	rec.Set("name", e.Name)
+	rec.Set("network", e.Network)
+	rec.Set("members", e.Members)
+	rec.Set("status", e.Status.String())
+	rec.Set("error", e.Error)
+	rec.Set("created_at", e.CreatedAt)
+}
+
+func environmentFromRecord(rec *core.Record) *dtos.Environment {
+	e := &dtos.Environment{}
+	e.ID = rec.Id
+	e.Name = db.ToString(rec.Get("name"))
+	e.Network = db.ToString(rec.Get("network"))
+	e.Status = dtos.ToStatus(db.ToString(rec.Get("status")))
+	e.Error = db.ToString(rec.Get("error"))
+	e.CreatedAt = db.ToInt64(rec.Get("created_at"))
+
+	rec.UnmarshalJSONField("members", &e.Members)
+	if e.Members == nil {
+		e.Members = []dtos.EnvironmentMember{}
+	}
+	return e
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/tim0-12432/simple-test-server/db"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+const presetsCollectionName = "presets"
+
+var (
+	ErrPresetNotFound = errors.New("preset not found")
+	ErrPresetExists   = errors.New("preset already exists")
+)
+
+// CreatePreset stores p and returns its generated id.
+func CreatePreset(p *dtos.Preset) (string, error) {
+	if db.DB == nil {
+		return "", errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, presetsCollectionName)
+	if err != nil {
+		return "", err
+	}
+	rec := core.NewRecord(coll)
+	setPresetFields(rec, p)
+	if err := savePreset(rec, p); err != nil {
+		return "", err
+	}
+	return rec.Id, nil
+}
+
+func ListPresets() ([]*dtos.Preset, error) {
+	if db.DB == nil {
+		return nil, errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, presetsCollectionName)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]*core.Record, 0)
+	if err := db.DB.App.RecordQuery(coll).OrderBy("type ASC", "name ASC").All(&recs); err != nil {
+		return nil, err
+	}
+
+	out := make([]*dtos.Preset, 0, len(recs))
+	for _, r := range recs {
+		out = append(out, presetFromRecord(r))
+	}
+	return out, nil
+}
+
+func GetPreset(id string) (*dtos.Preset, error) {
+	rec, err := findPresetRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	return presetFromRecord(rec), nil
+}
+
+// UpdatePreset overwrites the preset id with p.
+func UpdatePreset(id string, p *dtos.Preset) error {
+	rec, err := findPresetRecord(id)
+	if err != nil {
+		return err
+	}
+	setPresetFields(rec, p)
+	return savePreset(rec, p)
+}
+
+// savePreset saves rec and reports a name taken for the type as ErrPresetExists.
+func savePreset(rec *core.Record, p *dtos.Preset) error {
+	err := db.DB.App.SaveWithContext(context.Background(), rec)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s for %s", ErrPresetExists, p.Name, p.Type)
+	}
+	return err
+}
+
+func DeletePreset(id string) error {
+	rec, err := findPresetRecord(id)
+	if err != nil {
+		return err
+	}
+	return db.DB.App.Delete(rec)
+}
+
+func findPresetRecord(id string) (*core.Record, error) {
+	if db.DB == nil {
+		return nil, errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, presetsCollectionName)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := db.DB.App.FindRecordById(coll, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrPresetNotFound, id)
+		}
+		return nil, err
+	}
+	return rec, nil
+}
+
+func setPresetFields(rec *core.Record, p *dtos.Preset) {
+	rec.Set("name", p.Name)
+	rec.Set("type", p.Type)
+	rec.Set("description", p.Description)
+	rec.Set("configuration", p.Configuration)
+	rec.Set("created_at", p.CreatedAt)
+	rec.Set("updated_at", p.UpdatedAt)
+}
+
+func presetFromRecord(rec *core.Record) *dtos.Preset {
+	p := &dtos.Preset{}
+	p.ID = rec.Id
+	p.Name = db.ToString(rec.Get("name"))
+	p.Type = db.ToString(rec.Get("type"))
+	p.Description = db.ToString(rec.Get("description"))
+	p.CreatedAt = db.ToInt64(rec.Get("created_at"))
+	p.UpdatedAt = db.ToInt64(rec.Get("updated_at"))
+
+	rec.UnmarshalJSONField("configuration", &p.Configuration)
+	return p
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/tim0-12432/simple-test-server/db"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+const snapshotsCollectionName = "snapshots"
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+func CreateSnapshot(s *dtos.Snapshot) error {
+	if db.DB == nil {
+		return errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, snapshotsCollectionName)
+	if err != nil {
+		return err
+	}
+	rec := core.NewRecord(coll)
+	rec.Set("name", s.Name)
+	rec.Set("image", s.Image)
+	rec.Set("type", s.Type)
+	rec.Set("container_id", s.ContainerID)
+	rec.Set("container_name", s.ContainerName)
+	rec.Set("volumes", s.Volumes)
+	rec.Set("created_at", s.CreatedAt)
+	return db.DB.App.SaveWithContext(context.Background(), rec)
+}
+
+func ListSnapshots() ([]*dtos.Snapshot, error) {
+	if db.DB == nil {
+		return nil, errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, snapshotsCollectionName)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]*core.Record, 0)
+	if err := db.DB.App.RecordQuery(coll).OrderBy("created_at DESC").All(&recs); err != nil {
+		return nil, err
+	}
+
+	out := make([]*dtos.Snapshot, 0, len(recs))
+	for _, r := range recs {
+		out = append(out, snapshotFromRecord(r))
+	}
+	return out, nil
+}
+
+func GetSnapshot(name string) (*dtos.Snapshot, error) {
+	rec, err := findSnapshotRecord(name)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotFromRecord(rec), nil
+}
+
+func DeleteSnapshot(name string) error {
+	rec, err := findSnapshotRecord(name)
+	if err != nil {
+		return err
+	}
+	return db.DB.App.Delete(rec)
+}
+
+func findSnapshotRecord(name string) (*core.Record, error) {
+	if db.DB == nil {
+		return nil, errors.New("pocketbase not initialized")
+	}
+
+	coll, err := findCollection(db.DB.App, snapshotsCollectionName)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := db.DB.App.FindFirstRecordByData(coll, "name", name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+		}
+		return nil, err
+	}
+	return rec, nil
+}
+
+// findCollection returns the collection name or a descriptive error if the migration did not run.
+func findCollection(app core.App, name string) (*core.Collection, error) {
+	coll, err := app.FindCollectionByNameOrId(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("collection %s not found", name)
+		}
+		return nil, err
+	}
+	return coll, nil
+}
+
+// isUniqueViolation reports whether saving a record failed because of a unique index.
+func isUniqueViolation(err error) bool {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	for _, e := range errs {
+		if ve, ok := e.(validation.Error); ok && ve.Code() == "validation_not_unique" {
+			return true
+		}
+	}
+	return false
+}
+
+func snapshotFromRecord(rec *core.Record) *dtos.Snapshot {
+	s := &dtos.Snapshot{}
+	s.Name = db.ToString(rec.Get("name"))
+	s.Image = db.ToString(rec.Get("image"))
+	s.Type = db.ToString(rec.Get("type"))
+	s.ContainerID = db.ToString(rec.Get("container_id"))
+	s.ContainerName = db.ToString(rec.Get("container_name"))
+	s.CreatedAt = db.ToInt64(rec.Get("created_at"))
+
+	var volumes map[string]string
+	rec.UnmarshalJSONField("volumes", &volumes)
+	s.Volumes = db.ToStringMap(volumes)
+	return s
+}
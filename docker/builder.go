@@ -2,54 +2,116 @@ package docker
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
-	"os/exec"
-	"strings"
+	"os"
+	"path/filepath"
 	"time"
+
+	"github.com/tim0-12432/simple-test-server/config"
 )
 
 const CUSTOM_IMAGE_PATH = "./custom_images/"
 
-func CheckIfExistsLocally(imageName string) bool {
-	args := []string{"image", "inspect", imageName}
+// ContextHashLabel stores the hash of the build context a custom image was built from.
+const ContextHashLabel = "context_hash"
+
+// defaultBuildTimeout is used when BUILD_TIMEOUT_SECONDS is not configured.
+const defaultBuildTimeout = 60 * time.Second
+
+// customImageDir is the directory holding the build contexts; tests point it to a temp dir.
+var customImageDir = CUSTOM_IMAGE_PATH
 
+func CheckIfExistsLocally(imageName string) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	log.Printf("Running Docker command: docker %s", strings.Join(args, " "))
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	out, err := cmd.CombinedOutput()
+	exists, err := Runtime.ImageExists(ctx, imageName)
 	if err != nil {
-		fmt.Errorf("docker run failed: %v - %s", err, strings.TrimSpace(string(out)))
+		log.Printf("Failed to inspect image %s: %v", imageName, err)
 		return false
 	}
+	return exists
+}
 
-	if strings.Contains(string(out), "No such image") {
-		return false
+// BuildCustomDockerImage builds imageName from the directory buildContext below custom_images
+// unless an image built from the same context already exists. Build output is passed to
+// output line by line when it is set. Cancelling ctx aborts the build.
+func BuildCustomDockerImage(ctx context.Context, imageName string, buildContext string, output func(line string)) error {
+	path := filepath.Join(customImageDir, buildContext)
+	hash, err := contextHash(path)
+	if err != nil {
+		return fmt.Errorf("hash build context %s: %w", path, err)
 	}
-	return true
-}
 
-func BuildCustomDockerImage(imageName string) error {
-	if !CheckIfExistsLocally(imageName) {
-		path := CUSTOM_IMAGE_PATH + strings.Split(imageName, ":")[0]
-		dockerfilePath := path + "/Dockerfile"
+	ctx, cancel := context.WithTimeout(ctx, buildTimeout())
+	defer cancel()
 
-		args := []string{"build", "-t", imageName, "-f", dockerfilePath, path}
+	current, err := Runtime.InspectImage(ctx, imageName)
+	switch {
+	case err == nil && current.Labels[ContextHashLabel] == hash:
+		log.Printf("Docker image %s is up to date, skipping build", imageName)
+		return nil
+	case err == nil:
+		log.Printf("Build context of %s changed, rebuilding", imageName)
+	case !errors.Is(err, ErrImageNotFound):
+		return fmt.Errorf("inspect image %s: %w", imageName, err)
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-		defer cancel()
+	labels := managedLabels()
+	labels[ContextHashLabel] = hash
+
+	log.Printf("Building image %s from %s", imageName, path)
+	err = Runtime.BuildImage(ctx, BuildOptions{Tag: imageName, ContextDir: path, Dockerfile: "Dockerfile", Labels: labels, Output: output})
+	if err != nil {
+		return fmt.Errorf("image build failed: %w", err)
+	}
+	log.Printf("Docker image %s built successfully", imageName)
+	return nil
+}
 
-		log.Printf("Running Docker command: docker %s", strings.Join(args, " "))
-		cmd := exec.CommandContext(ctx, "docker", args...)
-		out, err := cmd.CombinedOutput()
+// contextHash returns a SHA-256 over the paths, modes and contents of all files below dir.
+// WalkDir visits entries in lexical order, so the hash is stable.
+func contextHash(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", filepath.ToSlash(rel), info.Mode())
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
 		if err != nil {
-			return fmt.Errorf("docker build failed: %v - %s", err, strings.TrimSpace(string(out)))
+			return err
 		}
-		log.Printf("Docker image %s built successfully", imageName)
-	} else {
-		log.Printf("Docker image %s already exists locally, skipping build", imageName)
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
-	return nil
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func buildTimeout() time.Duration {
+	if config.EnvConfig == nil || config.EnvConfig.BuildTimeoutSeconds <= 0 {
+		return defaultBuildTimeout
+	}
+	return time.Duration(config.EnvConfig.BuildTimeoutSeconds) * time.Second
 }
@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useCustomImageDir points the image builder to a temp dir holding a build context for each
+// of the given image names and returns the temp dir.
+func useCustomImageDir(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		writeFile(t, filepath.Join(dir, name, "Dockerfile"), "FROM scratch\n")
+	}
+	old := customImageDir
+	customImageDir = dir
+	t.Cleanup(func() { customImageDir = old })
+	return dir
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildCustomDockerImage_RebuildsOnlyWhenContextChanges(t *testing.T) {
+	rt := useFakeRuntime(t)
+	dir := useCustomImageDir(t, "custom-web")
+
+	for i := 0; i < 2; i++ {
+		if err := BuildCustomDockerImage(context.Background(), "custom-web:latest", "custom-web", nil); err != nil {
+			t.Fatalf("build %d: %v", i, err)
+		}
+	}
+	if len(rt.Built) != 1 {
+		t.Fatalf("expected an unchanged context to be built once, got %d builds", len(rt.Built))
+	}
+	first := rt.Built[0].Labels[ContextHashLabel]
+	if first == "" || rt.Built[0].Labels[ManagedByLabel] != ManagedByValue {
+		t.Fatalf("expected hash and managed labels, got %v", rt.Built[0].Labels)
+	}
+
+	writeFile(t, filepath.Join(dir, "custom-web", "nginx.conf"), "server {}\n")
+	if err := BuildCustomDockerImage(context.Background(), "custom-web:latest", "custom-web", nil); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if len(rt.Built) != 2 || rt.Built[1].Labels[ContextHashLabel] == first {
+		t.Fatalf("expected a rebuild with a new hash, got %+v", rt.Built)
+	}
+}
+
+func TestBuildCustomDockerImage_RebuildsImagesWithoutHash(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useCustomImageDir(t, "custom-mqtt")
+	rt.Images["custom-mqtt:latest"] = true
+
+	if err := BuildCustomDockerImage(context.Background(), "custom-mqtt:latest", "custom-mqtt", nil); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(rt.Built) != 1 {
+		t.Fatalf("expected image built before hashing to be rebuilt, got %d builds", len(rt.Built))
+	}
+}
+
+func TestContextHash_DependsOnContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.conf"), "one")
+	h1, err := contextHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := contextHash(dir)
+	writeFile(t, filepath.Join(dir, "a.conf"), "two")
+	h3, _ := contextHash(dir)
+	if h1 != h2 || h1 == h3 {
+		t.Fatalf("unexpected hashes: %s %s %s", h1, h2, h3)
+	}
+}
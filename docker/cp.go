@@ -3,22 +3,21 @@ package docker
 import (
 	"context"
 	"fmt"
-	"os/exec"
-	"strings"
+	"path"
 	"time"
 )
 
-// CopyFileToContainer copies a file from the host into the container using `docker cp` with a timeout.
+// CopyFileToContainer copies a file from the host into the container with a timeout.
 func CopyFileToContainer(ctx context.Context, containerName, srcPath, destPath string, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// docker cp <src> <container>:<dest>
-	destination := fmt.Sprintf("%s:%s", containerName, destPath)
-	cmd := exec.CommandContext(ctx, "docker", "cp", srcPath, destination)
-	out, err := cmd.CombinedOutput()
+	archive, err := tarFile(srcPath, path.Base(destPath))
 	if err != nil {
-		return fmt.Errorf("docker cp failed: %w - %s", err, strings.TrimSpace(string(out)))
+		return fmt.Errorf("copy to container: %w", err)
+	}
+	if err := Runtime.CopyToContainer(ctx, containerName, path.Dir(destPath), archive); err != nil {
+		return fmt.Errorf("copy to container: %w", err)
 	}
 	return nil
 }
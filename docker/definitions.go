@@ -0,0 +1,339 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+	"gopkg.in/yaml.v3"
+)
+
+// DefinitionVersion is the version of the definition documents written by ExportContainers.
+// Documents of other versions are rejected on import.
+const DefinitionVersion = 1
+
+// Definition is a versioned document describing a set of servers, so they can be committed
+// next to a test suite and recreated elsewhere.
+type Definition struct {
+	Version int                `json:"version"`
+	Servers []DefinitionServer `json:"servers"`
+}
+
+// DefinitionServer is a server of a definition document.
+type DefinitionServer struct {
+	Type          string              `json:"type"`
+	Configuration ServerConfiguration `json:"configuration"`
+}
+
+// ImportedServer is a server started from a definition document.
+type ImportedServer struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	// ReqID is the id of the progress stream of the start.
+	ReqID string `json:"reqId"`
+}
+
+// ExportContainers describes the containers ids in a definition document. Without ids all
+// containers that are not discarded are exported.
+func ExportContainers(ids []string) (*Definition, error) {
+	var records []*dtos.Container
+	if len(ids) == 0 {
+		all, err := listContainerRecordsFn()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range all {
+			if r.Status != dtos.Discarded {
+				records = append(records, r)
+			}
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt < records[j].CreatedAt })
+	} else {
+		for _, id := range ids {
+			r, err := getContainerFn(id)
+			if err != nil {
+				return nil, err
+			}
+			if r.Status == dtos.Discarded {
+				return nil, fmt.Errorf("%w: container %s was discarded", ErrConflict, r.Name)
+			}
+			records = append(records, r)
+		}
+	}
+
+	// the servers of a document are started at once, so the start order of environment
+	// members cannot be reproduced
+	environments, err := listEnvironmentsFn()
+	if err != nil {
+		return nil, err
+	}
+	dependent := map[string]string{}
+	for _, e := range environments {
+		if e.Status == dtos.Discarded {
+			continue
+		}
+		for _, m := range e.Members {
+			if m.ContainerID != "" && len(m.DependsOn) > 0 {
+				dependent[m.ContainerID] = fmt.Sprintf("member %s of environment %s depends on %s", m.Name, e.Name, strings.Join(m.DependsOn, ", "))
+			}
+		}
+	}
+
+	def := &Definition{Version: DefinitionVersion, Servers: []DefinitionServer{}}
+	for _, r := range records {
+		if reason, ok := dependent[r.ID]; ok {
+			return nil, fmt.Errorf("%w: container %s cannot be exported, %s", ErrConflict, r.Name, reason)
+		}
+		server, err := servers.GetServerByType(r.Type)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", r.Name, err)
+		}
+		config, err := exportedConfiguration(r, server)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", r.Name, err)
+		}
+		def.Servers = append(def.Servers, DefinitionServer{Type: r.Type, Configuration: config})
+	}
+	return def, nil
+}
+
+// exportedConfiguration returns the configuration that recreates the container. Defaults
+// are left out so the document stays portable.
+func exportedConfiguration(r *dtos.Container, server *servers.ServerInformation) (ServerConfiguration, error) {
+	config := ServerConfiguration{Name: r.Name, Ports: []map[string]int{}, Env: map[string]string{}}
+	config.Aliases = slices.Clone(r.Aliases)
+
+	restored := map[string]string{}
+	if r.Snapshot != "" {
+		snapshot, err := getSnapshotFn(r.Snapshot)
+		if errors.Is(err, services.ErrSnapshotNotFound) {
+			return config, fmt.Errorf("%w: the snapshot %s it was started from was deleted", ErrConflict, r.Snapshot)
+		}
+		if err != nil {
+			return config, err
+		}
+		config.Snapshot = r.Snapshot
+		restored = snapshot.Volumes
+	}
+
+	containerPorts := make([]int, 0, len(r.Ports))
+	for containerPort := range r.Ports {
+		containerPorts = append(containerPorts, containerPort)
+	}
+	sort.Ints(containerPorts)
+	for _, containerPort := range containerPorts {
+		config.Ports = append(config.Ports, map[string]int{strconv.Itoa(r.Ports[containerPort]): containerPort})
+	}
+	for k, v := range r.Environment {
+		config.Env[k] = v
+	}
+
+	for name, target := range r.Volumes {
+		if isRestoredVolume(name, target, restored) {
+			continue
+		}
+		if name == managedVolumeName(r.Name, target) {
+			if !slices.Contains(server.Volumes, target) {
+				config.Volumes = append(config.Volumes, VolumeSpec{Target: target})
+			}
+			continue
+		}
+		config.Volumes = append(config.Volumes, VolumeSpec{Source: name, Target: target})
+	}
+	sort.Slice(config.Volumes, func(i, j int) bool { return config.Volumes[i].Target < config.Volumes[j].Target })
+
+	for _, network := range r.Networks {
+		if network != DefaultNetworkName {
+			config.Network = network
+			break
+		}
+	}
+	if r.Limits != nil {
+		if defaults, err := resolveLimits(server.Limits, nil); err != nil || !equalLimits(defaults, r.Limits) {
+			config.Limits = r.Limits
+		}
+	}
+	return config, nil
+}
+
+// isRestoredVolume reports whether the volume name mounted at target is a copy of one of the
+// snapshot volumes, which restoreSnapshotVolumes names <source>-<suffix>.
+func isRestoredVolume(name string, target string, snapshotVolumes map[string]string) bool {
+	for source, t := range snapshotVolumes {
+		if t == target && strings.HasPrefix(name, source+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// equalLimits compares two limits, treating a missing and an empty ulimit list as equal.
+func equalLimits(a *dtos.ResourceLimits, b *dtos.ResourceLimits) bool {
+	return a.MemoryMB == b.MemoryMB && a.CPUs == b.CPUs && a.CPUShares == b.CPUShares &&
+		a.PidsLimit == b.PidsLimit && slices.Equal(a.Ulimits, b.Ulimits)
+}
+
+// MarshalDefinition writes def as YAML. The keys are the JSON names of the API.
+func MarshalDefinition(def *Definition) ([]byte, error) {
+	// yaml.v3 keeps the field order of structs, so version and type come first
+	type documentServer struct {
+		Type          string `yaml:"type"`
+		Configuration any    `yaml:"configuration"`
+	}
+	doc := struct {
+		Version int              `yaml:"version"`
+		Servers []documentServer `yaml:"servers"`
+	}{Version: def.Version, Servers: []documentServer{}}
+
+	for _, s := range def.Servers {
+		b, err := json.Marshal(s.Configuration)
+		if err != nil {
+			return nil, err
+		}
+		var config any
+		if err := json.Unmarshal(b, &config); err != nil {
+			return nil, err
+		}
+		doc.Servers = append(doc.Servers, documentServer{Type: s.Type, Configuration: config})
+	}
+	return yaml.Marshal(doc)
+}
+
+// ParseDefinition reads a YAML or JSON definition document. Unknown fields are rejected, so
+// typos do not silently change the started servers.
+func ParseDefinition(content []byte) (*Definition, error) {
+	var raw any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%w: invalid YAML: %v", ErrInvalidDefinition, err)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid YAML: %v", ErrInvalidDefinition, err)
+	}
+
+	var def Definition
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&def); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	return &def, nil
+}
+
+// ValidateDefinition checks def against the server catalog. Invalid env variables and ports
+// are reported together as a *servers.ConfigError with keys servers.<index>.<field>.
+func ValidateDefinition(ctx context.Context, def *Definition) error {
+	if def.Version != DefinitionVersion {
+		return fmt.Errorf("%w: unsupported version %d, expected %d", ErrInvalidDefinition, def.Version, DefinitionVersion)
+	}
+	if len(def.Servers) == 0 {
+		return fmt.Errorf("%w: no servers", ErrInvalidDefinition)
+	}
+
+	fields := map[string]string{}
+	names := map[string]bool{}
+	for i, s := range def.Servers {
+		prefix := "servers." + strconv.Itoa(i) + "."
+		if _, err := servers.GetServerByType(s.Type); err != nil {
+			return fmt.Errorf("%w: server %d: %v", ErrInvalidDefinition, i, err)
+		}
+		err := ValidateConfiguration(s.Type, s.Configuration)
+		var configErr *servers.ConfigError
+		if errors.As(err, &configErr) {
+			for k, v := range configErr.Fields {
+				fields[prefix+k] = v
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("server %d: %w", i, err)
+		}
+
+		config := s.Configuration
+		if config.Name != "" {
+			if names[config.Name] {
+				return fmt.Errorf("%w: container name %s is used twice", ErrInvalidDefinition, config.Name)
+			}
+			names[config.Name] = true
+			if err := CheckContainerName(ctx, config.Name); err != nil {
+				return fmt.Errorf("server %d: %w", i, err)
+			}
+		}
+		if config.Network != "" && !resourceNamePattern.MatchString(config.Network) {
+			return fmt.Errorf("server %d: %w: %q", i, ErrInvalidNetworkName, config.Network)
+		}
+		for _, v := range config.Volumes {
+			if v.Source != "" && !resourceNamePattern.MatchString(v.Source) {
+				return fmt.Errorf("server %d: %w: %q", i, ErrInvalidVolumeName, v.Source)
+			}
+		}
+		if config.Snapshot != "" {
+			if _, err := loadSnapshot(ctx, config.Snapshot, s.Type); err != nil {
+				return fmt.Errorf("server %d: %w", i, err)
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return &servers.ConfigError{Fields: fields}
+	}
+	return nil
+}
+
+// ImportDefinition validates def, creates the networks and named volumes it refers to if
+// they are missing and starts every server in the background with StartServerWithProgress.
+func ImportDefinition(ctx context.Context, def *Definition) ([]ImportedServer, error) {
+	if err := ValidateDefinition(ctx, def); err != nil {
+		return nil, err
+	}
+
+	for _, s := range def.Servers {
+		if s.Configuration.Network != "" {
+			if err := ensureManagedNetwork(ctx, s.Configuration.Network); err != nil {
+				return nil, err
+			}
+		}
+		for _, v := range s.Configuration.Volumes {
+			if v.Source != "" {
+				if err := ensureVolume(ctx, v.Source, s.Type, v.Target); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	started := make([]ImportedServer, 0, len(def.Servers))
+	for _, s := range def.Servers {
+		reqId := uuid.New().String()
+		go StartServerWithProgress(reqId, s.Type, s.Configuration)
+		started = append(started, ImportedServer{Type: s.Type, Name: s.Configuration.Name, ReqID: reqId})
+	}
+	log.Printf("Importing %d servers from a definition", len(started))
+	return started, nil
+}
+
+// ensureVolume creates the named volume for a container path of serverType unless it exists.
+func ensureVolume(ctx context.Context, name string, serverType string, target string) error {
+	if _, err := Runtime.InspectVolume(ctx, name); err == nil {
+		return nil
+	} else if !errors.Is(err, ErrVolumeNotFound) {
+		return fmt.Errorf("inspect volume %s failed: %w", name, err)
+	}
+	labels := managedLabels()
+	labels[ServerTypeLabel] = serverType
+	labels[VolumeTargetLabel] = target
+	if _, err := Runtime.CreateVolume(ctx, name, labels); err != nil && !errors.Is(err, ErrConflict) {
+		return fmt.Errorf("create volume %s failed: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+func ftpRecord() *dtos.Container {
+	return &dtos.Container{
+		ID:          "ftp1",
+		Name:        "ftp-test",
+		Type:        "FTP",
+		Status:      dtos.Running,
+		Ports:       map[int]int{21: 2121, 20: 2020},
+		Environment: map[string]string{"FTP_USER": "bob", "FTP_PASS": "secret"},
+		Volumes: map[string]string{
+			"ftp-test-home-user": "/home/user",
+			"shared-data":        "/data",
+		},
+		Networks: []string{"ci"},
+		Limits:   &dtos.ResourceLimits{MemoryMB: 256, PidsLimit: 256},
+	}
+}
+
+func TestExportContainers_WritesPortableDocument(t *testing.T) {
+	useFakeRecords(t, ftpRecord(), &dtos.Container{ID: "old", Name: "old", Type: "MAIL", Status: dtos.Discarded})
+	useFakeEnvironments(t)
+
+	def, err := ExportContainers(nil)
+	if err != nil {
+		t.Fatalf("ExportContainers: %v", err)
+	}
+	if def.Version != DefinitionVersion || len(def.Servers) != 1 {
+		t.Fatalf("expected the ftp server only, got %+v", def)
+	}
+	config := def.Servers[0].Configuration
+	want := ServerConfiguration{
+		Name:    "ftp-test",
+		Ports:   []map[string]int{{"2020": 20}, {"2121": 21}},
+		Env:     map[string]string{"FTP_USER": "bob", "FTP_PASS": "secret"},
+		Volumes: []VolumeSpec{{Source: "shared-data", Target: "/data"}},
+		Network: "ci",
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Fatalf("unexpected configuration\n got %+v\nwant %+v", config, want)
+	}
+
+	content, err := MarshalDefinition(def)
+	if err != nil {
+		t.Fatalf("MarshalDefinition: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "version: 1\nservers:\n    - type: FTP\n") {
+		t.Fatalf("unexpected document:\n%s", content)
+	}
+	parsed, err := ParseDefinition(content)
+	if err != nil {
+		t.Fatalf("ParseDefinition: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, def) {
+		t.Fatalf("round trip changed the definition\n got %+v\nwant %+v", parsed, def)
+	}
+
+	if _, err := ExportContainers([]string{"old"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a discarded container, got %v", err)
+	}
+	if _, err := ExportContainers([]string{"missing"}); !errors.Is(err, ErrContainerNotFound) {
+		t.Fatalf("expected ErrContainerNotFound, got %v", err)
+	}
+}
+
+func TestExportContainers_KeepsAliasesAndSnapshot(t *testing.T) {
+	record := ftpRecord()
+	record.Aliases = []string{"files"}
+	record.Snapshot = "seeded"
+	record.Image = "simple-test-server-snapshot:seeded"
+	record.Volumes = map[string]string{"seeded-home-a1b2c3": "/home/user", "shared-data": "/data"}
+	useFakeRecords(t, record)
+	useFakeSnapshots(t, &dtos.Snapshot{Name: "seeded", Type: "FTP", Image: record.Image, Volumes: map[string]string{"seeded-home": "/home/user"}})
+	useFakeEnvironments(t)
+
+	def, err := ExportContainers(nil)
+	if err != nil {
+		t.Fatalf("ExportContainers: %v", err)
+	}
+	config := def.Servers[0].Configuration
+	if !reflect.DeepEqual(config.Aliases, []string{"files"}) || config.Snapshot != "seeded" {
+		t.Fatalf("expected the alias and the snapshot, got %+v", config)
+	}
+	// the copy of the snapshot volume is restored from the snapshot again
+	if !reflect.DeepEqual(config.Volumes, []VolumeSpec{{Source: "shared-data", Target: "/data"}}) {
+		t.Fatalf("unexpected volumes %+v", config.Volumes)
+	}
+
+	useFakeSnapshots(t)
+	if _, err := ExportContainers(nil); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a deleted snapshot, got %v", err)
+	}
+}
+
+func TestExportContainers_RejectsDependentEnvironmentMembers(t *testing.T) {
+	useFakeRecords(t,
+		&dtos.Container{ID: "c1", Name: "stack-mail", Type: "MAIL", Status: dtos.Running, Networks: []string{"stack"}, Aliases: []string{"mail"}},
+		&dtos.Container{ID: "c2", Name: "stack-ftp", Type: "FTP", Status: dtos.Running, Networks: []string{"stack"}, Aliases: []string{"ftp"}},
+	)
+	useFakeEnvironments(t, &dtos.Environment{ID: "env1", Name: "stack", Network: "stack", Status: dtos.Running, Members: []dtos.EnvironmentMember{
+		{Name: "mail", Type: "MAIL", ContainerID: "c1"},
+		{Name: "ftp", Type: "FTP", DependsOn: []string{"mail"}, ContainerID: "c2"},
+	}})
+
+	def, err := ExportContainers([]string{"c1"})
+	if err != nil {
+		t.Fatalf("ExportContainers: %v", err)
+	}
+	if config := def.Servers[0].Configuration; config.Network != "stack" || !reflect.DeepEqual(config.Aliases, []string{"mail"}) {
+		t.Fatalf("expected the member alias on the environment network, got %+v", config)
+	}
+	if _, err := ExportContainers(nil); !errors.Is(err, ErrConflict) || !strings.Contains(err.Error(), "depends on mail") {
+		t.Fatalf("expected ErrConflict for a member with dependencies, got %v", err)
+	}
+}
+
+func TestParseAndValidateDefinition(t *testing.T) {
+	useFakeRuntime(t)
+
+	if _, err := ParseDefinition([]byte("version: 1\nservers:\n  - type: FTP\n    config: {}\n")); !errors.Is(err, ErrInvalidDefinition) {
+		t.Fatalf("expected unknown fields to be rejected, got %v", err)
+	}
+
+	cases := map[string]struct {
+		doc  string
+		want error
+	}{
+		"version":      {"version: 2\nservers: [{type: FTP}]", ErrInvalidDefinition},
+		"no servers":   {"version: 1\nservers: []", ErrInvalidDefinition},
+		"unknown type": {"version: 1\nservers: [{type: NOPE}]", ErrInvalidDefinition},
+		"same name": {"version: 1\nservers: [{type: FTP, configuration: {name: a1}}, {type: MAIL, configuration: {name: a1}}]",
+			ErrInvalidDefinition},
+		"network": {"version: 1\nservers: [{type: FTP, configuration: {network: '-x'}}]", ErrInvalidNetworkName},
+		"limits":  {"version: 1\nservers: [{type: FTP, configuration: {limits: {memory_mb: -1}}}]", ErrInvalidLimits},
+	}
+	for name, tc := range cases {
+		def, err := ParseDefinition([]byte(tc.doc))
+		if err != nil {
+			t.Fatalf("%s: ParseDefinition: %v", name, err)
+		}
+		if err := ValidateDefinition(context.Background(), def); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+
+	def, err := ParseDefinition([]byte("version: 1\nservers:\n  - type: FTP\n  - type: MAIL\n    configuration:\n      env: {MH_STORAGE: disk}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateDefinition(context.Background(), def)
+	var configErr *servers.ConfigError
+	if !errors.As(err, &configErr) || configErr.Fields["servers.1.env.MH_STORAGE"] == "" {
+		t.Fatalf("expected field errors of the second server, got %v", err)
+	}
+}
+
+func TestImportDefinition_StartsServers(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakeRecords(t)
+	stubHostPorts(t)
+	stubProbe(t, nil)
+
+	def, err := ParseDefinition([]byte(`
+version: 1
+servers:
+  - type: FTP
+    configuration:
+      name: ftp-ci
+      network: ci
+      volumes:
+        - source: shared-data
+          target: /data
+  - type: MAIL
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, err := ImportDefinition(context.Background(), def)
+	if err != nil {
+		t.Fatalf("ImportDefinition: %v", err)
+	}
+	if len(started) != 2 || started[0].Name != "ftp-ci" || started[0].ReqID == "" || started[1].Type != "MAIL" {
+		t.Fatalf("unexpected started servers %+v", started)
+	}
+	if _, ok := rt.Networks["ci"]; !ok {
+		t.Fatal("expected network ci to be created")
+	}
+	if v, ok := rt.Volumes["shared-data"]; !ok || v.Labels[ServerTypeLabel] != "FTP" {
+		t.Fatalf("expected volume shared-data to be created for FTP, got %+v", v)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rt.mu.Lock()
+		runs := len(rt.Runs)
+		rt.mu.Unlock()
+		if runs == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 started containers, got %d", runs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, s := range started {
+		if events := collectEvents(t, s.ReqID); !hasEvent(events, "Started") {
+			t.Fatalf("expected %s to be started, got %+v", s.Type, events)
+		}
+	}
+}
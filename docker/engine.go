@@ -0,0 +1,1212 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// engineAPIVersion is the Docker Engine API version requested by EngineRuntime.
+// v1.41 is served by Docker 20.10+ and by the Podman compatibility API.
+const engineAPIVersion = "v1.41"
+
+// EngineRuntime implements ContainerRuntime on top of the Docker Engine REST API.
+type EngineRuntime struct {
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewEngineRuntime creates a runtime talking to the engine at host, which is either
+// a unix socket (unix:///var/run/docker.sock) or a TCP address (tcp://host:2375).
+func NewEngineRuntime(host string) (*EngineRuntime, error) {
+	client, baseURL, err := newEngineHTTPClient(host)
+	if err != nil {
+		return nil, err
+	}
+	return &EngineRuntime{name: "docker", baseURL: baseURL, client: client}, nil
+}
+
+func newEngineHTTPClient(host string) (*http.Client, string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid runtime host %q: %w", host, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &http.Client{Transport: transport}, "http://localhost", nil
+	case "tcp", "http":
+		return &http.Client{}, "http://" + u.Host, nil
+	case "https":
+		return &http.Client{}, "https://" + u.Host, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported runtime host scheme %q", u.Scheme)
+	}
+}
+
+func (e *EngineRuntime) Name() string {
+	return e.name
+}
+
+func (e *EngineRuntime) Ping(ctx context.Context) error {
+	resp, err := e.request(ctx, http.MethodGet, "/_ping", nil, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("ping", resp, nil)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (e *EngineRuntime) ImageExists(ctx context.Context, image string) (bool, error) {
+	resp, err := e.request(ctx, http.MethodGet, "/images/"+escapeImage(image)+"/json", nil, nil, "")
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		resp.Body.Close()
+		return true, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return false, nil
+	default:
+		return false, responseError("inspect image", resp, ErrImageNotFound)
+	}
+}
+
+func (e *EngineRuntime) InspectImage(ctx context.Context, image string) (*ImageInfo, error) {
+	resp, err := e.request(ctx, http.MethodGet, "/images/"+escapeImage(image)+"/json", nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("inspect image", resp, ErrImageNotFound)
+	}
+	var raw struct {
+		Id       string
+		RepoTags []string
+		Config   struct {
+			Labels map[string]string
+		}
+	}
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, err
+	}
+	return &ImageInfo{ID: raw.Id, Tags: raw.RepoTags, Labels: raw.Config.Labels}, nil
+}
+
+func (e *EngineRuntime) PullImage(ctx context.Context, image string, onProgress func(PullProgress)) error {
+	// without a tag the engine pulls every tag of the repository
+	name, tag := splitImageReference(image)
+	query := url.Values{"fromImage": {name}, "tag": {tag}}
+	resp, err := e.request(ctx, http.MethodPost, "/images/create", query, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("pull image", resp, ErrImageNotFound)
+	}
+	defer resp.Body.Close()
+
+	var onMessage func(jsonMessage)
+	if onProgress != nil {
+		onMessage = func(m jsonMessage) {
+			if m.ID == "" || m.ProgressDetail == nil {
+				// messages without progress details describe the whole image
+				return
+			}
+			onProgress(PullProgress{Layer: m.ID, Status: m.Status, Current: m.ProgressDetail.Current, Total: m.ProgressDetail.Total})
+		}
+	}
+	if err := readJSONMessages(resp.Body, onMessage); err != nil {
+		return fmt.Errorf("pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+func (e *EngineRuntime) BuildImage(ctx context.Context, opts BuildOptions) error {
+	buildContext, err := tarDirectory(opts.ContextDir)
+	if err != nil {
+		return fmt.Errorf("build context %s: %w", opts.ContextDir, err)
+	}
+
+	dockerfile := opts.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	query := url.Values{"t": {opts.Tag}, "dockerfile": {dockerfile}, "rm": {"1"}}
+	if len(opts.Labels) > 0 {
+		labels, err := json.Marshal(opts.Labels)
+		if err != nil {
+			return err
+		}
+		query.Set("labels", string(labels))
+	}
+	resp, err := e.request(ctx, http.MethodPost, "/build", query, buildContext, "application/x-tar")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("build image", resp, nil)
+	}
+	defer resp.Body.Close()
+
+	var onMessage func(jsonMessage)
+	var partial string
+	if opts.Output != nil {
+		onMessage = func(m jsonMessage) {
+			// stream messages are chunks of the output that do not always end at a line break
+			partial += m.Stream
+			for {
+				i := strings.IndexByte(partial, '\n')
+				if i < 0 {
+					break
+				}
+				if line := strings.TrimSpace(partial[:i]); line != "" {
+					opts.Output(line)
+				}
+				partial = partial[i+1:]
+			}
+		}
+	}
+	if err := readJSONMessages(resp.Body, onMessage); err != nil {
+		return fmt.Errorf("build image %s: %w", opts.Tag, err)
+	}
+	if line := strings.TrimSpace(partial); line != "" {
+		opts.Output(line)
+	}
+	return nil
+}
+
+func (e *EngineRuntime) CommitContainer(ctx context.Context, id string, opts CommitOptions) (string, error) {
+	query := url.Values{
+		"container": {id},
+		"repo":      {opts.Repository},
+		"tag":       {opts.Tag},
+		"pause":     {strconv.FormatBool(opts.Pause)},
+	}
+	body := struct {
+		Labels map[string]string `json:"Labels,omitempty"`
+	}{Labels: opts.Labels}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/commit", query, body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", responseError("commit container", resp, ErrContainerNotFound)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decodeJSON(resp, &created); err != nil {
+		return "", fmt.Errorf("commit container: %w", err)
+	}
+	return created.ID, nil
+}
+
+// splitImageReference splits image into repository and tag or digest; the tag defaults to latest.
+func splitImageReference(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	// a colon before the last slash separates the registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// escapeImage escapes the path segments of an image reference, keeping its slashes.
+func escapeImage(image string) string {
+	parts := strings.Split(image, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+func (e *EngineRuntime) RemoveImage(ctx context.Context, image string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	resp, err := e.request(ctx, http.MethodDelete, "/images/"+escapeImage(image), query, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("remove image", resp, ErrImageNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// engineContainerConfig is the body of POST /containers/create.
+type engineContainerConfig struct {
+	Image            string                  `json:"Image"`
+	Cmd              []string                `json:"Cmd,omitempty"`
+	Env              []string                `json:"Env,omitempty"`
+	Labels           map[string]string       `json:"Labels,omitempty"`
+	ExposedPorts     map[string]struct{}     `json:"ExposedPorts,omitempty"`
+	HostConfig       engineHostConfig        `json:"HostConfig"`
+	NetworkingConfig *engineNetworkingConfig `json:"NetworkingConfig,omitempty"`
+}
+
+type engineNetworkingConfig struct {
+	EndpointsConfig map[string]engineEndpointConfig `json:"EndpointsConfig"`
+}
+
+type engineEndpointConfig struct {
+	Aliases []string `json:"Aliases,omitempty"`
+}
+
+type engineHostConfig struct {
+	PortBindings map[string][]enginePortBinding `json:"PortBindings,omitempty"`
+	Mounts       []engineMount                  `json:"Mounts,omitempty"`
+	NetworkMode  string                         `json:"NetworkMode,omitempty"`
+	Memory       int64                          `json:"Memory,omitempty"`
+	NanoCpus     int64                          `json:"NanoCpus,omitempty"`
+	CpuShares    int64                          `json:"CpuShares,omitempty"`
+	PidsLimit    *int64                         `json:"PidsLimit,omitempty"`
+	Ulimits      []engineUlimit                 `json:"Ulimits,omitempty"`
+}
+
+type engineUlimit struct {
+	Name string `json:"Name"`
+	Soft int64  `json:"Soft"`
+	Hard int64  `json:"Hard"`
+}
+
+type engineMount struct {
+	Type     string `json:"Type"`
+	Source   string `json:"Source"`
+	Target   string `json:"Target"`
+	ReadOnly bool   `json:"ReadOnly,omitempty"`
+}
+
+type enginePortBinding struct {
+	HostIP   string `json:"HostIp,omitempty"`
+	HostPort string `json:"HostPort"`
+}
+
+func (e *EngineRuntime) RunContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	body := engineContainerConfig{
+		Image:        spec.Image,
+		Cmd:          spec.Cmd,
+		Labels:       spec.Labels,
+		ExposedPorts: map[string]struct{}{},
+		HostConfig: engineHostConfig{
+			PortBindings: map[string][]enginePortBinding{},
+			Memory:       spec.Resources.Memory,
+			NanoCpus:     spec.Resources.NanoCPUs,
+			CpuShares:    spec.Resources.CPUShares,
+		},
+	}
+	if spec.Resources.PidsLimit > 0 {
+		pids := spec.Resources.PidsLimit
+		body.HostConfig.PidsLimit = &pids
+	}
+	for _, u := range spec.Resources.Ulimits {
+		body.HostConfig.Ulimits = append(body.HostConfig.Ulimits, engineUlimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+	}
+	for k, v := range spec.Env {
+		body.Env = append(body.Env, k+"="+v)
+	}
+	for cp, hp := range spec.Ports {
+		key := fmt.Sprintf("%d/tcp", cp)
+		body.ExposedPorts[key] = struct{}{}
+		body.HostConfig.PortBindings[key] = []enginePortBinding{{HostPort: strconv.Itoa(hp)}}
+	}
+	for _, m := range spec.Mounts {
+		body.HostConfig.Mounts = append(body.HostConfig.Mounts, engineMount{Type: "volume", Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly})
+	}
+	if spec.Network != "" {
+		body.HostConfig.NetworkMode = spec.Network
+		body.NetworkingConfig = &engineNetworkingConfig{
+			EndpointsConfig: map[string]engineEndpointConfig{spec.Network: {Aliases: spec.Aliases}},
+		}
+	}
+
+	query := url.Values{}
+	if spec.Name != "" {
+		query.Set("name", spec.Name)
+	}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/containers/create", query, body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", responseError("create container", resp, ErrImageNotFound)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decodeJSON(resp, &created); err != nil {
+		return "", fmt.Errorf("create container: %w", err)
+	}
+
+	if err := e.StartContainer(ctx, created.ID); err != nil {
+		// do not leave a created but never started container behind
+		_ = e.RemoveContainer(context.Background(), created.ID, true)
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (e *EngineRuntime) StartContainer(ctx context.Context, id string) error {
+	return e.containerAction(ctx, id, "start", nil)
+}
+
+func (e *EngineRuntime) StopContainer(ctx context.Context, id string, timeout time.Duration) error {
+	return e.containerAction(ctx, id, "stop", stopQuery(timeout))
+}
+
+func (e *EngineRuntime) RestartContainer(ctx context.Context, id string, timeout time.Duration) error {
+	return e.containerAction(ctx, id, "restart", stopQuery(timeout))
+}
+
+func (e *EngineRuntime) PauseContainer(ctx context.Context, id string) error {
+	return e.containerAction(ctx, id, "pause", nil)
+}
+
+func (e *EngineRuntime) UnpauseContainer(ctx context.Context, id string) error {
+	return e.containerAction(ctx, id, "unpause", nil)
+}
+
+// containerAction posts to /containers/{id}/{action}; 304 counts as success.
+func (e *EngineRuntime) containerAction(ctx context.Context, id string, action string, query url.Values) error {
+	resp, err := e.request(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/"+action, query, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		return responseError(action+" container", resp, ErrContainerNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func stopQuery(timeout time.Duration) url.Values {
+	return url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+}
+
+func (e *EngineRuntime) RemoveContainer(ctx context.Context, id string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	resp, err := e.request(ctx, http.MethodDelete, "/containers/"+url.PathEscape(id), query, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError("remove container", resp, ErrContainerNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// engineContainerJSON is the subset of GET /containers/{id}/json used by the runtime.
+type engineContainerJSON struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	State struct {
+		Status     string `json:"Status"`
+		Running    bool   `json:"Running"`
+		Paused     bool   `json:"Paused"`
+		ExitCode   int    `json:"ExitCode"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+	} `json:"State"`
+}
+
+func (e *EngineRuntime) InspectContainer(ctx context.Context, id string) (*ContainerInfo, error) {
+	resp, err := e.request(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/json", nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("inspect container", resp, ErrContainerNotFound)
+	}
+	var raw engineContainerJSON
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("inspect container: %w", err)
+	}
+
+	return &ContainerInfo{
+		ID:     raw.ID,
+		Name:   strings.TrimPrefix(raw.Name, "/"),
+		Image:  raw.Config.Image,
+		Labels: raw.Config.Labels,
+		State: ContainerState{
+			Status:     raw.State.Status,
+			Running:    raw.State.Running,
+			Paused:     raw.State.Paused,
+			ExitCode:   raw.State.ExitCode,
+			StartedAt:  parseEngineTime(raw.State.StartedAt),
+			FinishedAt: parseEngineTime(raw.State.FinishedAt),
+		},
+	}, nil
+}
+
+func (e *EngineRuntime) ListContainers(ctx context.Context, labels map[string]string) ([]ContainerInfo, error) {
+	query := url.Values{"all": {"1"}}
+	if len(labels) > 0 {
+		filters, err := labelFilters(labels)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", filters)
+	}
+
+	resp, err := e.request(ctx, http.MethodGet, "/containers/json", query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("list containers", resp, nil)
+	}
+	var raw []struct {
+		ID     string            `json:"Id"`
+		Names  []string          `json:"Names"`
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+		State  string            `json:"State"`
+	}
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("list containers: %w", err)
+	}
+
+	out := make([]ContainerInfo, 0, len(raw))
+	for _, c := range raw {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		out = append(out, ContainerInfo{
+			ID:     c.ID,
+			Name:   name,
+			Image:  c.Image,
+			Labels: c.Labels,
+			State: ContainerState{
+				Status:  c.State,
+				Running: c.State == "running",
+				Paused:  c.State == "paused",
+			},
+		})
+	}
+	return out, nil
+}
+
+func (e *EngineRuntime) Logs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error) {
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+	if opts.Tail > 0 {
+		query.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Since != nil {
+		query.Set("since", strconv.FormatInt(opts.Since.Unix(), 10))
+	}
+	if opts.Timestamps {
+		query.Set("timestamps", "1")
+	}
+
+	resp, err := e.request(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/logs", query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("container logs", resp, ErrContainerNotFound)
+	}
+	return &demuxReadCloser{demuxReader: demuxReader{src: resp.Body}, closer: resp.Body}, nil
+}
+
+func (e *EngineRuntime) WaitContainer(ctx context.Context, id string) (int, error) {
+	resp, err := e.request(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/wait", nil, nil, "")
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseError("wait container", resp, ErrContainerNotFound)
+	}
+	var result struct {
+		StatusCode int `json:"StatusCode"`
+		Error      *struct {
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return 0, fmt.Errorf("wait container: %w", err)
+	}
+	if result.Error != nil && result.Error.Message != "" {
+		return result.StatusCode, fmt.Errorf("wait container: %s", result.Error.Message)
+	}
+	return result.StatusCode, nil
+}
+
+// engineVolume is a volume as returned by the /volumes endpoints.
+type engineVolume struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver"`
+	Mountpoint string            `json:"Mountpoint"`
+	Labels     map[string]string `json:"Labels"`
+	CreatedAt  string            `json:"CreatedAt"`
+}
+
+func (v engineVolume) info() VolumeInfo {
+	return VolumeInfo{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		Labels:     v.Labels,
+		CreatedAt:  parseEngineTime(v.CreatedAt),
+	}
+}
+
+func (e *EngineRuntime) CreateVolume(ctx context.Context, name string, labels map[string]string) (*VolumeInfo, error) {
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/volumes/create", nil, map[string]any{"Name": name, "Labels": labels})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("create volume", resp, nil)
+	}
+	var raw engineVolume
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("create volume: %w", err)
+	}
+	info := raw.info()
+	return &info, nil
+}
+
+func (e *EngineRuntime) InspectVolume(ctx context.Context, name string) (*VolumeInfo, error) {
+	resp, err := e.request(ctx, http.MethodGet, "/volumes/"+url.PathEscape(name), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("inspect volume", resp, ErrVolumeNotFound)
+	}
+	var raw engineVolume
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("inspect volume: %w", err)
+	}
+	info := raw.info()
+	return &info, nil
+}
+
+func (e *EngineRuntime) ListVolumes(ctx context.Context, labels map[string]string) ([]VolumeInfo, error) {
+	query := url.Values{}
+	if len(labels) > 0 {
+		filters, err := labelFilters(labels)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", filters)
+	}
+
+	resp, err := e.request(ctx, http.MethodGet, "/volumes", query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("list volumes", resp, nil)
+	}
+	var raw struct {
+		Volumes []engineVolume `json:"Volumes"`
+	}
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("list volumes: %w", err)
+	}
+
+	out := make([]VolumeInfo, 0, len(raw.Volumes))
+	for _, v := range raw.Volumes {
+		out = append(out, v.info())
+	}
+	return out, nil
+}
+
+func (e *EngineRuntime) RemoveVolume(ctx context.Context, name string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	resp, err := e.request(ctx, http.MethodDelete, "/volumes/"+url.PathEscape(name), query, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError("remove volume", resp, ErrVolumeNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// engineNetwork is a network as returned by the /networks endpoints.
+type engineNetwork struct {
+	ID         string            `json:"Id"`
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver"`
+	Labels     map[string]string `json:"Labels"`
+	Created    string            `json:"Created"`
+	Containers map[string]struct {
+		Name string `json:"Name"`
+	} `json:"Containers"`
+}
+
+func (n engineNetwork) info() NetworkInfo {
+	containers := make([]string, 0, len(n.Containers))
+	for _, c := range n.Containers {
+		containers = append(containers, c.Name)
+	}
+	return NetworkInfo{
+		ID:         n.ID,
+		Name:       n.Name,
+		Driver:     n.Driver,
+		Labels:     n.Labels,
+		CreatedAt:  parseEngineTime(n.Created),
+		Containers: containers,
+	}
+}
+
+func (e *EngineRuntime) CreateNetwork(ctx context.Context, name string, labels map[string]string) (*NetworkInfo, error) {
+	body := map[string]any{"Name": name, "Driver": "bridge", "Labels": labels, "CheckDuplicate": true}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/networks/create", nil, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("create network", resp, nil)
+	}
+	resp.Body.Close()
+	return e.InspectNetwork(ctx, name)
+}
+
+func (e *EngineRuntime) InspectNetwork(ctx context.Context, name string) (*NetworkInfo, error) {
+	resp, err := e.request(ctx, http.MethodGet, "/networks/"+url.PathEscape(name), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("inspect network", resp, ErrNetworkNotFound)
+	}
+	var raw engineNetwork
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("inspect network: %w", err)
+	}
+	info := raw.info()
+	return &info, nil
+}
+
+func (e *EngineRuntime) ListNetworks(ctx context.Context, labels map[string]string) ([]NetworkInfo, error) {
+	query := url.Values{}
+	if len(labels) > 0 {
+		filters, err := labelFilters(labels)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", filters)
+	}
+
+	resp, err := e.request(ctx, http.MethodGet, "/networks", query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("list networks", resp, nil)
+	}
+	var raw []engineNetwork
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("list networks: %w", err)
+	}
+
+	out := make([]NetworkInfo, 0, len(raw))
+	for _, n := range raw {
+		out = append(out, n.info())
+	}
+	return out, nil
+}
+
+func (e *EngineRuntime) RemoveNetwork(ctx context.Context, name string) error {
+	resp, err := e.request(ctx, http.MethodDelete, "/networks/"+url.PathEscape(name), nil, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError("remove network", resp, ErrNetworkNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (e *EngineRuntime) ConnectNetwork(ctx context.Context, network string, containerId string, aliases []string) error {
+	body := map[string]any{"Container": containerId, "EndpointConfig": engineEndpointConfig{Aliases: aliases}}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/networks/"+url.PathEscape(network)+"/connect", nil, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("connect network", resp, ErrNetworkNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (e *EngineRuntime) DisconnectNetwork(ctx context.Context, network string, containerId string) error {
+	body := map[string]any{"Container": containerId, "Force": true}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/networks/"+url.PathEscape(network)+"/disconnect", nil, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("disconnect network", resp, ErrNetworkNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// engineEvent is one entry of the /events stream.
+type engineEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+func (e *EngineRuntime) Events(ctx context.Context, labels map[string]string) (<-chan ContainerEvent, <-chan error) {
+	events := make(chan ContainerEvent)
+	errs := make(chan error, 1)
+
+	filters := map[string][]string{"type": {"container"}}
+	for k, v := range labels {
+		filters["label"] = append(filters["label"], k+"="+v)
+	}
+	b, err := json.Marshal(filters)
+	if err != nil {
+		errs <- err
+		close(events)
+		return events, errs
+	}
+
+	go func() {
+		defer close(events)
+		resp, err := e.request(ctx, http.MethodGet, "/events", url.Values{"filters": {string(b)}}, nil, "")
+		if err != nil {
+			errs <- err
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			errs <- responseError("events", resp, nil)
+			return
+		}
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var raw engineEvent
+			if err := dec.Decode(&raw); err != nil {
+				if ctx.Err() == nil {
+					errs <- fmt.Errorf("events: %w", err)
+				}
+				return
+			}
+			if raw.Type != "" && raw.Type != "container" {
+				continue
+			}
+			ev := ContainerEvent{
+				ID:     raw.Actor.ID,
+				Name:   raw.Actor.Attributes["name"],
+				Action: raw.Action,
+				Labels: raw.Actor.Attributes,
+				Time:   time.Unix(0, raw.TimeNano),
+			}
+			if code, err := strconv.Atoi(raw.Actor.Attributes["exitCode"]); err == nil {
+				ev.ExitCode = code
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, errs
+}
+
+func (e *EngineRuntime) Exec(ctx context.Context, id string, cmd []string) (*ExecResult, error) {
+	createBody := map[string]any{"AttachStdout": true, "AttachStderr": true, "Cmd": cmd}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/exec", nil, createBody)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("create exec", resp, ErrContainerNotFound)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decodeJSON(resp, &created); err != nil {
+		return nil, fmt.Errorf("create exec: %w", err)
+	}
+
+	startBody := map[string]any{"Detach": false, "Tty": false}
+	resp, err = e.requestJSON(ctx, http.MethodPost, "/exec/"+created.ID+"/start", nil, startBody)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("start exec", resp, ErrContainerNotFound)
+	}
+	output, err := io.ReadAll(&demuxReader{src: resp.Body})
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read exec output: %w", err)
+	}
+
+	resp, err = e.request(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("inspect exec", resp, nil)
+	}
+	var inspect struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := decodeJSON(resp, &inspect); err != nil {
+		return nil, fmt.Errorf("inspect exec: %w", err)
+	}
+
+	return &ExecResult{Output: output, ExitCode: inspect.ExitCode}, nil
+}
+
+func (e *EngineRuntime) AttachExec(ctx context.Context, id string, cmd []string) (ExecSession, error) {
+	createBody := map[string]any{
+		"AttachStdin": true, "AttachStdout": true, "AttachStderr": true,
+		"Tty": true, "Cmd": cmd, "Env": []string{"TERM=xterm"},
+	}
+	resp, err := e.requestJSON(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/exec", nil, createBody)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("create exec", resp, ErrContainerNotFound)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decodeJSON(resp, &created); err != nil {
+		return nil, fmt.Errorf("create exec: %w", err)
+	}
+
+	// the engine hijacks the connection when asked to upgrade
+	b, err := json.Marshal(map[string]any{"Detach": false, "Tty": true})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/"+engineAPIVersion+"/exec/"+created.ID+"/start", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	resp, err = e.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRuntimeUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, responseError("start exec", resp, ErrContainerNotFound)
+	}
+	stream, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("start exec: connection was not upgraded")
+	}
+	return &engineExecSession{ReadWriteCloser: stream, engine: e, id: created.ID}, nil
+}
+
+// engineExecSession is the hijacked connection of an exec started with a TTY.
+type engineExecSession struct {
+	io.ReadWriteCloser
+	engine *EngineRuntime
+	id     string
+}
+
+func (s *engineExecSession) Resize(ctx context.Context, rows uint, cols uint) error {
+	query := url.Values{"h": {strconv.FormatUint(uint64(rows), 10)}, "w": {strconv.FormatUint(uint64(cols), 10)}}
+	resp, err := s.engine.request(ctx, http.MethodPost, "/exec/"+s.id+"/resize", query, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError("resize exec", resp, ErrContainerNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// engineStats is the subset of GET /containers/{id}/stats used by ContainerStats.
+type engineStats struct {
+	Name     string    `json:"name"`
+	Read     time.Time `json:"read"`
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage  uint64   `json:"total_usage"`
+			PercpuUsage []uint64 `json:"percpu_usage"`
+		} `json:"cpu_usage"`
+		SystemUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs  uint32 `json:"online_cpus"`
+	} `json:"cpu_stats"`
+	PreCPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+		SystemUsage uint64 `json:"system_cpu_usage"`
+	} `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage int64            `json:"usage"`
+		Limit int64            `json:"limit"`
+		Stats map[string]int64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes int64 `json:"rx_bytes"`
+		TxBytes int64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IoServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value int64  `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current int64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// ContainerStats requests a single sample. Without one-shot the engine waits for a second
+// CPU reading, so the CPU percentage can be computed like `docker stats` does.
+func (e *EngineRuntime) ContainerStats(ctx context.Context, id string) (*ContainerStats, error) {
+	query := url.Values{"stream": {"false"}}
+	resp, err := e.request(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/stats", query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("container stats", resp, ErrContainerNotFound)
+	}
+	var raw engineStats
+	if err := decodeJSON(resp, &raw); err != nil {
+		return nil, fmt.Errorf("container stats: %w", err)
+	}
+	return raw.stats(id), nil
+}
+
+func (s *engineStats) stats(id string) *ContainerStats {
+	out := &ContainerStats{
+		ID:          id,
+		Name:        strings.TrimPrefix(s.Name, "/"),
+		Time:        s.Read,
+		MemoryUsage: s.MemoryStats.Usage,
+		MemoryLimit: s.MemoryStats.Limit,
+		Pids:        s.PidsStats.Current,
+	}
+
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		out.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// like `docker stats`, inactive page cache is not counted as usage (cgroup v1 and v2)
+	if cache, ok := s.MemoryStats.Stats["total_inactive_file"]; ok && cache < out.MemoryUsage {
+		out.MemoryUsage -= cache
+	} else if cache, ok := s.MemoryStats.Stats["inactive_file"]; ok && cache < out.MemoryUsage {
+		out.MemoryUsage -= cache
+	}
+	if out.MemoryLimit > 0 {
+		out.MemoryPercent = float64(out.MemoryUsage) / float64(out.MemoryLimit) * 100
+	}
+
+	for _, n := range s.Networks {
+		out.NetworkRx += n.RxBytes
+		out.NetworkTx += n.TxBytes
+	}
+	for _, b := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(b.Op) {
+		case "read":
+			out.BlockRead += b.Value
+		case "write":
+			out.BlockWrite += b.Value
+		}
+	}
+	return out
+}
+
+func (e *EngineRuntime) CopyToContainer(ctx context.Context, id string, destDir string, content io.Reader) error {
+	query := url.Values{"path": {destDir}}
+	resp, err := e.request(ctx, http.MethodPut, "/containers/"+url.PathEscape(id)+"/archive", query, content, "application/x-tar")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("copy to container", resp, ErrContainerNotFound)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// request sends a request to the versioned engine API. Transport failures are reported as ErrRuntimeUnavailable.
+func (e *EngineRuntime) request(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	return e.rawRequest(ctx, method, "/"+engineAPIVersion+path, query, body, contentType)
+}
+
+// rawRequest sends a request to path without adding the API version prefix.
+func (e *EngineRuntime) rawRequest(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := e.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRuntimeUnavailable, err)
+	}
+	return resp, nil
+}
+
+func (e *EngineRuntime) requestJSON(ctx context.Context, method, path string, query url.Values, in any) (*http.Response, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	return e.request(ctx, method, path, query, bytes.NewReader(b), "application/json")
+}
+
+// responseError converts a failed engine response into a *RuntimeError, using notFound for 404.
+func responseError(op string, resp *http.Response, notFound error) error {
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil || body.Message == "" {
+		body.Message = strings.TrimSpace(string(raw))
+	}
+
+	var sentinel error
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		sentinel = notFound
+	case http.StatusConflict, http.StatusForbidden:
+		// 403 is returned for networks with active endpoints and for predefined networks
+		sentinel = ErrConflict
+		if strings.Contains(body.Message, "is not running") {
+			sentinel = ErrContainerNotRunning
+		}
+	}
+	if strings.HasSuffix(op, " container") && isPortBindMessage(body.Message) {
+		sentinel = ErrPortInUse
+	}
+
+	return &RuntimeError{Op: op, StatusCode: resp.StatusCode, Message: body.Message, Err: sentinel}
+}
+
+// isPortBindMessage matches the Docker and Podman errors for a taken host port.
+func isPortBindMessage(msg string) bool {
+	return strings.Contains(msg, "port is already allocated") || strings.Contains(msg, "address already in use")
+}
+
+func decodeJSON(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func labelFilters(labels map[string]string) (string, error) {
+	values := make([]string, 0, len(labels))
+	for k, v := range labels {
+		values = append(values, k+"="+v)
+	}
+	b, err := json.Marshal(map[string][]string{"label": values})
+	return string(b), err
+}
+
+func parseEngineTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.Year() <= 1 {
+		return time.Time{}
+	}
+	return t
+}
+
+// jsonMessage is one entry of the JSON progress stream returned by pull and build.
+type jsonMessage struct {
+	Stream         string `json:"stream"`
+	Status         string `json:"status"`
+	ID             string `json:"id"`
+	Progress       string `json:"progress"`
+	Error          string `json:"error"`
+	ProgressDetail *struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+}
+
+// readJSONMessages consumes a JSON progress stream and reports the first error message it contains.
+func readJSONMessages(r io.Reader, onMessage func(jsonMessage)) error {
+	dec := json.NewDecoder(r)
+	for {
+		var m jsonMessage
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if m.Error != "" {
+			return errors.New(m.Error)
+		}
+		if onMessage != nil {
+			onMessage(m)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestEngine starts an engine API stub and returns a runtime pointed at it.
+func newTestEngine(t *testing.T, handler http.HandlerFunc) *EngineRuntime {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	rt, err := NewEngineRuntime("tcp://" + strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+	return rt
+}
+
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestNewEngineRuntime_InvalidScheme(t *testing.T) {
+	if _, err := NewEngineRuntime("ftp://example"); err == nil {
+		t.Fatalf("expected error for unsupported scheme")
+	}
+}
+
+func TestEngineRuntime_RunContainer(t *testing.T) {
+	var created engineContainerConfig
+	var startedID string
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/"+engineAPIVersion+"/containers/create":
+			if r.URL.Query().Get("name") != "my-web" {
+				t.Errorf("unexpected name %q", r.URL.Query().Get("name"))
+			}
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"abc123","Warnings":[]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/"+engineAPIVersion+"/containers/abc123/start":
+			startedID = "abc123"
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	id, err := rt.RunContainer(context.Background(), ContainerSpec{
+		Name:   "my-web",
+		Image:  "nginx",
+		Env:    map[string]string{"A": "1"},
+		Ports:  map[int]int{80: 8080},
+		Labels: managedLabels(),
+		Resources: Resources{
+			Memory:    128 << 20,
+			NanoCPUs:  5e8,
+			PidsLimit: 64,
+			Ulimits:   []Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "abc123" || startedID != "abc123" {
+		t.Fatalf("expected container abc123 to be created and started, got id=%q started=%q", id, startedID)
+	}
+	if created.Image != "nginx" || len(created.Env) != 1 || created.Env[0] != "A=1" {
+		t.Fatalf("unexpected create body: %+v", created)
+	}
+	if b := created.HostConfig.PortBindings["80/tcp"]; len(b) != 1 || b[0].HostPort != "8080" {
+		t.Fatalf("unexpected port bindings: %+v", created.HostConfig.PortBindings)
+	}
+	if created.Labels[ManagedByLabel] != ManagedByValue {
+		t.Fatalf("expected managed_by label, got %v", created.Labels)
+	}
+	hc := created.HostConfig
+	if hc.Memory != 128<<20 || hc.NanoCpus != 5e8 || hc.PidsLimit == nil || *hc.PidsLimit != 64 {
+		t.Fatalf("unexpected resource limits: %+v", hc)
+	}
+	if len(hc.Ulimits) != 1 || hc.Ulimits[0].Name != "nofile" || hc.Ulimits[0].Hard != 2048 {
+		t.Fatalf("unexpected ulimits: %+v", hc.Ulimits)
+	}
+}
+
+func TestEngineRuntime_TypedErrors(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Conflict. The container name \"/x\" is already in use"}`))
+		case strings.HasSuffix(r.URL.Path, "/containers/busy/start"):
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message":"rootlessport listen tcp 0.0.0.0:9092: bind: address already in use"}`))
+		case strings.HasSuffix(r.URL.Path, "/exec"):
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Container abc is not running"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container: missing"}`))
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := rt.InspectContainer(ctx, "missing"); !errors.Is(err, ErrContainerNotFound) {
+		t.Fatalf("expected ErrContainerNotFound, got %v", err)
+	}
+	if err := rt.RemoveContainer(ctx, "missing", true); !errors.Is(err, ErrContainerNotFound) {
+		t.Fatalf("expected ErrContainerNotFound, got %v", err)
+	}
+	_, err := rt.RunContainer(ctx, ContainerSpec{Name: "x", Image: "nginx"})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	var rtErr *RuntimeError
+	if !errors.As(err, &rtErr) || rtErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected *RuntimeError with status 409, got %#v", err)
+	}
+	if _, err := rt.Exec(ctx, "abc", []string{"ls"}); !errors.Is(err, ErrContainerNotRunning) {
+		t.Fatalf("expected ErrContainerNotRunning, got %v", err)
+	}
+	if err := rt.StartContainer(ctx, "busy"); !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+}
+
+func TestEngineRuntime_Unavailable(t *testing.T) {
+	rt, err := NewEngineRuntime("unix:///nonexistent/docker.sock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rt.Ping(context.Background()); !errors.Is(err, ErrRuntimeUnavailable) {
+		t.Fatalf("expected ErrRuntimeUnavailable, got %v", err)
+	}
+}
+
+func TestEngineRuntime_PullReportsStreamError(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"Pulling from library/nope"}` + "\n" + `{"error":"manifest unknown"}` + "\n"))
+	})
+	err := rt.PullImage(context.Background(), "nope:latest", nil)
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+}
+
+func TestEngineRuntime_PullSendsTheTag(t *testing.T) {
+	var pulled []url.Values
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		pulled = append(pulled, r.URL.Query())
+	})
+	for _, image := range []string{"nginx", "localhost:5000/team/app:1.2", "redis@sha256:abc"} {
+		if err := rt.PullImage(context.Background(), image, nil); err != nil {
+			t.Fatalf("PullImage(%s): %v", image, err)
+		}
+	}
+
+	want := [][2]string{{"nginx", "latest"}, {"localhost:5000/team/app", "1.2"}, {"redis", "sha256:abc"}}
+	for i, w := range want {
+		if pulled[i].Get("fromImage") != w[0] || pulled[i].Get("tag") != w[1] {
+			t.Errorf("pull %d: expected %s with tag %s, got %v", i, w[0], w[1], pulled[i])
+		}
+	}
+}
+
+func TestEngineRuntime_EscapesImagePaths(t *testing.T) {
+	var paths []string
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNotFound)
+	})
+	ctx := context.Background()
+	_, _ = rt.ImageExists(ctx, "team/app#1")
+	_ = rt.RemoveImage(ctx, "team/app?x", true)
+
+	want := []string{"/" + engineAPIVersion + "/images/team/app%231/json", "/" + engineAPIVersion + "/images/team/app%3Fx"}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("expected paths %v, got %v", want, paths)
+	}
+}
+
+func TestEngineRuntime_PullReportsLayerProgress(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"Pulling from library/nginx","id":"latest"}` + "\n" +
+			`{"status":"Downloading","progressDetail":{"current":10,"total":40},"id":"abc"}` + "\n" +
+			`{"status":"Digest: sha256:123"}` + "\n"))
+	})
+
+	var got []PullProgress
+	if err := rt.PullImage(context.Background(), "nginx", func(p PullProgress) { got = append(got, p) }); err != nil {
+		t.Fatalf("PullImage: %v", err)
+	}
+	if len(got) != 1 || got[0] != (PullProgress{Layer: "abc", Status: "Downloading", Current: 10, Total: 40}) {
+		t.Fatalf("unexpected progress: %+v", got)
+	}
+}
+
+func TestEngineRuntime_PullProgressOfARealPull(t *testing.T) {
+	// the messages of `docker pull redis:7-alpine` with two of its layers
+	stream := `{"status":"Pulling from library/redis","id":"7-alpine"}
+{"status":"Already exists","progressDetail":{},"id":"4abcf2066143"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"1e2f3a4b5c6d"}
+{"status":"Waiting","progressDetail":{},"id":"1e2f3a4b5c6d"}
+{"status":"Downloading","progressDetail":{"current":523432,"total":1046861},"progress":"[=========================>                         ]  523.4kB/1.047MB","id":"1e2f3a4b5c6d"}
+{"status":"Verifying Checksum","progressDetail":{},"id":"1e2f3a4b5c6d"}
+{"status":"Download complete","progressDetail":{},"id":"1e2f3a4b5c6d"}
+{"status":"Extracting","progressDetail":{"current":1046861,"total":1046861},"progress":"[==================================================>]  1.047MB/1.047MB","id":"1e2f3a4b5c6d"}
+{"status":"Pull complete","progressDetail":{},"id":"1e2f3a4b5c6d"}
+{"status":"Digest: sha256:1b7f7a5e2d34c1a1f7f2b3bb0d2ea8e4f5b6c7d8e9f0a1b2c3d4e5f60718293a"}
+{"status":"Status: Downloaded newer image for redis:7-alpine"}
+`
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(stream))
+	})
+
+	tracker := newPullTracker()
+	if err := rt.PullImage(context.Background(), "redis:7-alpine", func(p PullProgress) { tracker.update(p) }); err != nil {
+		t.Fatalf("PullImage: %v", err)
+	}
+	if _, ok := tracker.layers["7-alpine"]; ok || len(tracker.layers) != 2 {
+		t.Fatalf("expected only the two layers to be tracked, got %v", tracker.layers)
+	}
+	if tracker.percent != pullEndPercent {
+		t.Fatalf("expected a complete pull at %d%%, got %d%%", pullEndPercent, tracker.percent)
+	}
+	if msg := tracker.message(); msg != "Pulling image: 2/2 layers, 1.0 MB downloaded" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
+
+func TestEngineRuntime_BuildStreamsOutputAndLabels(t *testing.T) {
+	var labels map[string]string
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("labels")), &labels)
+		_, _ = w.Write([]byte(`{"stream":"Step 1/2 : FROM nginx\n"}` + "\n" + `{"stream":" ---> abc"}` + "\n" + `{"stream":"123\nSuccessfully built"}` + "\n"))
+	})
+
+	var lines []string
+	err := rt.BuildImage(context.Background(), BuildOptions{
+		Tag:        "custom:latest",
+		ContextDir: t.TempDir(),
+		Labels:     map[string]string{ContextHashLabel: "abc"},
+		Output:     func(line string) { lines = append(lines, line) },
+	})
+	if err != nil {
+		t.Fatalf("BuildImage: %v", err)
+	}
+	if labels[ContextHashLabel] != "abc" {
+		t.Fatalf("expected labels to be sent, got %v", labels)
+	}
+	if strings.Join(lines, "|") != "Step 1/2 : FROM nginx|---> abc123|Successfully built" {
+		t.Fatalf("unexpected output lines: %q", lines)
+	}
+}
+
+func TestEngineRuntime_AttachExecHijacksConnection(t *testing.T) {
+	var resized string
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/web/exec"):
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["Tty"] != true || body["AttachStdin"] != true {
+				t.Errorf("expected a TTY with stdin, got %v", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"exec1"}`))
+		case strings.HasSuffix(r.URL.Path, "/exec/exec1/start"):
+			if r.Header.Get("Upgrade") != "tcp" {
+				t.Errorf("expected upgrade request, got headers %v", r.Header)
+			}
+			_, _ = io.ReadAll(r.Body)
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack: %v", err)
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n$ ")
+			_ = buf.Flush()
+			line, _ := buf.ReadString('\n')
+			_, _ = conn.Write([]byte("echo:" + line))
+		case strings.HasSuffix(r.URL.Path, "/exec/exec1/resize"):
+			resized = r.URL.Query().Get("h") + "x" + r.URL.Query().Get("w")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	session, err := rt.AttachExec(context.Background(), "web", []string{"sh"})
+	if err != nil {
+		t.Fatalf("AttachExec: %v", err)
+	}
+	defer session.Close()
+
+	if err := session.Resize(context.Background(), 24, 80); err != nil || resized != "24x80" {
+		t.Fatalf("unexpected resize %q: %v", resized, err)
+	}
+	if _, err := session.Write([]byte("id\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out, _ := io.ReadAll(session)
+	if string(out) != "$ echo:id\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestEngineRuntime_ContainerStats(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "false" {
+			t.Errorf("expected a single sample, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{
+			"name": "/web",
+			"cpu_stats": {"cpu_usage": {"total_usage": 300}, "system_cpu_usage": 2000, "online_cpus": 2},
+			"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000},
+			"memory_stats": {"usage": 600, "limit": 1000, "stats": {"inactive_file": 100}},
+			"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+			"blkio_stats": {"io_service_bytes_recursive": [{"op": "Read", "value": 7}, {"op": "write", "value": 9}]},
+			"pids_stats": {"current": 4}
+		}`))
+	})
+
+	stats, err := rt.ContainerStats(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("ContainerStats: %v", err)
+	}
+	want := ContainerStats{ID: "abc", Name: "web", CPUPercent: 40, MemoryUsage: 500, MemoryLimit: 1000, MemoryPercent: 50,
+		NetworkRx: 11, NetworkTx: 22, BlockRead: 7, BlockWrite: 9, Pids: 4}
+	if *stats != want {
+		t.Fatalf("unexpected stats:\n got %+v\nwant %+v", *stats, want)
+	}
+}
+
+func TestEngineRuntime_ExecDemultiplexes(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/c1/exec"):
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"e1"}`))
+		case strings.HasSuffix(r.URL.Path, "/exec/e1/start"):
+			_, _ = w.Write(append(frame(1, "out\n"), frame(2, "err\n")...))
+		case strings.HasSuffix(r.URL.Path, "/exec/e1/json"):
+			_, _ = w.Write([]byte(`{"ExitCode":3}`))
+		}
+	})
+	res, err := rt.Exec(context.Background(), "c1", []string{"find"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(res.Output) != "out\nerr\n" || res.ExitCode != 3 {
+		t.Fatalf("unexpected exec result: %q exit=%d", res.Output, res.ExitCode)
+	}
+}
+
+func TestDemuxReader_RawStream(t *testing.T) {
+	out, err := io.ReadAll(&demuxReader{src: strings.NewReader("plain tty output\n")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "plain tty output\n" {
+		t.Fatalf("expected raw passthrough, got %q", out)
+	}
+}
+
+func TestDemuxReader_Frames(t *testing.T) {
+	stream := bytes.Join([][]byte{frame(1, "first "), frame(2, "second"), frame(1, "\n")}, nil)
+	out, err := io.ReadAll(&demuxReader{src: bytes.NewReader(stream)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "first second\n" {
+		t.Fatalf("unexpected demuxed output: %q", out)
+	}
+}
+
+func TestEngineRuntime_Events(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		var filters map[string][]string
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		if len(filters["label"]) != 1 || filters["label"][0] != "managed_by=simple-test-server" {
+			t.Errorf("unexpected filters %v", filters)
+		}
+		_, _ = w.Write([]byte(`{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"name":"web"}},"timeNano":1000}` + "\n"))
+		_, _ = w.Write([]byte(`{"Type":"container","Action":"die","Actor":{"ID":"abc","Attributes":{"name":"web","exitCode":"137"}},"timeNano":2000}` + "\n"))
+	})
+
+	events, errs := rt.Events(context.Background(), managedLabels())
+	got := []ContainerEvent{}
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 2 || got[0].Action != "start" || got[1].Action != "die" || got[1].ExitCode != 137 || got[1].Name != "web" {
+		t.Fatalf("unexpected events %+v", got)
+	}
+	// the stub closes the stream, which is reported as an error
+	if err := <-errs; err == nil {
+		t.Fatalf("expected error when the stream ends")
+	}
+}
+
+func TestEngineRuntime_LifecycleActions(t *testing.T) {
+	calls := []string{}
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path+"?"+r.URL.RawQuery)
+		if strings.HasSuffix(r.URL.Path, "/stop") {
+			// already stopped
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/pause") {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"Container abc is not running"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	if err := rt.StopContainer(ctx, "abc", 10*time.Second); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := rt.RestartContainer(ctx, "abc", 5*time.Second); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if err := rt.PauseContainer(ctx, "abc"); !errors.Is(err, ErrContainerNotRunning) {
+		t.Fatalf("expected ErrContainerNotRunning, got %v", err)
+	}
+	want := []string{
+		"/" + engineAPIVersion + "/containers/abc/stop?t=10",
+		"/" + engineAPIVersion + "/containers/abc/restart?t=5",
+		"/" + engineAPIVersion + "/containers/abc/pause?",
+	}
+	if strings.Join(calls, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
+
+func TestEngineRuntime_Volumes(t *testing.T) {
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/"+engineAPIVersion+"/volumes/create":
+			var body struct {
+				Name   string
+				Labels map[string]string
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"Name": body.Name, "Driver": "local", "Labels": body.Labels, "CreatedAt": "2024-01-02T03:04:05Z"})
+		case r.Method == http.MethodGet && r.URL.Path == "/"+engineAPIVersion+"/volumes":
+			if !strings.Contains(r.URL.Query().Get("filters"), "managed_by=simple-test-server") {
+				t.Errorf("missing label filter: %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"Volumes":[{"Name":"data","Driver":"local","Labels":{"managed_by":"simple-test-server"}}],"Warnings":null}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/"+engineAPIVersion+"/volumes/data":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"remove data: volume is in use"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/"+engineAPIVersion+"/volumes/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"get missing: no such volume"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	v, err := rt.CreateVolume(ctx, "data", managedLabels())
+	if err != nil || v.Name != "data" || v.CreatedAt.Year() != 2024 {
+		t.Fatalf("create: %+v, %v", v, err)
+	}
+	list, err := rt.ListVolumes(ctx, managedLabels())
+	if err != nil || len(list) != 1 || list[0].Name != "data" {
+		t.Fatalf("list: %+v, %v", list, err)
+	}
+	if err := rt.RemoveVolume(ctx, "data", false); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if _, err := rt.InspectVolume(ctx, "missing"); !errors.Is(err, ErrVolumeNotFound) {
+		t.Fatalf("expected ErrVolumeNotFound, got %v", err)
+	}
+}
+
+func TestEngineRuntime_RunContainerInNetwork(t *testing.T) {
+	var created engineContainerConfig
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"abc"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	_, err := rt.RunContainer(context.Background(), ContainerSpec{Name: "mqtt-0", Image: "mosquitto", Network: "tests", Aliases: []string{"mqtt"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.HostConfig.NetworkMode != "tests" || created.NetworkingConfig == nil {
+		t.Fatalf("expected network configuration, got %+v", created)
+	}
+	if aliases := created.NetworkingConfig.EndpointsConfig["tests"].Aliases; len(aliases) != 1 || aliases[0] != "mqtt" {
+		t.Fatalf("unexpected aliases %v", aliases)
+	}
+}
+
+func TestEngineRuntime_CommitContainer(t *testing.T) {
+	var query url.Values
+	var body struct{ Labels map[string]string }
+	rt := newTestEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":"sha256:abc"}`))
+	})
+
+	id, err := rt.CommitContainer(context.Background(), "ftp-1", CommitOptions{Repository: "snap", Tag: "base", Labels: map[string]string{SnapshotLabel: "base"}, Pause: true})
+	if err != nil {
+		t.Fatalf("CommitContainer: %v", err)
+	}
+	if id != "sha256:abc" || query.Get("container") != "ftp-1" || query.Get("repo") != "snap" || query.Get("tag") != "base" || query.Get("pause") != "true" {
+		t.Fatalf("unexpected commit %s with query %v", id, query)
+	}
+	if body.Labels[SnapshotLabel] != "base" {
+		t.Fatalf("expected labels in the commit body, got %v", body.Labels)
+	}
+}
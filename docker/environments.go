@@ -0,0 +1,458 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+	"github.com/tim0-12432/simple-test-server/progress"
+)
+
+// The environment store functions are variables so tests can run without PocketBase.
+var (
+	createEnvironmentFn = services.CreateEnvironment
+	listEnvironmentsFn  = services.ListEnvironments
+	getEnvironmentFn    = services.GetEnvironment
+	updateEnvironmentFn = services.UpdateEnvironment
+)
+
+// EnvironmentSpec defines an environment as sent to the environments API.
+type EnvironmentSpec struct {
+	Name    string       `json:"name"`
+	Members []MemberSpec `json:"members"`
+}
+
+// MemberSpec defines a server of an environment.
+type MemberSpec struct {
+	// Name is unique in the environment; the other members reach the server by it.
+	Name string `json:"name"`
+	// Type may be omitted if the member is started from a preset.
+	Type string `json:"type"`
+	// Preset starts the member from a preset; the set fields of Configuration override it.
+	Preset string `json:"preset,omitempty"`
+	// DependsOn names the members that must be ready before this one is started.
+	DependsOn     []string            `json:"depends_on,omitempty"`
+	Configuration ServerConfiguration `json:"configuration"`
+}
+
+// ListEnvironments returns all environments, the newest first.
+func ListEnvironments() ([]*dtos.Environment, error) {
+	return listEnvironmentsFn()
+}
+
+// GetEnvironment returns the environment id.
+func GetEnvironment(id string) (*dtos.Environment, error) {
+	return getEnvironmentFn(id)
+}
+
+// CreateEnvironment validates spec and stores the environment as starting. Invalid env
+// variables and ports of the members are reported together as a *servers.ConfigError with
+// keys members.<member>.<field>. The members are started by StartEnvironmentWithProgress.
+func CreateEnvironment(ctx context.Context, spec EnvironmentSpec) (*dtos.Environment, error) {
+	if !resourceNamePattern.MatchString(spec.Name) {
+		return nil, fmt.Errorf("%w: name %q must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", ErrInvalidEnvironment, spec.Name)
+	}
+	if len(spec.Members) == 0 {
+		return nil, fmt.Errorf("%w: at least one member is required", ErrInvalidEnvironment)
+	}
+
+	existing, err := listEnvironmentsFn()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.Name == spec.Name && e.Status != dtos.Discarded {
+			return nil, fmt.Errorf("%w: environment %s already exists", ErrConflict, spec.Name)
+		}
+	}
+
+	members := make([]dtos.EnvironmentMember, 0, len(spec.Members))
+	names := map[string]bool{}
+	containerNames := map[string]string{}
+	fields := map[string]string{}
+	for _, m := range spec.Members {
+		if !aliasPattern.MatchString(m.Name) {
+			return nil, fmt.Errorf("%w: member name %q is not a valid DNS name", ErrInvalidEnvironment, m.Name)
+		}
+		if names[m.Name] {
+			return nil, fmt.Errorf("%w: member %s is listed twice", ErrInvalidEnvironment, m.Name)
+		}
+		names[m.Name] = true
+
+		member, config, err := environmentMember(ctx, spec.Name, m)
+		var configErr *servers.ConfigError
+		if errors.As(err, &configErr) {
+			for k, v := range configErr.Fields {
+				fields["members."+m.Name+"."+k] = v
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", m.Name, err)
+		}
+		if other, ok := containerNames[config.Name]; ok {
+			return nil, fmt.Errorf("%w: members %s and %s use the container name %s", ErrInvalidEnvironment, other, m.Name, config.Name)
+		}
+		containerNames[config.Name] = m.Name
+		members = append(members, member)
+	}
+	if len(fields) > 0 {
+		return nil, &servers.ConfigError{Fields: fields}
+	}
+	if err := checkDependencies(members); err != nil {
+		return nil, err
+	}
+
+	env := &dtos.Environment{
+		Name:      spec.Name,
+		Network:   DefaultNetworkName + "-" + spec.Name,
+		Members:   members,
+		Status:    dtos.Starting,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	id, err := createEnvironmentFn(env)
+	if errors.Is(err, services.ErrEnvironmentExists) {
+		// created concurrently since the check above
+		return nil, fmt.Errorf("%w: environment %s already exists", ErrConflict, spec.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	env.ID = id
+	return env, nil
+}
+
+// environmentMember resolves the preset and validates the configuration of a member. Members
+// without a container name get one derived from the environment name.
+func environmentMember(ctx context.Context, envName string, m MemberSpec) (dtos.EnvironmentMember, ServerConfiguration, error) {
+	serverType, config := m.Type, m.Configuration
+	if m.Preset != "" {
+		presetType, merged, err := PresetConfiguration(m.Preset, config)
+		if err != nil {
+			return dtos.EnvironmentMember{}, config, err
+		}
+		if serverType != "" && serverType != presetType {
+			return dtos.EnvironmentMember{}, config, fmt.Errorf("%w: preset %s is for %s servers", ErrInvalidEnvironment, m.Preset, presetType)
+		}
+		serverType, config = presetType, merged
+	}
+	if _, err := servers.GetServerByType(serverType); err != nil {
+		return dtos.EnvironmentMember{}, config, fmt.Errorf("%w: %v", ErrInvalidEnvironment, err)
+	}
+	if config.Network != "" {
+		return dtos.EnvironmentMember{}, config, fmt.Errorf("%w: members join the network of the environment", ErrInvalidEnvironment)
+	}
+	if err := ValidateConfiguration(serverType, config); err != nil {
+		return dtos.EnvironmentMember{}, config, err
+	}
+	if config.Name == "" {
+		config.Name = containerNamePrefix + envName + "-" + m.Name
+	}
+	if err := CheckContainerName(ctx, config.Name); err != nil {
+		return dtos.EnvironmentMember{}, config, err
+	}
+	if config.Snapshot != "" {
+		snapshot, err := GetSnapshot(config.Snapshot)
+		if err != nil {
+			return dtos.EnvironmentMember{}, config, err
+		}
+		if snapshot.Type != serverType {
+			return dtos.EnvironmentMember{}, config, fmt.Errorf("%w: snapshot %s was taken of a %s server", ErrInvalidEnvironment, snapshot.Name, snapshot.Type)
+		}
+	}
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return dtos.EnvironmentMember{}, config, err
+	}
+	dependsOn := slices.Clone(m.DependsOn)
+	if dependsOn == nil {
+		dependsOn = []string{}
+	}
+	return dtos.EnvironmentMember{Name: m.Name, Type: serverType, DependsOn: dependsOn, Configuration: raw}, config, nil
+}
+
+// checkDependencies rejects dependencies on unknown members and dependency cycles.
+func checkDependencies(members []dtos.EnvironmentMember) error {
+	deps := map[string][]string{}
+	for _, m := range members {
+		deps[m.Name] = m.DependsOn
+	}
+	for _, m := range members {
+		for _, d := range m.DependsOn {
+			if _, ok := deps[d]; !ok {
+				return fmt.Errorf("%w: member %s depends on unknown member %s", ErrInvalidEnvironment, m.Name, d)
+			}
+		}
+	}
+
+	// depth-first search; members on the current path are visiting
+	const visiting, done = 1, 2
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("%w: dependency cycle through member %s", ErrInvalidEnvironment, name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, d := range deps[name] {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, m := range members {
+		if err := visit(m.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartEnvironmentWithProgress starts the members of the environment id in parallel, each
+// as soon as its dependencies are ready. The progress of all members is sent to reqId. If
+// a member fails, the started members and the network are removed again and the
+// environment is marked as failed.
+func StartEnvironmentWithProgress(reqId string, id string) {
+	progress.Default.New(reqId)
+	ctx, done := trackStart(reqId)
+	defer done()
+
+	env, err := getEnvironmentFn(id)
+	if err != nil {
+		progress.Default.Send(reqId, progress.Event{Percent: 100, Message: err.Error(), Error: true})
+		return
+	}
+
+	start := &environmentStart{reqId: reqId, env: env, percents: make([]int, len(env.Members))}
+	if err := start.run(ctx); err != nil {
+		log.Printf("Environment %s failed to start: %v", env.Name, err)
+		start.rollback()
+		env.Status, env.Error = dtos.Failed, err.Error()
+		start.save()
+		progress.Default.Send(reqId, progress.Event{Percent: 100, Message: fmt.Sprintf("environment failed: %v", err), Error: true})
+		return
+	}
+
+	env.Status = dtos.Running
+	start.save()
+	progress.Default.Send(reqId, progress.Event{Percent: 100, Message: "Environment started", Error: false})
+
+	go func() {
+		time.Sleep(30 * time.Second)
+		progress.Default.Remove(reqId)
+	}()
+}
+
+// environmentStart tracks the members of an environment while they are started.
+type environmentStart struct {
+	reqId string
+
+	// mu guards env and percents, the last progress of every member
+	mu       sync.Mutex
+	env      *dtos.Environment
+	percents []int
+}
+
+func (s *environmentStart) run(ctx context.Context) error {
+	progress.Default.Send(s.reqId, progress.Event{Percent: 5, Message: "Creating network " + s.env.Network, Error: false})
+	if err := ensureManagedNetwork(ctx, s.env.Network); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ready := map[string]chan struct{}{}
+	for _, m := range s.env.Members {
+		ready[m.Name] = make(chan struct{})
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := range s.env.Members {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.startMember(ctx, i, ready); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					// the remaining members would be removed again anyway
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// startMember waits for the dependencies of member i and starts it. ready[name] is closed
+// once the member name is ready.
+func (s *environmentStart) startMember(ctx context.Context, i int, ready map[string]chan struct{}) error {
+	member := s.env.Members[i]
+	for _, d := range member.DependsOn {
+		s.forward(i, progress.Event{Percent: 0, Message: "Waiting for " + d})
+		select {
+		case <-ready[d]:
+		case <-ctx.Done():
+			return fmt.Errorf("%s: start cancelled", member.Name)
+		}
+	}
+
+	var config ServerConfiguration
+	if err := json.Unmarshal(member.Configuration, &config); err != nil {
+		return fmt.Errorf("%s: invalid configuration: %w", member.Name, err)
+	}
+	config.Network = s.env.Network
+	config.Aliases = append(config.Aliases, member.Name)
+
+	// the member reports to its own stream, which is forwarded to the environment stream
+	memberReqId := s.reqId + "-" + member.Name
+	ch := progress.Default.New(memberReqId)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for ev := range ch {
+			s.forward(i, ev)
+		}
+	}()
+	container, err := runServer(ctx, memberReqId, member.Type, config)
+	progress.Default.Remove(memberReqId)
+	<-forwarded
+
+	if container != nil {
+		s.mu.Lock()
+		s.env.Members[i].ContainerID = container.ID
+		s.mu.Unlock()
+		s.save()
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", member.Name, err)
+	}
+	close(ready[member.Name])
+	return nil
+}
+
+// forward sends an event of member i to the environment stream. The percentage is the
+// average of all members. An error event would end the stream for its clients, so errors of
+// members are reported by the final event, which names the member and its failed step.
+func (s *environmentStart) forward(i int, ev progress.Event) {
+	s.mu.Lock()
+	s.percents[i] = ev.Percent
+	total := 0
+	for _, p := range s.percents {
+		total += p
+	}
+	name := s.env.Members[i].Name
+	s.mu.Unlock()
+
+	percent := 10 + total*89/(100*len(s.percents))
+	progress.Default.Send(s.reqId, progress.Event{Percent: percent, Message: name + ": " + ev.Message, Error: false})
+}
+
+// rollback removes the containers of the started members and the network.
+func (s *environmentStart) rollback() {
+	s.mu.Lock()
+	members := slices.Clone(s.env.Members)
+	s.mu.Unlock()
+	for _, m := range members {
+		if m.ContainerID == "" {
+			continue
+		}
+		if err := DiscardContainer(m.ContainerID); err != nil {
+			log.Printf("Failed to remove container %s of environment %s: %v", m.ContainerID, s.env.Name, err)
+		}
+	}
+	removeEnvironmentNetwork(s.env.Network)
+}
+
+func (s *environmentStart) save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := updateEnvironmentFn(s.env.ID, s.env); err != nil {
+		log.Printf("Failed to store environment %s: %v", s.env.Name, err)
+	}
+}
+
+// failInterruptedEnvironmentStarts marks the environments that are still starting as failed.
+// Their start was interrupted by the last shutdown, so they could otherwise never be torn down.
+func failInterruptedEnvironmentStarts() {
+	environments, err := listEnvironmentsFn()
+	if err != nil {
+		log.Printf("List environments: %v", err)
+		return
+	}
+	for _, env := range environments {
+		if env.Status != dtos.Starting {
+			continue
+		}
+		log.Printf("Start of environment %s was interrupted, marking it as failed", env.Name)
+		env.Status, env.Error = dtos.Failed, "start interrupted by a restart of the application"
+		if err := updateEnvironmentFn(env.ID, env); err != nil {
+			log.Printf("Failed to store environment %s: %v", env.Name, err)
+		}
+	}
+}
+
+// TeardownEnvironment removes the containers and the network of the environment id and
+// marks it as discarded.
+func TeardownEnvironment(id string) (*dtos.Environment, error) {
+	env, err := getEnvironmentFn(id)
+	if err != nil {
+		return nil, err
+	}
+	switch env.Status {
+	case dtos.Starting:
+		return nil, fmt.Errorf("%w: environment %s is still starting", ErrConflict, env.Name)
+	case dtos.Discarded:
+		return nil, fmt.Errorf("%w: environment %s was already torn down", ErrConflict, env.Name)
+	}
+
+	log.Printf("Tearing down environment %s", env.Name)
+	var errs []error
+	for _, m := range env.Members {
+		if m.ContainerID == "" {
+			continue
+		}
+		// members may have been discarded on their own
+		if err := DiscardContainer(m.ContainerID); err != nil && !errors.Is(err, services.ErrInvalidTransition) {
+			errs = append(errs, fmt.Errorf("member %s: %w", m.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	removeEnvironmentNetwork(env.Network)
+
+	env.Status = dtos.Discarded
+	if err := updateEnvironmentFn(env.ID, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// removeEnvironmentNetwork removes the network of an environment once no member uses it.
+func removeEnvironmentNetwork(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := DeleteNetwork(ctx, name); err != nil && !errors.Is(err, ErrNetworkNotFound) {
+		log.Printf("Failed to remove network %s: %v", name, err)
+	}
+}
@@ -0,0 +1,369 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// fakeEnvironments is an in-memory replacement for the environments collection.
+type fakeEnvironments struct {
+	mu           sync.Mutex
+	environments map[string]*dtos.Environment
+	nextID       int
+}
+
+func useFakeEnvironments(t *testing.T, environments ...*dtos.Environment) *fakeEnvironments {
+	t.Helper()
+	f := &fakeEnvironments{environments: map[string]*dtos.Environment{}}
+	for _, e := range environments {
+		f.environments[e.ID] = e
+	}
+
+	oldCreate, oldList, oldGet, oldUpdate := createEnvironmentFn, listEnvironmentsFn, getEnvironmentFn, updateEnvironmentFn
+	createEnvironmentFn = func(e *dtos.Environment) (string, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.nextID++
+		id := fmt.Sprintf("env%d", f.nextID)
+		f.environments[id] = copyEnvironment(e)
+		f.environments[id].ID = id
+		return id, nil
+	}
+	listEnvironmentsFn = func() ([]*dtos.Environment, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		out := []*dtos.Environment{}
+		for _, e := range f.environments {
+			out = append(out, copyEnvironment(e))
+		}
+		return out, nil
+	}
+	getEnvironmentFn = func(id string) (*dtos.Environment, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		e, ok := f.environments[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", services.ErrEnvironmentNotFound, id)
+		}
+		return copyEnvironment(e), nil
+	}
+	updateEnvironmentFn = func(id string, e *dtos.Environment) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.environments[id] = copyEnvironment(e)
+		return nil
+	}
+	t.Cleanup(func() {
+		createEnvironmentFn, listEnvironmentsFn, getEnvironmentFn, updateEnvironmentFn = oldCreate, oldList, oldGet, oldUpdate
+	})
+	return f
+}
+
+func (f *fakeEnvironments) get(id string) *dtos.Environment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return copyEnvironment(f.environments[id])
+}
+
+func copyEnvironment(e *dtos.Environment) *dtos.Environment {
+	c := *e
+	c.Members = slices.Clone(e.Members)
+	return &c
+}
+
+func mailAndFtpSpec() EnvironmentSpec {
+	return EnvironmentSpec{
+		Name: "integration",
+		Members: []MemberSpec{
+			{Name: "mail", Type: "MAIL", DependsOn: []string{"ftp"}},
+			{Name: "ftp", Type: "FTP", Configuration: ServerConfiguration{Env: map[string]string{"FTP_USER": "bob"}}},
+		},
+	}
+}
+
+func TestCreateEnvironment_Validates(t *testing.T) {
+	useFakeRuntime(t)
+	useFakePresets(t)
+	useFakeEnvironments(t, &dtos.Environment{ID: "old", Name: "taken", Status: dtos.Running})
+
+	cases := map[string]struct {
+		spec EnvironmentSpec
+		want error
+	}{
+		"invalid name":       {EnvironmentSpec{Name: "-x", Members: mailAndFtpSpec().Members}, ErrInvalidEnvironment},
+		"no members":         {EnvironmentSpec{Name: "stack"}, ErrInvalidEnvironment},
+		"existing name":      {EnvironmentSpec{Name: "taken", Members: mailAndFtpSpec().Members}, ErrConflict},
+		"invalid member":     {EnvironmentSpec{Name: "stack", Members: []MemberSpec{{Name: "a b", Type: "FTP"}}}, ErrInvalidEnvironment},
+		"duplicate member":   {EnvironmentSpec{Name: "stack", Members: []MemberSpec{{Name: "a", Type: "FTP"}, {Name: "a", Type: "MAIL"}}}, ErrInvalidEnvironment},
+		"unknown type":       {EnvironmentSpec{Name: "stack", Members: []MemberSpec{{Name: "a", Type: "NOPE"}}}, ErrInvalidEnvironment},
+		"own network":        {EnvironmentSpec{Name: "stack", Members: []MemberSpec{{Name: "a", Type: "FTP", Configuration: ServerConfiguration{Network: "other"}}}}, ErrInvalidEnvironment},
+		"unknown dependency": {EnvironmentSpec{Name: "stack", Members: []MemberSpec{{Name: "a", Type: "FTP", DependsOn: []string{"b"}}}}, ErrInvalidEnvironment},
+		"cycle": {EnvironmentSpec{Name: "stack", Members: []MemberSpec{
+			{Name: "a", Type: "FTP", DependsOn: []string{"b"}},
+			{Name: "b", Type: "MAIL", DependsOn: []string{"a"}},
+		}}, ErrInvalidEnvironment},
+		"preset of other type": {EnvironmentSpec{Name: "stack", Members: []MemberSpec{{Name: "a", Type: "FTP", Preset: "builtin-mail"}}}, ErrInvalidEnvironment},
+	}
+	for name, tc := range cases {
+		if _, err := CreateEnvironment(context.Background(), tc.spec); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+
+	_, err := CreateEnvironment(context.Background(), EnvironmentSpec{Name: "stack", Members: []MemberSpec{
+		{Name: "mail", Type: "MAIL", Configuration: ServerConfiguration{Env: map[string]string{"MH_STORAGE": "disk"}}},
+	}})
+	var configErr *servers.ConfigError
+	if !errors.As(err, &configErr) || configErr.Fields["members.mail.env.MH_STORAGE"] == "" {
+		t.Fatalf("expected member field errors, got %v", err)
+	}
+}
+
+func TestCreateEnvironment_StoresMembers(t *testing.T) {
+	useFakeRuntime(t)
+	useFakePresets(t)
+	store := useFakeEnvironments(t)
+
+	spec := mailAndFtpSpec()
+	spec.Members = append(spec.Members, MemberSpec{Name: "mail2", Preset: "builtin-mail"})
+	env, err := CreateEnvironment(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("CreateEnvironment: %v", err)
+	}
+	stored := store.get(env.ID)
+	if stored.Status != dtos.Starting || stored.Network != "simple-test-server-integration" || len(stored.Members) != 3 {
+		t.Fatalf("unexpected environment %+v", stored)
+	}
+	if stored.Members[2].Type != "MAIL" || !strings.Contains(string(stored.Members[2].Configuration), `"name":"simple-test-server-integration-mail2"`) {
+		t.Fatalf("expected the preset type and a derived container name, got %+v", stored.Members[2])
+	}
+}
+
+func TestStartEnvironmentWithProgress_StartsInDependencyOrder(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakePresets(t)
+	records := useFakeRecords(t)
+	stubHostPorts(t)
+	stubProbe(t, nil)
+	store := useFakeEnvironments(t)
+	// without the dependency the mail server would be started first
+	rt.PullFn = func(ctx context.Context, image string) error {
+		if strings.HasPrefix(image, "garethflowers/ftp-server") {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return nil
+	}
+
+	env, err := CreateEnvironment(context.Background(), mailAndFtpSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	StartEnvironmentWithProgress("req-env", env.ID)
+	events := collectEvents(t, "req-env")
+
+	last := events[len(events)-1]
+	if last.Percent != 100 || last.Error || last.Message != "Environment started" {
+		t.Fatalf("unexpected final event %+v (all: %+v)", last, events)
+	}
+	if !hasEvent(events, "mail: Waiting for ftp") || !hasEvent(events, "ftp: Started") {
+		t.Fatalf("expected the member events in the environment stream, got %+v", events)
+	}
+	for _, ev := range events[:len(events)-1] {
+		if ev.Percent >= 100 {
+			t.Fatalf("expected member events below 100%%, got %+v", ev)
+		}
+	}
+
+	if len(rt.Runs) != 2 || rt.Runs[0].Name != "simple-test-server-integration-ftp" {
+		t.Fatalf("expected ftp to be started first, got %+v", rt.Runs)
+	}
+	for _, run := range rt.Runs {
+		if run.Network != "simple-test-server-integration" {
+			t.Fatalf("expected the environment network, got %s", run.Network)
+		}
+	}
+	if !slices.Contains(rt.Runs[1].Aliases, "mail") {
+		t.Fatalf("expected the member name as alias, got %v", rt.Runs[1].Aliases)
+	}
+
+	stored := store.get(env.ID)
+	if stored.Status != dtos.Running {
+		t.Fatalf("expected running environment, got %+v", stored)
+	}
+	for _, m := range stored.Members {
+		if r := records.get(m.ContainerID); r == nil || r.Status != dtos.Running {
+			t.Fatalf("expected running container for member %s, got %+v", m.Name, r)
+		}
+	}
+}
+
+func TestStartEnvironmentWithProgress_RollsBackOnFailure(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakePresets(t)
+	records := useFakeRecords(t)
+	stubHostPorts(t)
+	stubProbe(t, nil)
+	store := useFakeEnvironments(t)
+	rt.PullFn = func(ctx context.Context, image string) error {
+		if strings.HasPrefix(image, "mailhog/") {
+			time.Sleep(50 * time.Millisecond)
+			return errors.New("registry unavailable")
+		}
+		return nil
+	}
+
+	spec := mailAndFtpSpec()
+	spec.Members[0].DependsOn = nil
+	env, err := CreateEnvironment(context.Background(), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	StartEnvironmentWithProgress("req-env-fail", env.ID)
+	events := collectEvents(t, "req-env-fail")
+
+	last := events[len(events)-1]
+	if !last.Error || !strings.Contains(last.Message, "mail: pull failed") {
+		t.Fatalf("unexpected final event %+v", last)
+	}
+	stored := store.get(env.ID)
+	if stored.Status != dtos.Failed || stored.Error == "" {
+		t.Fatalf("expected failed environment, got %+v", stored)
+	}
+	if len(rt.Containers) != 0 {
+		t.Fatalf("expected the started members to be removed, got %d containers", len(rt.Containers))
+	}
+	for _, m := range stored.Members {
+		if m.ContainerID != "" && records.get(m.ContainerID).Status != dtos.Discarded {
+			t.Fatalf("expected member %s to be discarded", m.Name)
+		}
+	}
+	if _, ok := rt.Networks[stored.Network]; ok {
+		t.Fatalf("expected network %s to be removed", stored.Network)
+	}
+}
+
+func TestStartEnvironmentWithProgress_StopsWaitingMembersOnFailure(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakePresets(t)
+	useFakeRecords(t)
+	stubHostPorts(t)
+	store := useFakeEnvironments(t)
+	// ftp never becomes ready within its timeout of 30s
+	stubProbe(t, errors.New("connection refused"))
+	rt.PullFn = func(ctx context.Context, image string) error {
+		if strings.HasPrefix(image, "mailhog/") {
+			time.Sleep(200 * time.Millisecond)
+			return errors.New("registry unavailable")
+		}
+		return nil
+	}
+
+	spec := mailAndFtpSpec()
+	spec.Members[0].DependsOn = nil
+	env, err := CreateEnvironment(context.Background(), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now()
+	StartEnvironmentWithProgress("req-env-cancel", env.ID)
+	events := collectEvents(t, "req-env-cancel")
+
+	if time.Since(started) > 10*time.Second {
+		t.Fatalf("the waiting member must be cancelled, took %s", time.Since(started))
+	}
+	if last := events[len(events)-1]; !last.Error || !strings.Contains(last.Message, "mail: pull failed") {
+		t.Fatalf("unexpected final event %+v", last)
+	}
+	if stored := store.get(env.ID); stored.Status != dtos.Failed {
+		t.Fatalf("expected failed environment, got %+v", stored)
+	}
+}
+
+func TestCreateEnvironment_ConcurrentNameIsAConflict(t *testing.T) {
+	useFakeRuntime(t)
+	useFakePresets(t)
+	useFakeEnvironments(t)
+	// another request stored the name after the check of this one
+	createEnvironmentFn = func(e *dtos.Environment) (string, error) {
+		return "", fmt.Errorf("%w: %s", services.ErrEnvironmentExists, e.Name)
+	}
+
+	if _, err := CreateEnvironment(context.Background(), mailAndFtpSpec()); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+}
+
+func TestTeardownEnvironment(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakePresets(t)
+	records := useFakeRecords(t)
+	stubHostPorts(t)
+	stubProbe(t, nil)
+	store := useFakeEnvironments(t)
+
+	env, err := CreateEnvironment(context.Background(), mailAndFtpSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TeardownEnvironment(env.ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict while starting, got %v", err)
+	}
+	StartEnvironmentWithProgress("req-env-teardown", env.ID)
+
+	torndown, err := TeardownEnvironment(env.ID)
+	if err != nil {
+		t.Fatalf("TeardownEnvironment: %v", err)
+	}
+	if torndown.Status != dtos.Discarded || store.get(env.ID).Status != dtos.Discarded {
+		t.Fatalf("expected discarded environment, got %+v", torndown)
+	}
+	for _, m := range torndown.Members {
+		if records.get(m.ContainerID).Status != dtos.Discarded {
+			t.Fatalf("expected member %s to be discarded", m.Name)
+		}
+	}
+	if len(rt.Containers) != 0 {
+		t.Fatalf("expected no containers, got %d", len(rt.Containers))
+	}
+	if _, ok := rt.Networks[env.Network]; ok {
+		t.Fatalf("expected network %s to be removed", env.Network)
+	}
+	if _, err := TeardownEnvironment(env.ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict on second teardown, got %v", err)
+	}
+}
+
+func TestFailInterruptedEnvironmentStarts_AllowsTeardown(t *testing.T) {
+	rt := useFakeRuntime(t)
+	records := useFakeRecords(t, &dtos.Container{ID: "mail-1", Name: "mail-1", Status: dtos.Running})
+	rt.AddContainer("mail-1", "mail-1", "mailhog")
+	store := useFakeEnvironments(t,
+		&dtos.Environment{ID: "env1", Name: "integration", Status: dtos.Starting, Members: []dtos.EnvironmentMember{{Name: "mail", ContainerID: "mail-1"}}},
+		&dtos.Environment{ID: "env2", Name: "nightly", Status: dtos.Running},
+	)
+
+	failInterruptedEnvironmentStarts()
+	if e := store.get("env1"); e.Status != dtos.Failed || e.Error == "" {
+		t.Fatalf("expected the interrupted environment to fail, got %+v", e)
+	}
+	if e := store.get("env2"); e.Status != dtos.Running {
+		t.Fatalf("a running environment must be kept, got %+v", e)
+	}
+
+	if _, err := TeardownEnvironment("env1"); err != nil {
+		t.Fatalf("TeardownEnvironment: %v", err)
+	}
+	if records.get("mail-1").Status != dtos.Discarded {
+		t.Fatalf("expected the member to be discarded")
+	}
+}
@@ -2,20 +2,42 @@ package docker
 
 import (
 	"context"
-	"os/exec"
+	"fmt"
+	"slices"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
 )
 
-// execCmdWrapper uses the real os/exec.CommandContext to run commands.
-type execCmdWrapper struct {
-	ctx  context.Context
-	name string
-	args []string
-}
+// AttachShell starts cmd interactively in the managed container containerId. The command
+// must be one executable on the allowlist of the container's server type without arguments,
+// as arguments would let any allowed shell run anything; an empty cmd starts the first
+// allowed command. The caller must close the returned session.
+func AttachShell(ctx context.Context, containerId string, cmd []string) (ExecSession, error) {
+	container, err := getContainerFn(containerId)
+	if err != nil {
+		return nil, err
+	}
+	if container.Status != dtos.Running {
+		return nil, fmt.Errorf("%w: container %s is %s", ErrContainerNotRunning, container.Name, container.Status)
+	}
 
-// CombinedOutput runs the command and returns combined stdout/stderr.
-func (e *execCmdWrapper) CombinedOutput() ([]byte, error) {
-	cmd := exec.CommandContext(e.ctx, e.name, e.args...)
-	return cmd.CombinedOutput()
-}
+	server, err := servers.GetServerByType(container.Type)
+	if err != nil {
+		return nil, err
+	}
+	if len(cmd) == 0 {
+		if len(server.ExecCommands) == 0 {
+			return nil, fmt.Errorf("%w: %s containers allow no commands", ErrCommandNotAllowed, container.Type)
+		}
+		cmd = []string{server.ExecCommands[0]}
+	}
+	if !slices.Contains(server.ExecCommands, cmd[0]) {
+		return nil, fmt.Errorf("%w: %s is not allowed for %s containers", ErrCommandNotAllowed, cmd[0], container.Type)
+	}
+	if len(cmd) > 1 {
+		return nil, fmt.Errorf("%w: %s must be started without arguments", ErrCommandNotAllowed, cmd[0])
+	}
 
-// Note: tests can replace ExecCommandContextFn with a stub that returns the desired output.
+	return Runtime.AttachExec(ctx, container.ID, cmd)
+}
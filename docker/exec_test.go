@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+func TestAttachShell_EnforcesAllowlist(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("mqtt-1", "mqtt-1", "mosquitto")
+	rt.AddContainer("otel-1", "otel-1", "otel")
+	useFakeRecords(t,
+		&dtos.Container{ID: "mqtt-1", Name: "mqtt-1", Type: "MQTT", Status: dtos.Running},
+		&dtos.Container{ID: "otel-1", Name: "otel-1", Type: "OTEL", Status: dtos.Running},
+	)
+
+	if _, err := AttachShell(context.Background(), "mqtt-1", []string{"rm", "-rf", "/"}); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Fatalf("expected ErrCommandNotAllowed, got %v", err)
+	}
+	if _, err := AttachShell(context.Background(), "otel-1", nil); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Fatalf("expected types without commands to be rejected, got %v", err)
+	}
+
+	// an allowed shell must not run arbitrary commands through its arguments
+	if _, err := AttachShell(context.Background(), "mqtt-1", []string{"sh", "-c", "rm -rf /"}); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Fatalf("expected arguments to be rejected, got %v", err)
+	}
+
+	var started []string
+	rt.AttachFn = func(c *FakeContainer, cmd []string) (ExecSession, error) {
+		started = cmd
+		return NewEchoSession(), nil
+	}
+	session, err := AttachShell(context.Background(), "mqtt-1", []string{"mosquitto_sub"})
+	if err != nil {
+		t.Fatalf("AttachShell: %v", err)
+	}
+	session.Close()
+	if len(started) != 1 || started[0] != "mosquitto_sub" {
+		t.Fatalf("unexpected command %v", started)
+	}
+
+	session, err = AttachShell(context.Background(), "mqtt-1", nil)
+	if err != nil {
+		t.Fatalf("AttachShell: %v", err)
+	}
+	session.Close()
+	if len(started) != 1 || started[0] != "sh" {
+		t.Fatalf("expected the first allowed command by default, got %v", started)
+	}
+}
+
+func TestAttachShell_RequiresRunningContainer(t *testing.T) {
+	useFakeRuntime(t)
+	useFakeRecords(t, &dtos.Container{ID: "web-1", Name: "web-1", Type: "WEB", Status: dtos.Stopped})
+
+	if _, err := AttachShell(context.Background(), "web-1", []string{"sh"}); !errors.Is(err, ErrContainerNotRunning) {
+		t.Fatalf("expected ErrContainerNotRunning, got %v", err)
+	}
+	if _, err := AttachShell(context.Background(), "missing", []string{"sh"}); err == nil {
+		t.Fatal("expected an error for unknown containers")
+	}
+}
+
+func TestEchoSession_EchoesInput(t *testing.T) {
+	session := NewEchoSession()
+	defer session.Close()
+
+	go session.Write([]byte("ls\n"))
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(session, buf); err != nil || string(buf) != "ls\n" {
+		t.Fatalf("unexpected echo %q: %v", buf, err)
+	}
+	session.Resize(context.Background(), 24, 80)
+	if calls := session.ResizeCalls(); len(calls) != 1 || calls[0] != [2]uint{24, 80} {
+		t.Fatalf("unexpected resizes %v", calls)
+	}
+}
@@ -0,0 +1,670 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeRuntime is an in-memory ContainerRuntime for tests. It never talks to a daemon.
+type FakeRuntime struct {
+	mu sync.Mutex
+
+	Images      map[string]bool
+	ImageLabels map[string]map[string]string
+	Containers  map[string]*FakeContainer
+	Volumes     map[string]*VolumeInfo
+	Networks    map[string]*NetworkInfo
+
+	Pulled   []string
+	Built    []BuildOptions
+	Commits  []CommitOptions
+	LogCalls []LogsOptions
+	// Runs records the spec of every container started, including removed ones.
+	Runs []ContainerSpec
+	// BuildOutput is reported line by line to the Output of every build.
+	BuildOutput []string
+	// PullProgress is reported to the progress callback of every pull.
+	PullProgress []PullProgress
+
+	// Err* fields make the corresponding operation fail when set.
+	ErrPull  error
+	ErrBuild error
+	ErrRun   error
+
+	// ExecFn answers Exec calls. When nil, Exec returns empty output with exit code 0.
+	ExecFn func(c *FakeContainer, cmd []string) (*ExecResult, error)
+	// AttachFn answers AttachExec calls. When nil, the session echoes its input.
+	AttachFn func(c *FakeContainer, cmd []string) (ExecSession, error)
+	// Stats answers ContainerStats calls by container id.
+	Stats map[string]ContainerStats
+	// PullFn is called before every pull, e.g. to block until the context is cancelled.
+	PullFn func(ctx context.Context, image string) error
+	// BuildFn is called before every build, like PullFn.
+	BuildFn func(ctx context.Context, opts BuildOptions) error
+	// WaitFn answers WaitContainer calls. When nil, containers exit with code 0.
+	WaitFn func(c *FakeContainer) (int, error)
+	// HostPortsInUse makes starting a container that publishes one of these host ports fail.
+	HostPortsInUse map[int]bool
+
+	nextID      int
+	subscribers []chan ContainerEvent
+}
+
+// FakeContainer is a container held by FakeRuntime.
+type FakeContainer struct {
+	Info  ContainerInfo
+	Spec  ContainerSpec
+	Logs  []string
+	Files map[string][]byte
+}
+
+// NewFakeRuntime returns an empty FakeRuntime.
+func NewFakeRuntime() *FakeRuntime {
+	return &FakeRuntime{
+		Images:      map[string]bool{},
+		ImageLabels: map[string]map[string]string{},
+		Stats:       map[string]ContainerStats{},
+		Containers:  map[string]*FakeContainer{},
+		Volumes:     map[string]*VolumeInfo{},
+		Networks:    map[string]*NetworkInfo{},
+	}
+}
+
+func (f *FakeRuntime) Name() string {
+	return "fake"
+}
+
+func (f *FakeRuntime) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (f *FakeRuntime) ImageExists(ctx context.Context, image string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Images[image], nil
+}
+
+func (f *FakeRuntime) InspectImage(ctx context.Context, image string) (*ImageInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.Images[image] {
+		return nil, &RuntimeError{Op: "inspect image", StatusCode: 404, Message: "No such image: " + image, Err: ErrImageNotFound}
+	}
+	return &ImageInfo{ID: image, Tags: []string{image}, Labels: f.ImageLabels[image]}, nil
+}
+
+func (f *FakeRuntime) PullImage(ctx context.Context, image string, onProgress func(PullProgress)) error {
+	if f.PullFn != nil {
+		if err := f.PullFn(ctx, image); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ErrPull != nil {
+		return f.ErrPull
+	}
+	if onProgress != nil {
+		for _, p := range f.PullProgress {
+			onProgress(p)
+		}
+	}
+	f.Pulled = append(f.Pulled, image)
+	f.Images[image] = true
+	return nil
+}
+
+func (f *FakeRuntime) BuildImage(ctx context.Context, opts BuildOptions) error {
+	if f.BuildFn != nil {
+		if err := f.BuildFn(ctx, opts); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ErrBuild != nil {
+		return f.ErrBuild
+	}
+	f.Built = append(f.Built, opts)
+	f.Images[opts.Tag] = true
+	f.ImageLabels[opts.Tag] = opts.Labels
+	if opts.Output != nil {
+		for _, line := range f.BuildOutput {
+			opts.Output(line)
+		}
+	}
+	return nil
+}
+
+func (f *FakeRuntime) CommitContainer(ctx context.Context, id string, opts CommitOptions) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.lookup(id); err != nil {
+		return "", err
+	}
+	image := opts.Repository + ":" + opts.Tag
+	f.Commits = append(f.Commits, opts)
+	f.Images[image] = true
+	f.ImageLabels[image] = opts.Labels
+	return "sha256:" + image, nil
+}
+
+func (f *FakeRuntime) RemoveImage(ctx context.Context, image string, force bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.Images[image] {
+		return &RuntimeError{Op: "remove image", StatusCode: 404, Message: "No such image: " + image, Err: ErrImageNotFound}
+	}
+	delete(f.Images, image)
+	delete(f.ImageLabels, image)
+	return nil
+}
+
+// AddContainer registers a running container and returns it so tests can attach logs or files.
+func (f *FakeRuntime) AddContainer(id string, name string, image string) *FakeContainer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := &FakeContainer{
+		Info: ContainerInfo{
+			ID:     id,
+			Name:   name,
+			Image:  image,
+			Labels: managedLabels(),
+			State:  ContainerState{Status: "running", Running: true, StartedAt: time.Now()},
+		},
+		Files: map[string][]byte{},
+	}
+	f.Containers[id] = c
+	return c
+}
+
+func (f *FakeRuntime) RunContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ErrRun != nil {
+		return "", f.ErrRun
+	}
+	if !f.Images[spec.Image] {
+		return "", &RuntimeError{Op: "create container", StatusCode: 404, Message: "No such image: " + spec.Image, Err: ErrImageNotFound}
+	}
+	for _, hp := range spec.Ports {
+		if f.HostPortsInUse[hp] {
+			return "", &RuntimeError{Op: "start container", StatusCode: 500, Message: fmt.Sprintf("Bind for 0.0.0.0:%d failed: port is already allocated", hp), Err: ErrPortInUse}
+		}
+	}
+	for _, c := range f.Containers {
+		if spec.Name != "" && c.Info.Name == spec.Name {
+			return "", &RuntimeError{Op: "create container", StatusCode: 409, Message: "name already in use: " + spec.Name, Err: ErrConflict}
+		}
+	}
+
+	if spec.Network != "" {
+		n, ok := f.Networks[spec.Network]
+		if !ok {
+			return "", &RuntimeError{Op: "create container", StatusCode: 404, Message: "network " + spec.Network + " not found", Err: ErrNetworkNotFound}
+		}
+		n.Containers = append(n.Containers, spec.Name)
+	}
+	f.Runs = append(f.Runs, spec)
+	f.nextID++
+	id := fmt.Sprintf("fake%060d", f.nextID)
+	f.Containers[id] = &FakeContainer{
+		Info: ContainerInfo{
+			ID:     id,
+			Name:   spec.Name,
+			Image:  spec.Image,
+			Labels: spec.Labels,
+			State:  ContainerState{Status: "running", Running: true, StartedAt: time.Now()},
+		},
+		Spec:  spec,
+		Files: map[string][]byte{},
+	}
+	return id, nil
+}
+
+func (f *FakeRuntime) RemoveContainer(ctx context.Context, id string, force bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.lookup(id)
+	if err != nil {
+		return err
+	}
+	if c.Info.State.Running && !force {
+		return &RuntimeError{Op: "remove container", StatusCode: 409, Message: "container is running", Err: ErrConflict}
+	}
+	delete(f.Containers, c.Info.ID)
+	// removed containers leave their networks
+	for _, n := range f.Networks {
+		n.Containers = slices.DeleteFunc(n.Containers, func(name string) bool { return name == c.Info.Name })
+	}
+	return nil
+}
+
+func (f *FakeRuntime) StartContainer(ctx context.Context, id string) error {
+	return f.setState(id, func(s *ContainerState) error {
+		if !s.Running {
+			*s = ContainerState{Status: "running", Running: true, StartedAt: time.Now()}
+		}
+		return nil
+	})
+}
+
+func (f *FakeRuntime) StopContainer(ctx context.Context, id string, timeout time.Duration) error {
+	return f.setState(id, func(s *ContainerState) error {
+		if s.Running {
+			*s = ContainerState{Status: "exited", ExitCode: 0, StartedAt: s.StartedAt, FinishedAt: time.Now()}
+		}
+		return nil
+	})
+}
+
+func (f *FakeRuntime) RestartContainer(ctx context.Context, id string, timeout time.Duration) error {
+	return f.setState(id, func(s *ContainerState) error {
+		*s = ContainerState{Status: "running", Running: true, StartedAt: time.Now()}
+		return nil
+	})
+}
+
+func (f *FakeRuntime) PauseContainer(ctx context.Context, id string) error {
+	return f.setState(id, func(s *ContainerState) error {
+		if !s.Running || s.Paused {
+			return &RuntimeError{Op: "pause container", StatusCode: 409, Message: "container is not running", Err: ErrContainerNotRunning}
+		}
+		s.Status, s.Paused = "paused", true
+		return nil
+	})
+}
+
+func (f *FakeRuntime) UnpauseContainer(ctx context.Context, id string) error {
+	return f.setState(id, func(s *ContainerState) error {
+		if !s.Paused {
+			return &RuntimeError{Op: "unpause container", StatusCode: 409, Message: "container is not paused", Err: ErrConflict}
+		}
+		s.Status, s.Paused = "running", false
+		return nil
+	})
+}
+
+func (f *FakeRuntime) setState(id string, change func(s *ContainerState) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.lookup(id)
+	if err != nil {
+		return err
+	}
+	return change(&c.Info.State)
+}
+
+func (f *FakeRuntime) InspectContainer(ctx context.Context, id string) (*ContainerInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	info := c.Info
+	return &info, nil
+}
+
+func (f *FakeRuntime) ListContainers(ctx context.Context, labels map[string]string) ([]ContainerInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]ContainerInfo, 0, len(f.Containers))
+	for _, c := range f.Containers {
+		if hasLabels(c.Info.Labels, labels) {
+			out = append(out, c.Info)
+		}
+	}
+	return out, nil
+}
+
+func (f *FakeRuntime) Logs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.LogCalls = append(f.LogCalls, opts)
+	c, err := f.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	lines := c.Logs
+	if opts.Tail > 0 && len(lines) > opts.Tail {
+		lines = lines[len(lines)-opts.Tail:]
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	return io.NopCloser(strings.NewReader(b.String())), nil
+}
+
+func (f *FakeRuntime) Exec(ctx context.Context, id string, cmd []string) (*ExecResult, error) {
+	f.mu.Lock()
+	c, err := f.lookup(id)
+	execFn := f.ExecFn
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !c.Info.State.Running {
+		return nil, &RuntimeError{Op: "create exec", StatusCode: 409, Message: "container is not running", Err: ErrContainerNotRunning}
+	}
+	if execFn == nil {
+		return &ExecResult{}, nil
+	}
+	return execFn(c, cmd)
+}
+
+func (f *FakeRuntime) AttachExec(ctx context.Context, id string, cmd []string) (ExecSession, error) {
+	f.mu.Lock()
+	c, err := f.lookup(id)
+	attachFn := f.AttachFn
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !c.Info.State.Running {
+		return nil, &RuntimeError{Op: "create exec", StatusCode: 409, Message: "container is not running", Err: ErrContainerNotRunning}
+	}
+	if attachFn == nil {
+		return NewEchoSession(), nil
+	}
+	return attachFn(c, cmd)
+}
+
+// EchoSession is an ExecSession that returns everything written to it and records resizes.
+type EchoSession struct {
+	*io.PipeReader
+	w *io.PipeWriter
+
+	mu      sync.Mutex
+	resizes [][2]uint
+}
+
+// NewEchoSession returns an open EchoSession.
+func NewEchoSession() *EchoSession {
+	r, w := io.Pipe()
+	return &EchoSession{PipeReader: r, w: w}
+}
+
+func (s *EchoSession) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s *EchoSession) Close() error {
+	s.w.Close()
+	return s.PipeReader.Close()
+}
+
+func (s *EchoSession) Resize(ctx context.Context, rows uint, cols uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resizes = append(s.resizes, [2]uint{rows, cols})
+	return nil
+}
+
+// ResizeCalls returns the sizes passed to Resize so far.
+func (s *EchoSession) ResizeCalls() [][2]uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][2]uint{}, s.resizes...)
+}
+
+func (f *FakeRuntime) ContainerStats(ctx context.Context, id string) (*ContainerStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Info.State.Running {
+		return nil, &RuntimeError{Op: "container stats", StatusCode: 409, Message: "container is not running", Err: ErrContainerNotRunning}
+	}
+	stats := f.Stats[c.Info.ID]
+	stats.ID = c.Info.ID
+	stats.Name = c.Info.Name
+	if stats.Time.IsZero() {
+		stats.Time = time.Now()
+	}
+	return &stats, nil
+}
+
+func (f *FakeRuntime) CopyToContainer(ctx context.Context, id string, destDir string, content io.Reader) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.lookup(id)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(content)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil {
+			return err
+		}
+		c.Files[path.Join(destDir, header.Name)] = buf.Bytes()
+	}
+}
+
+func (f *FakeRuntime) Events(ctx context.Context, labels map[string]string) (<-chan ContainerEvent, <-chan error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan ContainerEvent, 16)
+	f.subscribers = append(f.subscribers, ch)
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, s := range f.subscribers {
+			if s == ch {
+				f.subscribers = append(f.subscribers[:i], f.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+	return ch, make(chan error)
+}
+
+// Emit delivers ev to every open Events stream.
+func (f *FakeRuntime) Emit(ev ContainerEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range f.subscribers {
+		ch <- ev
+	}
+}
+
+func (f *FakeRuntime) WaitContainer(ctx context.Context, id string) (int, error) {
+	f.mu.Lock()
+	c, err := f.lookup(id)
+	waitFn := f.WaitFn
+	f.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	code := 0
+	if waitFn != nil {
+		if code, err = waitFn(c); err != nil {
+			return 0, err
+		}
+	}
+	f.mu.Lock()
+	c.Info.State = ContainerState{Status: "exited", ExitCode: code, StartedAt: c.Info.State.StartedAt, FinishedAt: time.Now()}
+	f.mu.Unlock()
+	return code, nil
+}
+
+func (f *FakeRuntime) CreateVolume(ctx context.Context, name string, labels map[string]string) (*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.Volumes[name]; ok {
+		// like docker, creating an existing volume returns it unchanged
+		info := *v
+		return &info, nil
+	}
+	v := &VolumeInfo{Name: name, Driver: "local", Mountpoint: "/var/lib/docker/volumes/" + name + "/_data", Labels: labels, CreatedAt: time.Now()}
+	f.Volumes[name] = v
+	info := *v
+	return &info, nil
+}
+
+func (f *FakeRuntime) InspectVolume(ctx context.Context, name string) (*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.Volumes[name]
+	if !ok {
+		return nil, &RuntimeError{Op: "inspect volume", StatusCode: 404, Message: "no such volume: " + name, Err: ErrVolumeNotFound}
+	}
+	info := *v
+	return &info, nil
+}
+
+func (f *FakeRuntime) ListVolumes(ctx context.Context, labels map[string]string) ([]VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]VolumeInfo, 0, len(f.Volumes))
+	for _, v := range f.Volumes {
+		if hasLabels(v.Labels, labels) {
+			out = append(out, *v)
+		}
+	}
+	return out, nil
+}
+
+func (f *FakeRuntime) RemoveVolume(ctx context.Context, name string, force bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.Volumes[name]; !ok {
+		return &RuntimeError{Op: "remove volume", StatusCode: 404, Message: "no such volume: " + name, Err: ErrVolumeNotFound}
+	}
+	for _, c := range f.Containers {
+		for _, m := range c.Spec.Mounts {
+			if m.Source == name {
+				return &RuntimeError{Op: "remove volume", StatusCode: 409, Message: "volume is in use", Err: ErrConflict}
+			}
+		}
+	}
+	delete(f.Volumes, name)
+	return nil
+}
+
+func (f *FakeRuntime) CreateNetwork(ctx context.Context, name string, labels map[string]string) (*NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.Networks[name]; ok {
+		return nil, &RuntimeError{Op: "create network", StatusCode: 409, Message: "network with name " + name + " already exists", Err: ErrConflict}
+	}
+	n := &NetworkInfo{ID: "net-" + name, Name: name, Driver: "bridge", Labels: labels, CreatedAt: time.Now(), Containers: []string{}}
+	f.Networks[name] = n
+	info := *n
+	return &info, nil
+}
+
+func (f *FakeRuntime) InspectNetwork(ctx context.Context, name string) (*NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.Networks[name]
+	if !ok {
+		return nil, &RuntimeError{Op: "inspect network", StatusCode: 404, Message: "network " + name + " not found", Err: ErrNetworkNotFound}
+	}
+	info := *n
+	info.Containers = append([]string{}, n.Containers...)
+	return &info, nil
+}
+
+func (f *FakeRuntime) ListNetworks(ctx context.Context, labels map[string]string) ([]NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]NetworkInfo, 0, len(f.Networks))
+	for _, n := range f.Networks {
+		if hasLabels(n.Labels, labels) {
+			out = append(out, *n)
+		}
+	}
+	return out, nil
+}
+
+func (f *FakeRuntime) RemoveNetwork(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.Networks[name]
+	if !ok {
+		return &RuntimeError{Op: "remove network", StatusCode: 404, Message: "network " + name + " not found", Err: ErrNetworkNotFound}
+	}
+	if len(n.Containers) > 0 {
+		return &RuntimeError{Op: "remove network", StatusCode: 403, Message: "network " + name + " has active endpoints", Err: ErrConflict}
+	}
+	delete(f.Networks, name)
+	return nil
+}
+
+func (f *FakeRuntime) ConnectNetwork(ctx context.Context, network string, containerId string, aliases []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.Networks[network]
+	if !ok {
+		return &RuntimeError{Op: "connect network", StatusCode: 404, Message: "network " + network + " not found", Err: ErrNetworkNotFound}
+	}
+	for _, c := range n.Containers {
+		if c == containerId {
+			return &RuntimeError{Op: "connect network", StatusCode: 403, Message: "endpoint already exists in network", Err: ErrConflict}
+		}
+	}
+	n.Containers = append(n.Containers, containerId)
+	return nil
+}
+
+func (f *FakeRuntime) DisconnectNetwork(ctx context.Context, network string, containerId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.Networks[network]
+	if !ok {
+		return &RuntimeError{Op: "disconnect network", StatusCode: 404, Message: "network " + network + " not found", Err: ErrNetworkNotFound}
+	}
+	for i, c := range n.Containers {
+		if c == containerId {
+			n.Containers = append(n.Containers[:i], n.Containers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// lookup finds a container by id or name. The caller must hold f.mu.
+func (f *FakeRuntime) lookup(idOrName string) (*FakeContainer, error) {
+	if c, ok := f.Containers[idOrName]; ok {
+		return c, nil
+	}
+	for _, c := range f.Containers {
+		if c.Info.Name == idOrName {
+			return c, nil
+		}
+	}
+	return nil, &RuntimeError{Op: "inspect container", StatusCode: 404, Message: "No such container: " + idOrName, Err: ErrContainerNotFound}
+}
+
+func hasLabels(have map[string]string, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/config"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+// janitorInterval is how often expired containers are looked for.
+var janitorInterval = 30 * time.Second
+
+// ErrNoExpiry is returned when extending the lease of a container that never expires.
+var ErrNoExpiry = errors.New("container does not expire")
+
+// expiresAt returns the expiry time in unix milliseconds for a container started at now with
+// the requested TTL in minutes, or 0 if it never expires. A TTL of 0 uses the configured default.
+func expiresAt(ttlMinutes int, now time.Time) int64 {
+	if ttlMinutes == 0 && config.EnvConfig != nil {
+		ttlMinutes = config.EnvConfig.DefaultTTLMinutes
+	}
+	if ttlMinutes <= 0 {
+		return 0
+	}
+	return now.Add(time.Duration(ttlMinutes) * time.Minute).UnixMilli()
+}
+
+// RunJanitor discards expired containers until ctx is cancelled.
+func RunJanitor(ctx context.Context) {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			discardExpiredContainers(time.Now())
+		}
+	}
+}
+
+// discardExpiredContainers removes every container whose lease ended before now.
+func discardExpiredContainers(now time.Time) {
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		log.Printf("Janitor: list containers failed: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		if record.Status == dtos.Discarded || record.ExpiresAt == 0 || record.ExpiresAt > now.UnixMilli() {
+			continue
+		}
+		log.Printf("Janitor: container %s expired, discarding it", record.Name)
+		if err := DiscardContainer(record.ID); err != nil {
+			log.Printf("Janitor: discard container %s failed: %v", record.Name, err)
+		}
+	}
+}
+
+// ExtendContainerLease moves the expiry of a container by the given duration. Leases that
+// already ended are extended from now.
+func ExtendContainerLease(containerId string, by time.Duration) (*dtos.Container, error) {
+	container, err := getContainerFn(containerId)
+	if err != nil {
+		return nil, err
+	}
+	if container.Status == dtos.Discarded {
+		return nil, fmt.Errorf("%w: container is discarded", services.ErrInvalidTransition)
+	}
+	if container.ExpiresAt == 0 {
+		return nil, ErrNoExpiry
+	}
+
+	base := time.UnixMilli(container.ExpiresAt)
+	if now := time.Now(); base.Before(now) {
+		base = now
+	}
+	container.ExpiresAt = base.Add(by).UnixMilli()
+	if err := updateContainerFn(container.ID, container); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
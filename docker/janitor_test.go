@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+func TestExpiresAt(t *testing.T) {
+	now := time.UnixMilli(1_000_000)
+	if got := expiresAt(30, now); got != now.Add(30*time.Minute).UnixMilli() {
+		t.Fatalf("unexpected expiry %d", got)
+	}
+	if got := expiresAt(-1, now); got != 0 {
+		t.Fatalf("negative TTL must never expire, got %d", got)
+	}
+}
+
+func TestDiscardExpiredContainers(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("expired", "expired", "nginx")
+	rt.AddContainer("fresh", "fresh", "nginx")
+	rt.AddContainer("forever", "forever", "nginx")
+	now := time.Now()
+	records := useFakeRecords(t,
+		&dtos.Container{ID: "expired", Name: "expired", Status: dtos.Running, ExpiresAt: now.Add(-time.Second).UnixMilli()},
+		&dtos.Container{ID: "fresh", Name: "fresh", Status: dtos.Running, ExpiresAt: now.Add(time.Hour).UnixMilli()},
+		&dtos.Container{ID: "forever", Name: "forever", Status: dtos.Stopped},
+	)
+
+	discardExpiredContainers(now)
+
+	if _, ok := rt.Containers["expired"]; ok || records.get("expired").Status != dtos.Discarded {
+		t.Fatalf("expired container should have been removed and discarded")
+	}
+	if _, ok := rt.Containers["fresh"]; !ok || records.get("fresh").Status != dtos.Running {
+		t.Fatalf("fresh container must be kept")
+	}
+	if _, ok := rt.Containers["forever"]; !ok {
+		t.Fatalf("containers without expiry must be kept")
+	}
+}
+
+func TestExtendContainerLease(t *testing.T) {
+	useFakeRuntime(t)
+	soon := time.Now().Add(5 * time.Minute).UnixMilli()
+	records := useFakeRecords(t,
+		&dtos.Container{ID: "web", Name: "web", Status: dtos.Running, ExpiresAt: soon},
+		&dtos.Container{ID: "late", Name: "late", Status: dtos.Running, ExpiresAt: time.Now().Add(-time.Hour).UnixMilli()},
+		&dtos.Container{ID: "forever", Name: "forever", Status: dtos.Running},
+		&dtos.Container{ID: "gone", Name: "gone", Status: dtos.Discarded, ExpiresAt: soon},
+	)
+
+	c, err := ExtendContainerLease("web", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if want := soon + (30 * time.Minute).Milliseconds(); c.ExpiresAt != want || records.get("web").ExpiresAt != want {
+		t.Fatalf("expected expiry %d, got %d", want, c.ExpiresAt)
+	}
+
+	c, err = ExtendContainerLease("late", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if c.ExpiresAt < time.Now().Add(9*time.Minute).UnixMilli() {
+		t.Fatalf("an ended lease must be extended from now, got %d", c.ExpiresAt)
+	}
+
+	if _, err := ExtendContainerLease("forever", time.Minute); !errors.Is(err, ErrNoExpiry) {
+		t.Fatalf("expected ErrNoExpiry, got %v", err)
+	}
+	if _, err := ExtendContainerLease("gone", time.Minute); !errors.Is(err, services.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+}
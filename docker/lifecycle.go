@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// stopTimeout is how long a container gets to shut down before it is killed.
+const stopTimeout = 10 * time.Second
+
+// StopContainer stops a container but keeps it, so it can be started again later.
+func StopContainer(containerId string) (*dtos.Container, error) {
+	return changeContainerState(containerId, dtos.Stopped, func(ctx context.Context, id string) error {
+		return Runtime.StopContainer(ctx, id, stopTimeout)
+	})
+}
+
+// StartContainer starts a stopped or failed container. The container is reported as
+// starting until its readiness check succeeds.
+func StartContainer(containerId string) (*dtos.Container, error) {
+	container, err := changeContainerState(containerId, dtos.Starting, Runtime.StartContainer)
+	if err != nil {
+		return nil, err
+	}
+	go awaitReadiness(context.Background(), "", container, readinessCheckFor(container.Type))
+	return container, nil
+}
+
+// RestartContainer restarts a container and waits for it to become ready again in the background.
+func RestartContainer(containerId string) (*dtos.Container, error) {
+	container, err := changeContainerState(containerId, dtos.Starting, func(ctx context.Context, id string) error {
+		return Runtime.RestartContainer(ctx, id, stopTimeout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	go awaitReadiness(context.Background(), "", container, readinessCheckFor(container.Type))
+	return container, nil
+}
+
+// PauseContainer freezes all processes of a running container.
+func PauseContainer(containerId string) (*dtos.Container, error) {
+	return changeContainerState(containerId, dtos.Paused, Runtime.PauseContainer)
+}
+
+// UnpauseContainer resumes a paused container.
+func UnpauseContainer(containerId string) (*dtos.Container, error) {
+	return changeContainerState(containerId, dtos.Running, Runtime.UnpauseContainer)
+}
+
+// changeContainerState validates the transition to status, runs action against the runtime
+// and stores the new status.
+func changeContainerState(containerId string, status dtos.Status, action func(ctx context.Context, id string) error) (*dtos.Container, error) {
+	container, err := getContainerFn(containerId)
+	if err != nil {
+		return nil, err
+	}
+	if err := services.ValidateTransition(container.Status, status); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout+30*time.Second)
+	defer cancel()
+
+	log.Printf("Changing container %s from %s to %s", container.Name, container.Status, status)
+	if err := action(ctx, container.ID); err != nil {
+		return nil, fmt.Errorf("changing container to %s failed: %w", status, err)
+	}
+
+	if err := updateContainerStatusFn(container.ID, status); err != nil {
+		return nil, err
+	}
+	container.Status = status
+	return container, nil
+}
+
+// readinessCheckFor returns the readiness check of a server type, or nil if the type is unknown.
+func readinessCheckFor(serverType string) *servers.ReadinessCheck {
+	info, err := servers.GetServerByType(serverType)
+	if err != nil {
+		return nil
+	}
+	return info.Readiness
+}
@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+func TestStopAndStartContainer(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("web", "web", "nginx")
+	records := useFakeRecords(t, &dtos.Container{ID: "web", Name: "web", Type: "WEB", Status: dtos.Running})
+	stubProbe(t, nil)
+
+	c, err := StopContainer("web")
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if c.Status != dtos.Stopped || records.get("web").Status != dtos.Stopped {
+		t.Fatalf("expected stopped record, got %+v", records.get("web"))
+	}
+	if rt.Containers["web"].Info.State.Running {
+		t.Fatalf("runtime container should be stopped")
+	}
+	if _, ok := rt.Containers["web"]; !ok {
+		t.Fatalf("stop must keep the container")
+	}
+
+	c, err = StartContainer("web")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if c.Status != dtos.Starting {
+		t.Fatalf("expected starting status, got %v", c.Status)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for records.get("web").Status != dtos.Running {
+		if time.Now().After(deadline) {
+			t.Fatalf("container did not become running: %+v", records.get("web"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPauseAndUnpauseContainer(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("mqtt", "mqtt", "mosquitto")
+	records := useFakeRecords(t, &dtos.Container{ID: "mqtt", Name: "mqtt", Status: dtos.Running})
+
+	if _, err := PauseContainer("mqtt"); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if !rt.Containers["mqtt"].Info.State.Paused || records.get("mqtt").Status != dtos.Paused {
+		t.Fatalf("expected paused container and record")
+	}
+	if _, err := PauseContainer("mqtt"); !errors.Is(err, services.ErrInvalidTransition) {
+		t.Fatalf("pausing twice should be an invalid transition, got %v", err)
+	}
+	if _, err := UnpauseContainer("mqtt"); err != nil {
+		t.Fatalf("unpause: %v", err)
+	}
+	if rt.Containers["mqtt"].Info.State.Paused || records.get("mqtt").Status != dtos.Running {
+		t.Fatalf("expected running container and record")
+	}
+}
+
+func TestLifecycle_InvalidTransitionSkipsRuntime(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("ftp", "ftp", "ftp").Info.State = ContainerState{Status: "exited"}
+	useFakeRecords(t, &dtos.Container{ID: "ftp", Name: "ftp", Status: dtos.Stopped})
+
+	if _, err := PauseContainer("ftp"); !errors.Is(err, services.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	if rt.Containers["ftp"].Info.State.Paused {
+		t.Fatalf("runtime must not be called for invalid transitions")
+	}
+}
+
+func TestDiscardContainer_RemovedOutsideApplication(t *testing.T) {
+	useFakeRuntime(t)
+	records := useFakeRecords(t, &dtos.Container{ID: "gone", Name: "gone", Status: dtos.Stopped})
+
+	if err := DiscardContainer("gone"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if records.get("gone").Status != dtos.Discarded {
+		t.Fatalf("expected discarded record")
+	}
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tim0-12432/simple-test-server/config"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// maxLimitsFn returns the admin-configured maximum limits; a variable so tests can set them.
+var maxLimitsFn = configuredMaxLimits
+
+var ulimitNamePattern = regexp.MustCompile(`^[a-z]+$`)
+
+// ValidateLimits reports whether the requested limits are valid for a server type with the
+// given defaults, so invalid configurations can be rejected before a server is started.
+func ValidateLimits(defaults *dtos.ResourceLimits, requested *dtos.ResourceLimits) error {
+	_, err := resolveLimits(defaults, requested)
+	return err
+}
+
+// resolveLimits merges the requested limits over the defaults of the server type and applies
+// the configured maximum. Limits left unlimited are set to the maximum.
+func resolveLimits(defaults *dtos.ResourceLimits, requested *dtos.ResourceLimits) (*dtos.ResourceLimits, error) {
+	limits := dtos.ResourceLimits{}
+	if defaults != nil {
+		limits = *defaults
+		limits.Ulimits = append([]dtos.Ulimit{}, defaults.Ulimits...)
+	}
+	if requested != nil {
+		if requested.MemoryMB != 0 {
+			limits.MemoryMB = requested.MemoryMB
+		}
+		if requested.CPUs != 0 {
+			limits.CPUs = requested.CPUs
+		}
+		if requested.CPUShares != 0 {
+			limits.CPUShares = requested.CPUShares
+		}
+		if requested.PidsLimit != 0 {
+			limits.PidsLimit = requested.PidsLimit
+		}
+		for _, u := range requested.Ulimits {
+			limits.Ulimits = setUlimit(limits.Ulimits, u)
+		}
+	}
+
+	if limits.MemoryMB < 0 || limits.CPUs < 0 || limits.CPUShares < 0 || limits.PidsLimit < 0 {
+		return nil, fmt.Errorf("%w: limits must not be negative", ErrInvalidLimits)
+	}
+	for _, u := range limits.Ulimits {
+		if !ulimitNamePattern.MatchString(u.Name) || u.Soft < 0 || u.Hard < u.Soft {
+			return nil, fmt.Errorf("%w: invalid ulimit %s=%d:%d", ErrInvalidLimits, u.Name, u.Soft, u.Hard)
+		}
+	}
+
+	// only requested limits above the maximum are rejected, defaults of the server type are lowered
+	if requested == nil {
+		requested = &dtos.ResourceLimits{}
+	}
+	max := maxLimitsFn()
+	var err error
+	if limits.MemoryMB, err = capLimit("memory_mb", limits.MemoryMB, requested.MemoryMB != 0, max.MemoryMB); err != nil {
+		return nil, err
+	}
+	if limits.PidsLimit, err = capLimit("pids_limit", limits.PidsLimit, requested.PidsLimit != 0, max.PidsLimit); err != nil {
+		return nil, err
+	}
+	if limits.CPUs, err = capLimit("cpus", limits.CPUs, requested.CPUs != 0, max.CPUs); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// capLimit applies max to value: unlimited values and defaults above it get the maximum,
+// requested values above it are an error.
+func capLimit[T int64 | float64](name string, value T, requested bool, max T) (T, error) {
+	if max <= 0 {
+		return value, nil
+	}
+	if value > max && requested {
+		return 0, fmt.Errorf("%w: %s %v exceeds maximum %v", ErrInvalidLimits, name, value, max)
+	}
+	if value == 0 || value > max {
+		return max, nil
+	}
+	return value, nil
+}
+
+// setUlimit replaces the ulimit with the same name or appends it.
+func setUlimit(ulimits []dtos.Ulimit, u dtos.Ulimit) []dtos.Ulimit {
+	for i := range ulimits {
+		if ulimits[i].Name == u.Name {
+			ulimits[i] = u
+			return ulimits
+		}
+	}
+	return append(ulimits, u)
+}
+
+// runtimeResources converts limits into the units of the container runtime.
+func runtimeResources(limits *dtos.ResourceLimits) Resources {
+	if limits == nil {
+		return Resources{}
+	}
+	res := Resources{
+		Memory:    limits.MemoryMB << 20,
+		NanoCPUs:  int64(limits.CPUs * 1e9),
+		CPUShares: limits.CPUShares,
+		PidsLimit: limits.PidsLimit,
+	}
+	for _, u := range limits.Ulimits {
+		res.Ulimits = append(res.Ulimits, Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+	}
+	return res
+}
+
+func configuredMaxLimits() dtos.ResourceLimits {
+	if config.EnvConfig == nil {
+		return dtos.ResourceLimits{}
+	}
+	return dtos.ResourceLimits{
+		MemoryMB:  config.EnvConfig.MaxMemoryMB,
+		CPUs:      config.EnvConfig.MaxCPUs,
+		PidsLimit: config.EnvConfig.MaxPids,
+	}
+}
@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// stubMaxLimits sets the admin-configured maximum limits for the duration of the test.
+func stubMaxLimits(t *testing.T, max dtos.ResourceLimits) {
+	t.Helper()
+	old := maxLimitsFn
+	maxLimitsFn = func() dtos.ResourceLimits { return max }
+	t.Cleanup(func() { maxLimitsFn = old })
+}
+
+func TestResolveLimits_OverridesDefaults(t *testing.T) {
+	stubMaxLimits(t, dtos.ResourceLimits{})
+
+	defaults := &dtos.ResourceLimits{MemoryMB: 256, PidsLimit: 128, Ulimits: []dtos.Ulimit{{Name: "nofile", Soft: 1024, Hard: 1024}}}
+	limits, err := resolveLimits(defaults, &dtos.ResourceLimits{MemoryMB: 64, CPUs: 0.5, Ulimits: []dtos.Ulimit{{Name: "nofile", Soft: 64, Hard: 128}}})
+	if err != nil {
+		t.Fatalf("resolveLimits: %v", err)
+	}
+	if limits.MemoryMB != 64 || limits.CPUs != 0.5 || limits.PidsLimit != 128 {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+	if len(limits.Ulimits) != 1 || limits.Ulimits[0].Hard != 128 {
+		t.Fatalf("expected requested ulimit to replace the default, got %+v", limits.Ulimits)
+	}
+	if defaults.Ulimits[0].Hard != 1024 {
+		t.Fatalf("defaults were modified: %+v", defaults)
+	}
+}
+
+func TestResolveLimits_AppliesMaximum(t *testing.T) {
+	stubMaxLimits(t, dtos.ResourceLimits{MemoryMB: 512, CPUs: 2, PidsLimit: 100})
+
+	limits, err := resolveLimits(&dtos.ResourceLimits{MemoryMB: 128}, nil)
+	if err != nil {
+		t.Fatalf("resolveLimits: %v", err)
+	}
+	if limits.MemoryMB != 128 || limits.CPUs != 2 || limits.PidsLimit != 100 {
+		t.Fatalf("expected unset limits to get the maximum, got %+v", limits)
+	}
+
+	for _, requested := range []*dtos.ResourceLimits{
+		{MemoryMB: 1024},
+		{CPUs: 4},
+		{PidsLimit: 1000},
+		{MemoryMB: -1},
+		{Ulimits: []dtos.Ulimit{{Name: "nofile", Soft: 10, Hard: 5}}},
+	} {
+		if _, err := resolveLimits(nil, requested); !errors.Is(err, ErrInvalidLimits) {
+			t.Fatalf("expected ErrInvalidLimits for %+v, got %v", requested, err)
+		}
+	}
+}
+
+func TestResolveLimits_LowersDefaultsToTheMaximum(t *testing.T) {
+	stubMaxLimits(t, dtos.ResourceLimits{MemoryMB: 512, CPUs: 1, PidsLimit: 100})
+
+	defaults := &dtos.ResourceLimits{MemoryMB: 1024, CPUs: 2, PidsLimit: 1024}
+	limits, err := resolveLimits(defaults, nil)
+	if err != nil {
+		t.Fatalf("defaults above the maximum must not be rejected: %v", err)
+	}
+	if limits.MemoryMB != 512 || limits.CPUs != 1 || limits.PidsLimit != 100 {
+		t.Fatalf("expected defaults lowered to the maximum, got %+v", limits)
+	}
+
+	if _, err := resolveLimits(defaults, &dtos.ResourceLimits{MemoryMB: 768}); !errors.Is(err, ErrInvalidLimits) {
+		t.Fatalf("expected ErrInvalidLimits for a requested limit above the maximum, got %v", err)
+	}
+}
+
+func TestRunContainer_AppliesAndStoresLimits(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.Images["nginx"] = true
+	created := captureCreatedContainers(t)
+	stubMaxLimits(t, dtos.ResourceLimits{})
+
+	config := ServerConfiguration{Limits: &dtos.ResourceLimits{CPUs: 1.5}}
+	if _, err := RunContainer(config, "WEB", "nginx", "web", nil, nil, nil, &dtos.ResourceLimits{MemoryMB: 128}); err != nil {
+		t.Fatalf("RunContainer: %v", err)
+	}
+	if res := rt.Runs[0].Resources; res.Memory != 128<<20 || res.NanoCPUs != 1.5e9 {
+		t.Fatalf("unexpected runtime resources: %+v", res)
+	}
+	if l := (*created)[0].Limits; l == nil || l.MemoryMB != 128 || l.CPUs != 1.5 {
+		t.Fatalf("expected applied limits on the record, got %+v", l)
+	}
+}
@@ -3,7 +3,6 @@ package docker
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -17,35 +16,33 @@ import (
 // Returns the entries, a boolean indicating whether the result was truncated
 // due to the maxEntries limit, and an error if something went wrong.
 func ListContainerDir(ctx context.Context, containerId string, relPath string, maxEntries int) ([]FileEntry, bool, error) {
+	return listDirectory(ctx, containerId, "/usr/share/nginx/html", relPath, maxEntries)
+}
+
+// listDirectory lists the immediate children of relPath below root inside the container.
+func listDirectory(ctx context.Context, containerId string, root string, relPath string, maxEntries int) ([]FileEntry, bool, error) {
 	if containerId == "" {
 		return nil, false, fmt.Errorf("container id empty")
 	}
 
 	// basic validation: disallow absolute paths and traversal
 	if strings.HasPrefix(relPath, "/") {
-		return nil, false, fmt.Errorf("relPath must be relative")
+		return nil, false, fmt.Errorf("%w: relPath must be relative", ErrInvalidPath)
 	}
 	if strings.Contains(relPath, "..") {
-		return nil, false, fmt.Errorf("relPath must not contain '..'")
+		return nil, false, fmt.Errorf("%w: relPath must not contain '..'", ErrInvalidPath)
 	}
 
-	// verify container exists by running inspect
-	if err := func() error {
-		ci := exec.Command("docker", "inspect", containerId)
-		if err := ci.Run(); err != nil {
-			return fmt.Errorf("container not found: %s", containerId)
-		}
-		return nil
-	}(); err != nil {
+	// verify container exists before running anything inside it
+	if _, err := Runtime.InspectContainer(ctx, containerId); err != nil {
 		return nil, false, err
 	}
 
-	webroot := "/usr/share/nginx/html"
 	var target string
 	if relPath == "" {
-		target = webroot
+		target = root
 	} else {
-		target = filepath.Join(webroot, relPath)
+		target = filepath.Join(root, relPath)
 	}
 
 	// set a conservative timeout for the exec
@@ -55,36 +52,35 @@ func ListContainerDir(ctx context.Context, containerId string, relPath string, m
 	// Use find to list immediate children with metadata (type|size|mtime|path)
 	// Note: This requires `find` in the container to support -printf. Most
 	// standard GNU find implementations do; some minimal images may not.
-	cmd := exec.CommandContext(ctx, "docker", "exec", containerId, "find", target, "-maxdepth", "1", "-mindepth", "1", "-printf", "%y|%s|%T@|%p\n")
-	out, err := cmd.CombinedOutput()
+	res, err := Runtime.Exec(ctx, containerId, []string{"find", target, "-maxdepth", "1", "-mindepth", "1", "-printf", "%y|%s|%T@|%p\n"})
 	if err != nil {
-		outStr := strings.TrimSpace(string(out))
+		return nil, false, fmt.Errorf("exec find failed: %w", err)
+	}
+	if res.ExitCode != 0 {
+		outStr := strings.TrimSpace(string(res.Output))
 		// some minimal find implementations (e.g. busybox) do not support -printf
 		// fallback to a POSIX shell loop that prints: type|size|mtime|path with mtime=0.0
 		if strings.Contains(strings.ToLower(outStr), "unrecognized -printf") || strings.Contains(strings.ToLower(outStr), "unknown primary") || strings.Contains(strings.ToLower(outStr), "-printf") {
 			fallback := `for f in ` + target + `/* ; do [ -e "$f" ] || continue ; t='?'; if [ -d "$f" ]; then t='d'; elif [ -L "$f" ]; then t='l'; elif [ -f "$f" ]; then t='f'; fi; s=0; if [ -f "$f" ]; then s=$(wc -c <"$f" 2>/dev/null || echo 0); fi; printf "%s|%s|%s|%s\n" "$t" "$s" "0.0" "$f"; done`
-			fbCmd := exec.CommandContext(ctx, "docker", "exec", containerId, "sh", "-c", fallback)
-			out2, err2 := fbCmd.CombinedOutput()
-			if err2 != nil {
-				return nil, false, fmt.Errorf("docker exec fallback listing failed: %v - %s", err2, strings.TrimSpace(string(out2)))
+			res, err = Runtime.Exec(ctx, containerId, []string{"sh", "-c", fallback})
+			if err != nil {
+				return nil, false, fmt.Errorf("exec fallback listing failed: %w", err)
 			}
-			raw := strings.TrimSpace(string(out2))
-			if raw == "" {
-				return []FileEntry{}, false, nil
+			if res.ExitCode != 0 {
+				return nil, false, fmt.Errorf("exec fallback listing failed with exit code %d - %s", res.ExitCode, strings.TrimSpace(string(res.Output)))
 			}
-			entries, truncated := parseFindOutput(raw, webroot, maxEntries)
-			return entries, truncated, nil
+		} else {
+			// return output to help debugging, but wrap it
+			return nil, false, fmt.Errorf("exec find failed with exit code %d - %s", res.ExitCode, outStr)
 		}
-		// return output to help debugging, but wrap it
-		return nil, false, fmt.Errorf("docker exec find failed: %v - %s", err, outStr)
 	}
 
-	raw := strings.TrimSpace(string(out))
+	raw := strings.TrimSpace(string(res.Output))
 	if raw == "" {
 		return []FileEntry{}, false, nil
 	}
 
-	entries, truncated := parseFindOutput(raw, webroot, maxEntries)
+	entries, truncated := parseFindOutput(raw, root, maxEntries)
 	return entries, truncated, nil
 }
 
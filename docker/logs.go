@@ -3,8 +3,9 @@ package docker
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
 	"strings"
 	"time"
 
@@ -15,25 +16,8 @@ import (
 // MaxLineLen limits the size of a single log line returned.
 const MaxLineLen = 8192
 
-// ExecCommandContextFn is the signature used to run external commands. It returns combined stdout/stderr
-// and an error if the command failed. Tests may replace this variable to inject output.
-var ExecCommandContextFn = func(ctx context.Context, name string, args ...string) ([]byte, error) {
-	// default implementation uses CombinedOutput
-	cmd := execCommand(ctx, name, args...)
-	return cmd.CombinedOutput()
-}
-
-// execCommand is a tiny wrapper so the default implementation can be replaced in tests if needed.
-var execCommand = func(ctx context.Context, name string, args ...string) *execCmdWrapper {
-	return &execCmdWrapper{ctx: ctx, name: name, args: args}
-}
-
-// execCmdWrapper abstracts exec.CommandContext to avoid importing os/exec in tests that don't need it.
-// It is implemented using the real os/exec package in production via the default execCommand above.
-// In tests we can replace ExecCommandContextFn to a stub and don't need to touch this.
-
-// FetchContainerLogs runs `docker logs` for the given container name and returns the last `tail` lines.
-// If `since` is non-nil, it will add the --since option. Returns a slice of LogLine ordered oldest->newest.
+// FetchContainerLogs reads the logs of the given container name from the runtime and returns the last `tail` lines.
+// If `since` is non-nil, only lines after that time are returned. Returns a slice of LogLine ordered oldest->newest.
 func FetchContainerLogs(ctx context.Context, containerName string, tail int, since *time.Time) ([]dtos.LogLine, bool, error) {
 	if tail <= 0 {
 		tail = 500
@@ -48,23 +32,20 @@ func FetchContainerLogs(ctx context.Context, containerName string, tail int, sin
 		return nil, false, ErrContainerNotFound
 	}
 
-	// build command
-	args := []string{"logs", "--tail", fmt.Sprint(tail)}
-	if since != nil {
-		args = append(args, "--since", since.UTC().Format(time.RFC3339))
-	}
-	args = append(args, container.Name)
-
 	// run with timeout
 	runCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	out, err := ExecCommandContextFn(runCtx, "docker", args...)
+	rc, err := Runtime.Logs(runCtx, container.Name, LogsOptions{Tail: tail, Since: since})
 	if err != nil {
-		low := strings.ToLower(string(out))
-		if strings.Contains(low, "no such container") {
+		if errors.Is(err, ErrContainerNotFound) {
 			return nil, false, ErrContainerNotFound
 		}
-		return nil, false, fmt.Errorf("docker logs failed: %w", err)
+		return nil, false, fmt.Errorf("container logs failed: %w", err)
+	}
+	defer rc.Close()
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, false, fmt.Errorf("read container logs: %w", err)
 	}
 
 	lines, truncated, err := parseLogOutput(out, tail, nil)
@@ -83,7 +64,7 @@ func FetchContainerLogs(ctx context.Context, containerName string, tail int, sin
 // getContainerFn is a variable so tests can override lookup behavior.
 var getContainerFn = services.GetContainer
 
-// parseLogOutput parses combined output bytes from docker logs and returns parsed LogLine slice and truncated flag.
+// parseLogOutput parses combined output bytes from the container logs and returns parsed LogLine slice and truncated flag.
 // If nowFunc is nil, time.Now will be used for fallback timestamps. nowFunc is provided for tests.
 func parseLogOutput(output []byte, tail int, nowFunc func() time.Time) ([]dtos.LogLine, bool, error) {
 	if nowFunc == nil {
@@ -130,7 +111,7 @@ func parseLogOutput(output []byte, tail int, nowFunc func() time.Time) ([]dtos.L
 	return lines, truncated, nil
 }
 
-// GetContainerLogs returns the container logs (stdout/stderr) with timestamps using `docker logs`.
+// GetContainerLogs returns the container logs (stdout/stderr) with timestamps.
 func GetContainerLogs(ctx context.Context, containerId string, tail int) (string, error) {
 	if containerId == "" {
 		return "", fmt.Errorf("container id empty")
@@ -143,59 +124,21 @@ func GetContainerLogs(ctx context.Context, containerId string, tail int) (string
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	args := []string{"logs", "--timestamps", "--tail", fmt.Sprintf("%d", tail), containerId}
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	out, err := cmd.CombinedOutput()
+	rc, err := Runtime.Logs(ctx, containerId, LogsOptions{Tail: tail, Timestamps: true})
 	if err != nil {
-		return "", fmt.Errorf("docker logs failed: %v - %s", err, strings.TrimSpace(string(out)))
+		return "", fmt.Errorf("container logs failed: %w", err)
 	}
-	return string(out), nil
-}
-
-// StreamCommandRunner is the interface for running streaming commands. Tests can inject a mock.
-type StreamCommandRunner interface {
-	Start() error
-	StdoutPipe() (StreamReader, error)
-	StderrPipe() (StreamReader, error)
-	Wait() error
-}
-
-// StreamReader abstracts reading from command output streams.
-type StreamReader interface {
-	Read(p []byte) (n int, error error)
-}
-
-// ExecCommandStreamFn is injected to start a streaming command. Tests replace this to inject mock behavior.
-var ExecCommandStreamFn = func(ctx context.Context, name string, args ...string) StreamCommandRunner {
-	return &realCommandRunner{
-		cmd: exec.CommandContext(ctx, name, args...),
+	defer rc.Close()
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("read container logs: %w", err)
 	}
+	return string(out), nil
 }
 
-// realCommandRunner wraps exec.Cmd to implement StreamCommandRunner.
-type realCommandRunner struct {
-	cmd *exec.Cmd
-}
-
-func (r *realCommandRunner) Start() error {
-	return r.cmd.Start()
-}
-
-func (r *realCommandRunner) StdoutPipe() (StreamReader, error) {
-	return r.cmd.StdoutPipe()
-}
-
-func (r *realCommandRunner) StderrPipe() (StreamReader, error) {
-	return r.cmd.StderrPipe()
-}
-
-func (r *realCommandRunner) Wait() error {
-	return r.cmd.Wait()
-}
-
-// StreamContainerLogs streams docker logs for a container in real-time using `docker logs -f`.
-// It sends each log line to the onLine callback. Blocks until context is cancelled or command finishes.
-// Returns error if container not found or command fails to start.
+// StreamContainerLogs streams the logs of a container in real-time.
+// It sends each log line to the onLine callback. Blocks until context is cancelled or the log stream ends.
+// Returns ErrContainerNotFound if the container is unknown to the registry or the runtime.
 func StreamContainerLogs(ctx context.Context, containerID string, onLine func(line string)) error {
 	// Validate container exists in our registry
 	container, err := getContainerFn(containerID)
@@ -203,89 +146,37 @@ func StreamContainerLogs(ctx context.Context, containerID string, onLine func(li
 		return ErrContainerNotFound
 	}
 
-	// build command: docker logs -f --tail=50 <containerName>
-	args := []string{"logs", "-f", "--tail", "50", container.Name}
-
-	// start streaming command
-	cmd := ExecCommandStreamFn(ctx, "docker", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-	stderr, err := cmd.StderrPipe()
+	rc, err := Runtime.Logs(ctx, container.Name, LogsOptions{Follow: true, Tail: 50})
 	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start docker logs: %w", err)
+		if errors.Is(err, ErrContainerNotFound) {
+			return ErrContainerNotFound
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to start log stream: %w", err)
 	}
+	defer rc.Close()
 
-	// goroutine to read stdout
-	stdoutDone := make(chan struct{})
-	go func() {
-		defer close(stdoutDone)
-		scanner := bufio.NewScanner(stdout)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, MaxLineLen)
-		for scanner.Scan() {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				line := scanner.Text()
-				if len(line) > MaxLineLen {
-					line = line[:MaxLineLen]
-				}
-				onLine(line)
-			}
+	scanner := bufio.NewScanner(rc)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, MaxLineLen)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			break
 		}
-	}()
-
-	// goroutine to read stderr
-	stderrDone := make(chan struct{})
-	go func() {
-		defer close(stderrDone)
-		scanner := bufio.NewScanner(stderr)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, MaxLineLen)
-		for scanner.Scan() {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				line := scanner.Text()
-				if len(line) > MaxLineLen {
-					line = line[:MaxLineLen]
-				}
-				onLine(line)
-			}
+		line := scanner.Text()
+		if len(line) > MaxLineLen {
+			line = line[:MaxLineLen]
 		}
-	}()
-
-	// wait for context cancellation or command completion
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
+		onLine(line)
+	}
 
-	select {
-	case <-ctx.Done():
-		// context cancelled, wait for goroutines to finish
-		<-stdoutDone
-		<-stderrDone
+	if ctx.Err() != nil {
 		return ctx.Err()
-	case err := <-done:
-		// command finished
-		<-stdoutDone
-		<-stderrDone
-		if err != nil {
-			// check if container not found
-			if strings.Contains(err.Error(), "No such container") {
-				return ErrContainerNotFound
-			}
-			return fmt.Errorf("docker logs command failed: %w", err)
-		}
-		return nil
 	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("log stream failed: %w", err)
+	}
+	return nil
 }
@@ -11,11 +11,10 @@ import (
 
 func TestFetchContainerLogs_Success(t *testing.T) {
 	oldGet := getContainerFn
-	oldExec := ExecCommandContextFn
 	defer func() {
 		getContainerFn = oldGet
-		ExecCommandContextFn = oldExec
 	}()
+	rt := useFakeRuntime(t)
 
 	getContainerFn = func(name string) (*dtos.Container, error) {
 		return &dtos.Container{
@@ -25,10 +24,8 @@ func TestFetchContainerLogs_Success(t *testing.T) {
 		}, nil
 	}
 
-	ExecCommandContextFn = func(ctx context.Context, name string, args ...string) ([]byte, error) {
-		// simulate docker logs output
-		return []byte("2025-10-05T11:59:58Z first-line\nsecond-line-without-ts\n"), nil
-	}
+	// simulate container log output
+	rt.AddContainer("id-1", "my-container", "nginx").Logs = []string{"2025-10-05T11:59:58Z first-line", "second-line-without-ts"}
 
 	ctx := context.Background()
 	lines, truncated, err := FetchContainerLogs(ctx, "my-container", 500, nil)
@@ -51,35 +48,32 @@ func TestFetchContainerLogs_Success(t *testing.T) {
 
 func TestFetchContainerLogs_NotFoundByLookup(t *testing.T) {
 	oldGet := getContainerFn
-	oldExec := ExecCommandContextFn
 	defer func() {
 		getContainerFn = oldGet
-		ExecCommandContextFn = oldExec
 	}()
+	rt := useFakeRuntime(t)
 
 	getContainerFn = func(name string) (*dtos.Container, error) {
 		return nil, errors.New("not found")
 	}
 
-	// Exec shouldn't be called, but stub to be safe
-	ExecCommandContextFn = func(ctx context.Context, name string, args ...string) ([]byte, error) {
-		return nil, nil
-	}
-
 	ctx := context.Background()
 	_, _, err := FetchContainerLogs(ctx, "missing", 10, nil)
 	if !errors.Is(err, ErrContainerNotFound) {
 		t.Fatalf("expected ErrContainerNotFound, got %v", err)
 	}
+	// the runtime shouldn't be asked for logs
+	if len(rt.LogCalls) != 0 {
+		t.Fatalf("expected no runtime log calls, got %d", len(rt.LogCalls))
+	}
 }
 
 func TestFetchContainerLogs_NotRunning(t *testing.T) {
 	oldGet := getContainerFn
-	oldExec := ExecCommandContextFn
 	defer func() {
 		getContainerFn = oldGet
-		ExecCommandContextFn = oldExec
 	}()
+	rt := useFakeRuntime(t)
 
 	getContainerFn = func(name string) (*dtos.Container, error) {
 		return &dtos.Container{
@@ -89,9 +83,7 @@ func TestFetchContainerLogs_NotRunning(t *testing.T) {
 		}, nil
 	}
 
-	ExecCommandContextFn = func(ctx context.Context, name string, args ...string) ([]byte, error) {
-		return []byte("line-a\nline-b\n"), nil
-	}
+	rt.AddContainer("id-1", "stopped", "nginx").Logs = []string{"line-a", "line-b"}
 
 	ctx := context.Background()
 	lines, truncated, err := FetchContainerLogs(ctx, "stopped", 100, nil)
@@ -104,13 +96,12 @@ func TestFetchContainerLogs_NotRunning(t *testing.T) {
 	_ = truncated
 }
 
-func TestFetchContainerLogs_DockerNoSuchContainer(t *testing.T) {
+func TestFetchContainerLogs_RuntimeNoSuchContainer(t *testing.T) {
 	oldGet := getContainerFn
-	oldExec := ExecCommandContextFn
 	defer func() {
 		getContainerFn = oldGet
-		ExecCommandContextFn = oldExec
 	}()
+	useFakeRuntime(t)
 
 	getContainerFn = func(name string) (*dtos.Container, error) {
 		return &dtos.Container{
@@ -120,49 +111,33 @@ func TestFetchContainerLogs_DockerNoSuchContainer(t *testing.T) {
 		}, nil
 	}
 
-	ExecCommandContextFn = func(ctx context.Context, name string, args ...string) ([]byte, error) {
-		return []byte("Error: No such container: foo"), errors.New("exit status 1")
-	}
-
+	// the container is known to the registry but not to the runtime
 	ctx := context.Background()
 	_, _, err := FetchContainerLogs(ctx, "my-container", 10, nil)
 	if !errors.Is(err, ErrContainerNotFound) {
-		t.Fatalf("expected ErrContainerNotFound from docker output, got %v", err)
+		t.Fatalf("expected ErrContainerNotFound from runtime, got %v", err)
 	}
 }
 
 func TestFetchContainerLogs_WithSinceOption(t *testing.T) {
 	oldGet := getContainerFn
-	oldExec := ExecCommandContextFn
 	defer func() {
 		getContainerFn = oldGet
-		ExecCommandContextFn = oldExec
 	}()
+	rt := useFakeRuntime(t)
 
-	capturedArgs := []string{}
 	getContainerFn = func(name string) (*dtos.Container, error) {
 		return &dtos.Container{Name: name, Status: dtos.Running, Type: "web"}, nil
 	}
-
-	ExecCommandContextFn = func(ctx context.Context, name string, args ...string) ([]byte, error) {
-		capturedArgs = append(capturedArgs, args...)
-		return []byte("ok\n"), nil
-	}
+	rt.AddContainer("id-1", "c", "nginx").Logs = []string{"ok"}
 
 	since := time.Date(2025, 10, 5, 10, 0, 0, 0, time.UTC)
 	_, _, err := FetchContainerLogs(context.Background(), "c", 10, &since)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	// ensure --since was passed
-	found := false
-	for i := 0; i < len(capturedArgs)-1; i++ {
-		if capturedArgs[i] == "--since" {
-			found = true
-			break
-		}
-	}
-	if !found {
-		t.Fatalf("expected --since in docker args, got %v", capturedArgs)
+	// ensure since was passed to the runtime
+	if len(rt.LogCalls) != 1 || rt.LogCalls[0].Since == nil || !rt.LogCalls[0].Since.Equal(since) {
+		t.Fatalf("expected since in runtime log options, got %+v", rt.LogCalls)
 	}
 }
@@ -3,7 +3,6 @@ package docker
 import (
 	"context"
 	"errors"
-	"io"
 	"strings"
 	"testing"
 	"time"
@@ -11,52 +10,13 @@ import (
 	"github.com/tim0-12432/simple-test-server/db/dtos"
 )
 
-// mockStreamReader implements StreamReader for testing.
-type mockStreamReader struct {
-	data []byte
-	pos  int
-}
-
-func (m *mockStreamReader) Read(p []byte) (int, error) {
-	if m.pos >= len(m.data) {
-		return 0, io.EOF
-	}
-	n := copy(p, m.data[m.pos:])
-	m.pos += n
-	return n, nil
-}
-
-// mockStreamCommand implements StreamCommandRunner for testing.
-type mockStreamCommand struct {
-	stdout *mockStreamReader
-	stderr *mockStreamReader
-	err    error
-}
-
-func (m *mockStreamCommand) Start() error {
-	return nil
-}
-
-func (m *mockStreamCommand) StdoutPipe() (StreamReader, error) {
-	return m.stdout, nil
-}
-
-func (m *mockStreamCommand) StderrPipe() (StreamReader, error) {
-	return m.stderr, nil
-}
-
-func (m *mockStreamCommand) Wait() error {
-	return m.err
-}
-
 func TestStreamContainerLogs_Success(t *testing.T) {
 	// Save original functions
-	origExecStreamFn := ExecCommandStreamFn
 	origGetContainerFn := getContainerFn
 	defer func() {
-		ExecCommandStreamFn = origExecStreamFn
 		getContainerFn = origGetContainerFn
 	}()
+	rt := useFakeRuntime(t)
 
 	// Mock container lookup
 	getContainerFn = func(id string) (*dtos.Container, error) {
@@ -68,24 +28,11 @@ func TestStreamContainerLogs_Success(t *testing.T) {
 		}, nil
 	}
 
-	// Mock streaming command that outputs a few lines
-	stdoutData := "2024-01-01T10:00:00Z log line 1\n2024-01-01T10:00:01Z log line 2\n"
-	stderrData := "2024-01-01T10:00:02Z error line\n"
-
-	ExecCommandStreamFn = func(ctx context.Context, name string, args ...string) StreamCommandRunner {
-		// verify command
-		if name != "docker" {
-			t.Errorf("expected command 'docker', got %q", name)
-		}
-		if len(args) < 3 || args[0] != "logs" || args[1] != "-f" {
-			t.Errorf("expected args starting with 'logs -f', got %v", args)
-		}
-
-		return &mockStreamCommand{
-			stdout: &mockStreamReader{data: []byte(stdoutData)},
-			stderr: &mockStreamReader{data: []byte(stderrData)},
-			err:    nil,
-		}
+	// Runtime container that outputs a few lines
+	rt.AddContainer("test-id", "test-otel-container", "otel").Logs = []string{
+		"2024-01-01T10:00:00Z log line 1",
+		"2024-01-01T10:00:01Z log line 2",
+		"2024-01-01T10:00:02Z error line",
 	}
 
 	// Collect lines
@@ -106,7 +53,6 @@ func TestStreamContainerLogs_Success(t *testing.T) {
 		t.Errorf("expected 3 lines, got %d: %v", len(lines), lines)
 	}
 
-	// Check content (order is not guaranteed due to concurrent stdout/stderr reading)
 	allLines := strings.Join(lines, "\n")
 	expectedContents := []string{"log line 1", "log line 2", "error line"}
 	for _, expected := range expectedContents {
@@ -114,6 +60,11 @@ func TestStreamContainerLogs_Success(t *testing.T) {
 			t.Errorf("expected output to contain %q, got lines: %v", expected, lines)
 		}
 	}
+
+	// verify the runtime was asked to follow
+	if len(rt.LogCalls) != 1 || !rt.LogCalls[0].Follow {
+		t.Errorf("expected a single follow log call, got %+v", rt.LogCalls)
+	}
 }
 
 func TestStreamContainerLogs_ContainerNotFound(t *testing.T) {
@@ -136,14 +87,30 @@ func TestStreamContainerLogs_ContainerNotFound(t *testing.T) {
 	}
 }
 
+func TestStreamContainerLogs_RuntimeContainerNotFound(t *testing.T) {
+	origGetContainerFn := getContainerFn
+	defer func() {
+		getContainerFn = origGetContainerFn
+	}()
+	useFakeRuntime(t)
+
+	getContainerFn = func(id string) (*dtos.Container, error) {
+		return &dtos.Container{ID: "gone", Name: "gone", Type: "OTEL", Status: dtos.Running}, nil
+	}
+
+	err := StreamContainerLogs(context.Background(), "gone", func(line string) {})
+	if err != ErrContainerNotFound {
+		t.Errorf("expected ErrContainerNotFound, got %v", err)
+	}
+}
+
 func TestStreamContainerLogs_ContextCancelled(t *testing.T) {
 	// Save original functions
-	origExecStreamFn := ExecCommandStreamFn
 	origGetContainerFn := getContainerFn
 	defer func() {
-		ExecCommandStreamFn = origExecStreamFn
 		getContainerFn = origGetContainerFn
 	}()
+	rt := useFakeRuntime(t)
 
 	// Mock container lookup
 	getContainerFn = func(id string) (*dtos.Container, error) {
@@ -154,16 +121,7 @@ func TestStreamContainerLogs_ContextCancelled(t *testing.T) {
 			Status: dtos.Running,
 		}, nil
 	}
-
-	// Mock streaming command that never ends
-	ExecCommandStreamFn = func(ctx context.Context, name string, args ...string) StreamCommandRunner {
-		// infinite stream - will be stopped by context cancellation
-		return &mockStreamCommand{
-			stdout: &mockStreamReader{data: []byte{}}, // empty stream
-			stderr: &mockStreamReader{data: []byte{}},
-			err:    nil,
-		}
-	}
+	rt.AddContainer("test-id", "test-container", "otel")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	// cancel immediately
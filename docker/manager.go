@@ -2,58 +2,57 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os/exec"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/tim0-12432/simple-test-server/db/dtos"
 	"github.com/tim0-12432/simple-test-server/db/services"
 )
 
-var DockerClient interface{}
+// maxPortAttempts is how often a container is started with newly allocated host ports when
+// the runtime reports an allocated port as taken.
+const maxPortAttempts = 3
 
-var id = 0
+// createContainerFn and updateContainerStatusFn are variables so tests can run without PocketBase.
+var (
+	createContainerFn       = services.CreateContainer
+	updateContainerStatusFn = services.UpdateContainerStatus
+)
+
+// CheckAndPullImage pulls image unless it exists locally. The pull is aborted when ctx is
+// cancelled or the configured pull timeout elapses. onProgress receives the layer progress.
+func CheckAndPullImage(ctx context.Context, image string, onProgress func(PullProgress)) error {
+	ctx, cancel := context.WithTimeout(ctx, pullTimeout())
+	defer cancel()
 
-func CheckAndPullImage(image string) error {
-	log.Printf("Running Docker command: docker images -q %s", image)
-	cmd := exec.Command("docker", "images", "-q", image)
-	out, err := cmd.CombinedOutput()
+	exists, err := Runtime.ImageExists(ctx, image)
 	if err != nil {
-		return fmt.Errorf("docker images failed: %v - %s", err, strings.TrimSpace(string(out)))
+		return fmt.Errorf("inspect image failed: %w", err)
 	}
-
-	if strings.TrimSpace(string(out)) != "" {
+	if exists {
 		log.Printf("Docker image %s is already available locally", image)
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
-	defer cancel()
-
-	log.Printf("Running Docker command: docker pull %s", image)
-	cmdPull := exec.CommandContext(ctx, "docker", "pull", image)
-	pullOut, err := cmdPull.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("docker pull failed: %v - %s", err, strings.TrimSpace(string(pullOut)))
+	log.Printf("Pulling image %s via %s runtime", image, Runtime.Name())
+	if err := Runtime.PullImage(ctx, image, onProgress); err != nil {
+		return fmt.Errorf("image pull failed: %w", err)
 	}
 
-	log.Printf("Successfully pulled Docker image %s: %s", image, strings.TrimSpace(string(pullOut)))
+	log.Printf("Successfully pulled Docker image %s", image)
 	return nil
 }
 
-func RunContainer(config ServerConfiguration, cType string, image string, name string, ports []int, env map[string]string) error {
+// RunContainer creates and starts a managed container and stores its record. The stored
+// record is returned so callers can wait for the container to become ready.
+func RunContainer(config ServerConfiguration, cType string, image string, name string, ports []int, env map[string]string, volumes []string, limits *dtos.ResourceLimits) (*dtos.Container, error) {
 
-	var allPorts = map[int]int{}
+	var requestedPorts = map[int]int{}
 	var allEnv = map[string]string{}
-	var finalName = "simple-test-server-" + name + "-" + fmt.Sprint(id)
-	id++
 
-	for _, p := range ports {
-		allPorts[p] = p
-	}
 	for k, v := range env {
 		allEnv[k] = v
 	}
@@ -61,101 +60,161 @@ func RunContainer(config ServerConfiguration, cType string, image string, name s
 	// Merge ports from configuration (frontend provides an array of maps like [{"80":8080}])
 	for _, mapping := range config.Ports {
 		for hpStr, cp := range mapping {
-			if hpStr == "" {
-				continue
-			}
 			hp, err := strconv.Atoi(hpStr)
 			if err != nil {
-				// ignore malformed host port entries
-				continue
+				return nil, fmt.Errorf("invalid host port %q for container port %d", hpStr, cp)
 			}
-			allPorts[cp] = hp
+			requestedPorts[cp] = hp
 		}
 	}
 
 	for k, v := range config.Env {
 		allEnv[k] = v
 	}
-	if config.Name != "" {
-		finalName = config.Name
-	}
 
-	args := []string{"run", "-d", "--name", finalName, "--label", "managed_by=simple-test-server"}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
 
-	for cp, hp := range allPorts {
-		args = append(args, "-p", fmt.Sprintf("%d:%d", hp, cp))
-	}
-	for k, v := range allEnv {
-		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	finalName, releaseName, err := reserveContainerName(ctx, config.Name, name)
+	if err != nil {
+		return nil, err
 	}
+	defer releaseName()
 
-	args = append(args, image)
+	appliedLimits, err := resolveLimits(limits, config.Limits)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	labels := managedLabels()
+	labels[ServerTypeLabel] = cType
 
-	log.Printf("Running Docker command: docker %s", strings.Join(args, " "))
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	out, err := cmd.CombinedOutput()
+	network, err := resolveNetwork(ctx, config.Network)
 	if err != nil {
-		return fmt.Errorf("docker run failed: %v - %s", err, strings.TrimSpace(string(out)))
+		return nil, err
 	}
+	aliases, err := networkAliases(name, network, config.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	mounts, allVolumes, removeVolumes, err := resolveMounts(ctx, finalName, cType, volumes, config.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	// host ports the runtime could not bind are skipped when the ports are allocated again
+	excludedPorts := map[int]bool{}
+	var containerId string
+	var allPorts map[int]int
+	var runEnv map[string]string
+	for attempt := 1; ; attempt++ {
+		var releasePorts func()
+		allPorts, releasePorts, err = allocatePorts(ports, requestedPorts, excludedPorts)
+		if err != nil {
+			removeVolumes()
+			return nil, err
+		}
 
-	services.CreateContainer(&dtos.Container{
-		ID:          strings.TrimSpace(string(out)),
+		// env values may refer to the host ports, which differ between attempts
+		runEnv = expandEnv(allEnv, finalName, allPorts)
+		log.Printf("Creating container name=%s image=%s via %s runtime", finalName, image, Runtime.Name())
+		containerId, err = Runtime.RunContainer(ctx, ContainerSpec{
+			Name:      finalName,
+			Image:     image,
+			Env:       runEnv,
+			Ports:     allPorts,
+			Labels:    labels,
+			Mounts:    mounts,
+			Network:   network,
+			Aliases:   aliases,
+			Resources: runtimeResources(appliedLimits),
+		})
+		if err == nil {
+			defer releasePorts()
+			break
+		}
+		releasePorts()
+
+		// another process took a port between allocation and start, or the host probe could not
+		// see it; allocated ports are replaced, explicitly requested ones are not
+		allocated := false
+		for cp, hp := range allPorts {
+			if _, ok := requestedPorts[cp]; !ok {
+				excludedPorts[hp] = true
+				allocated = true
+			}
+		}
+		if !errors.Is(err, ErrPortInUse) || !allocated || attempt == maxPortAttempts {
+			removeVolumes()
+			return nil, fmt.Errorf("container run failed: %w", err)
+		}
+		log.Printf("Host port of container %s is in use, allocating other ports: %v", finalName, err)
+	}
+
+	container := &dtos.Container{
+		ID:          containerId,
 		Name:        finalName,
 		Image:       image,
 		CreatedAt:   time.Now().UnixMilli(),
-		Environment: allEnv,
+		Environment: runEnv,
 		Ports:       allPorts,
-		Volumes:     map[string]string{},
-		Networks:    []string{"host"},
-		Status:      dtos.Running,
+		Volumes:     allVolumes,
+		Networks:    []string{network},
+		Status:      dtos.Starting,
 		Type:        cType,
-	})
+		ExpiresAt:   expiresAt(config.TTLMinutes, time.Now()),
+		Limits:      appliedLimits,
+		Aliases:     config.Aliases,
+		Snapshot:    config.Snapshot,
+	}
+	if _, err := createContainerFn(container); err != nil {
+		log.Printf("Failed to store container %s: %v", containerId, err)
+	}
 
-	log.Printf("Started container name=%s image=%s output=%s", name, image, strings.TrimSpace(string(out)))
-	return nil
+	log.Printf("Started container name=%s image=%s id=%s", name, image, containerId)
+	return container, nil
 }
 
 func StopAllContainers() error {
-	log.Printf("Running Docker command: docker ps -aq -f label=managed_by=simple-test-server")
-	cmdList := exec.Command("docker", "ps", "-aq", "-f", "label=managed_by=simple-test-server")
-	out, err := cmdList.CombinedOutput()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	containers, err := Runtime.ListContainers(ctx, managedLabels())
 	if err != nil {
-		return fmt.Errorf("docker ps failed: %v - %s", err, strings.TrimSpace(string(out)))
+		return fmt.Errorf("list containers failed: %w", err)
 	}
 
-	ids := strings.Fields(strings.TrimSpace(string(out)))
-	if len(ids) == 0 {
+	if len(containers) == 0 {
 		log.Println("No managed containers to stop")
 		return nil
 	}
 
-	log.Printf("Running Docker command: docker rm -f %s", strings.Join(ids, " "))
-	args := append([]string{"rm", "-f"}, ids...)
-	cmdRm := exec.Command("docker", args...)
-	rmOut, err := cmdRm.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("docker rm failed: %v - %s", err, strings.TrimSpace(string(rmOut)))
-	}
-
-	for _, id := range ids {
-		services.UpdateContainerStatus(id, dtos.Discarded)
+	var firstErr error
+	for _, c := range containers {
+		if err := Runtime.RemoveContainer(ctx, c.ID, true); err != nil {
+			log.Printf("Failed to remove container %s: %v", c.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("remove container %s failed: %w", c.Name, err)
+			}
+			continue
+		}
+		updateContainerStatusFn(c.ID, dtos.Discarded)
+		log.Printf("Removed container %s", c.Name)
 	}
 
-	log.Printf("Removed containers: %s", strings.TrimSpace(string(rmOut)))
-	return nil
+	return firstErr
 }
 
-func StopContainer(containerId string) error {
-	log.Printf("Running Docker command: docker rm -f %s", containerId)
-	cmdRm := exec.Command("docker", "rm", "-f", containerId)
-	rmOut, err := cmdRm.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("docker rm failed: %v - %s", err, strings.TrimSpace(string(rmOut)))
+// DiscardContainer force-removes a container and marks its record as discarded.
+func DiscardContainer(containerId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	log.Printf("Removing container %s", containerId)
+	// a container removed outside of the application is still discarded
+	if err := Runtime.RemoveContainer(ctx, containerId, true); err != nil && !errors.Is(err, ErrContainerNotFound) {
+		return fmt.Errorf("remove container failed: %w", err)
 	}
 
-	services.UpdateContainerStatus(containerId, dtos.Discarded)
-	return nil
+	return updateContainerStatusFn(containerId, dtos.Discarded)
 }
@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// containerNamePrefix starts the generated name of every managed container.
+const containerNamePrefix = "simple-test-server-"
+
+// nameAttempts bounds how often a generated name is retried after a collision.
+const nameAttempts = 10
+
+// nameMu guards reservedNames, the names of containers that are being started but do not
+// exist in the runtime yet.
+var (
+	nameMu        sync.Mutex
+	reservedNames = map[string]bool{}
+)
+
+// nameSuffixFn returns the random part of generated names; tests replace it.
+var nameSuffixFn = randomNameSuffix
+
+// CheckContainerName reports whether name can be used for a new container. It returns
+// ErrInvalidContainerName if Docker would reject the name and ErrConflict if a container
+// with that name exists or is being started.
+func CheckContainerName(ctx context.Context, name string) error {
+	release, err := reserveName(ctx, name)
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// reserveContainerName reserves requested, or a generated name for serverName if requested
+// is empty, until the returned release function is called.
+func reserveContainerName(ctx context.Context, requested string, serverName string) (string, func(), error) {
+	if requested != "" {
+		release, err := reserveName(ctx, requested)
+		return requested, release, err
+	}
+
+	for i := 0; i < nameAttempts; i++ {
+		suffix, err := nameSuffixFn()
+		if err != nil {
+			return "", nil, fmt.Errorf("generate container name: %w", err)
+		}
+		name := containerNamePrefix + serverName + "-" + suffix
+		release, err := reserveName(ctx, name)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		return name, release, err
+	}
+	return "", nil, fmt.Errorf("%w: no unused name for %s after %d attempts", ErrConflict, serverName, nameAttempts)
+}
+
+// reserveName validates name, marks it as taken and checks that the runtime has no container
+// of that name. The returned function releases the reservation.
+func reserveName(ctx context.Context, name string) (func(), error) {
+	if !resourceNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", ErrInvalidContainerName, name)
+	}
+
+	nameMu.Lock()
+	if reservedNames[name] {
+		nameMu.Unlock()
+		return nil, fmt.Errorf("%w: container %s is already being started", ErrConflict, name)
+	}
+	reservedNames[name] = true
+	nameMu.Unlock()
+	release := func() {
+		nameMu.Lock()
+		delete(reservedNames, name)
+		nameMu.Unlock()
+	}
+
+	// container names are unique per runtime, so unmanaged containers block a name as well
+	_, err := Runtime.InspectContainer(ctx, name)
+	switch {
+	case err == nil:
+		release()
+		return nil, fmt.Errorf("%w: container %s already exists", ErrConflict, name)
+	case !errors.Is(err, ErrContainerNotFound):
+		release()
+		return nil, fmt.Errorf("inspect container %s: %w", name, err)
+	}
+	return release, nil
+}
+
+func randomNameSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckContainerName_Validates(t *testing.T) {
+	useFakeRuntime(t)
+
+	for _, name := range []string{"", "a", "-web", "web server", "web/1", "wéb"} {
+		if err := CheckContainerName(context.Background(), name); !errors.Is(err, ErrInvalidContainerName) {
+			t.Fatalf("expected ErrInvalidContainerName for %q, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"web", "my_web.1", "Web-2"} {
+		if err := CheckContainerName(context.Background(), name); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", name, err)
+		}
+	}
+}
+
+func TestCheckContainerName_DetectsExistingContainers(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("c1", "leftover", "nginx")
+
+	if err := CheckContainerName(context.Background(), "leftover"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for existing container, got %v", err)
+	}
+
+	_, release, err := reserveContainerName(context.Background(), "pending", "web")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if err := CheckContainerName(context.Background(), "pending"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for reserved name, got %v", err)
+	}
+	release()
+	if err := CheckContainerName(context.Background(), "pending"); err != nil {
+		t.Fatalf("expected released name to be free, got %v", err)
+	}
+}
+
+func TestReserveContainerName_SkipsTakenNames(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("c1", "simple-test-server-web-aaaa", "nginx")
+	suffixes := []string{"aaaa", "bbbb"}
+	old := nameSuffixFn
+	nameSuffixFn = func() (string, error) {
+		s := suffixes[0]
+		suffixes = suffixes[1:]
+		return s, nil
+	}
+	t.Cleanup(func() { nameSuffixFn = old })
+
+	name, release, err := reserveContainerName(context.Background(), "", "web")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	defer release()
+	if name != "simple-test-server-web-bbbb" {
+		t.Fatalf("expected the second candidate, got %s", name)
+	}
+}
+
+func TestReserveContainerName_UniqueUnderConcurrency(t *testing.T) {
+	useFakeRuntime(t)
+
+	var mu sync.Mutex
+	names := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name, _, err := reserveContainerName(context.Background(), "", "mqtt")
+			if err != nil {
+				t.Errorf("reserve: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if names[name] || !strings.HasPrefix(name, "simple-test-server-mqtt-") {
+				t.Errorf("unexpected name %s", name)
+			}
+			names[name] = true
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStartServerWithProgress_RejectsTakenNameBeforePull(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("c1", "taken", "nginx")
+
+	StartServerWithProgress("req-taken", "FTP", ServerConfiguration{Name: "taken"})
+	events := collectEvents(t, "req-taken")
+
+	if last := events[len(events)-1]; !last.Error || !strings.Contains(last.Message, "already exists") {
+		t.Fatalf("unexpected final event: %+v", last)
+	}
+	if len(rt.Pulled) != 0 {
+		t.Fatalf("expected no pull for a taken name, got %v", rt.Pulled)
+	}
+}
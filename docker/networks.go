@@ -0,0 +1,214 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/tim0-12432/simple-test-server/config"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// DefaultNetworkName is the managed network containers join when no network is configured.
+const DefaultNetworkName = "simple-test-server"
+
+// aliasPattern matches a single DNS label.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// NetworkDetails is a managed network as returned by the networks API.
+type NetworkDetails struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Labels     map[string]string `json:"labels"`
+	CreatedAt  int64             `json:"created_at"`
+	Containers []string          `json:"containers"`
+}
+
+// resolveNetwork returns the network a new container joins. Without a name the default
+// managed network is used and created if needed; a given name must already exist.
+func resolveNetwork(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		if err := ensureManagedNetwork(ctx, DefaultNetworkName); err != nil {
+			return "", err
+		}
+		return DefaultNetworkName, nil
+	}
+	if _, err := Runtime.InspectNetwork(ctx, name); err != nil {
+		return "", fmt.Errorf("network %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// ensureManagedNetwork creates the managed network name unless it exists and attaches the
+// application container to it, as a recreated application loses its networks.
+func ensureManagedNetwork(ctx context.Context, name string) error {
+	if _, err := Runtime.InspectNetwork(ctx, name); err == nil {
+		connectSelf(ctx, name)
+		return nil
+	} else if !errors.Is(err, ErrNetworkNotFound) {
+		return fmt.Errorf("inspect network %s failed: %w", name, err)
+	}
+
+	// a concurrent start may have created it in the meantime
+	if _, err := Runtime.CreateNetwork(ctx, name, managedLabels()); err != nil && !errors.Is(err, ErrConflict) {
+		return fmt.Errorf("create network %s failed: %w", name, err)
+	}
+	connectSelf(ctx, name)
+	return nil
+}
+
+// selfContainerFn returns the container the application runs in; a variable so tests can set it.
+var selfContainerFn = configuredSelfContainer
+
+func configuredSelfContainer() string {
+	if config.EnvConfig == nil {
+		return ""
+	}
+	return config.EnvConfig.SelfContainer
+}
+
+// connectSelf attaches the application container to network, if it runs in one.
+func connectSelf(ctx context.Context, network string) {
+	self := selfContainerFn()
+	if self == "" {
+		return
+	}
+	err := Runtime.ConnectNetwork(ctx, network, self, nil)
+	if err != nil && !errors.Is(err, ErrConflict) {
+		log.Printf("Failed to attach %s to network %s: %v", self, network, err)
+	}
+}
+
+// networkAliases returns the short name of a server plus extra. The default network is
+// shared by all runs, so servers on it only get extra.
+func networkAliases(serverName string, network string, extra []string) ([]string, error) {
+	aliases := []string{}
+	if network != DefaultNetworkName {
+		aliases = append(aliases, serverName)
+	}
+	for _, alias := range extra {
+		if !aliasPattern.MatchString(alias) {
+			return nil, fmt.Errorf("%w: alias %q is not a valid DNS name", ErrInvalidNetworkName, alias)
+		}
+		duplicate := false
+		for _, a := range aliases {
+			if strings.EqualFold(a, alias) {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases, nil
+}
+
+// ListNetworks returns all managed networks.
+func ListNetworks(ctx context.Context) ([]NetworkDetails, error) {
+	networks, err := Runtime.ListNetworks(ctx, managedLabels())
+	if err != nil {
+		return nil, fmt.Errorf("list networks failed: %w", err)
+	}
+	out := make([]NetworkDetails, 0, len(networks))
+	for _, n := range networks {
+		out = append(out, networkDetails(n))
+	}
+	return out, nil
+}
+
+// GetNetwork returns a single managed network.
+func GetNetwork(ctx context.Context, name string) (*NetworkDetails, error) {
+	info, err := inspectManagedNetwork(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	details := networkDetails(*info)
+	return &details, nil
+}
+
+// CreateNetwork creates a managed bridge network that containers can join by name.
+func CreateNetwork(ctx context.Context, name string) (*NetworkDetails, error) {
+	if !resourceNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNetworkName, name)
+	}
+	if _, err := Runtime.InspectNetwork(ctx, name); err == nil {
+		return nil, fmt.Errorf("%w: network %s already exists", ErrConflict, name)
+	}
+	if _, err := Runtime.CreateNetwork(ctx, name, managedLabels()); err != nil {
+		return nil, fmt.Errorf("create network failed: %w", err)
+	}
+	connectSelf(ctx, name)
+	return GetNetwork(ctx, name)
+}
+
+// DeleteNetwork removes a managed network that no container record uses anymore.
+func DeleteNetwork(ctx context.Context, name string) error {
+	if _, err := inspectManagedNetwork(ctx, name); err != nil {
+		return err
+	}
+	if users := networkUsers()[name]; len(users) > 0 {
+		return fmt.Errorf("%w: network %s is used by %s", ErrConflict, name, strings.Join(users, ", "))
+	}
+	if self := selfContainerFn(); self != "" {
+		_ = Runtime.DisconnectNetwork(ctx, name, self)
+	}
+	if err := Runtime.RemoveNetwork(ctx, name); err != nil {
+		return fmt.Errorf("remove network failed: %w", err)
+	}
+	return nil
+}
+
+// inspectManagedNetwork returns the network name, or ErrNetworkNotFound if it is not managed
+// by the application.
+func inspectManagedNetwork(ctx context.Context, name string) (*NetworkInfo, error) {
+	info, err := Runtime.InspectNetwork(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !hasLabels(info.Labels, managedLabels()) {
+		return nil, fmt.Errorf("%w: %s is not managed", ErrNetworkNotFound, name)
+	}
+	return info, nil
+}
+
+// networkUsers maps network names to the names of the containers that are not discarded.
+func networkUsers() map[string][]string {
+	users := map[string][]string{}
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		log.Printf("List containers failed: %v", err)
+		return users
+	}
+	for _, r := range records {
+		if r.Status == dtos.Discarded {
+			continue
+		}
+		for _, name := range r.Networks {
+			users[name] = append(users[name], r.Name)
+		}
+	}
+	return users
+}
+
+func networkDetails(n NetworkInfo) NetworkDetails {
+	var created int64
+	if !n.CreatedAt.IsZero() {
+		created = n.CreatedAt.UnixMilli()
+	}
+	containers := n.Containers
+	if containers == nil {
+		containers = []string{}
+	}
+	return NetworkDetails{
+		ID:         n.ID,
+		Name:       n.Name,
+		Driver:     n.Driver,
+		Labels:     n.Labels,
+		CreatedAt:  created,
+		Containers: containers,
+	}
+}
@@ -0,0 +1,122 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+func TestRunContainer_JoinsDefaultNetwork(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	rt.Images["mosquitto"] = true
+
+	config := ServerConfiguration{Name: "broker", Aliases: []string{"broker-alias", "MQTT"}}
+	if _, err := RunContainer(config, "MQTT", "mosquitto", "mqtt", nil, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	network := rt.Networks[DefaultNetworkName]
+	if network == nil || network.Labels[ManagedByLabel] != ManagedByValue {
+		t.Fatalf("expected managed default network, got %+v", rt.Networks)
+	}
+	spec := rt.Runs[0]
+	// the shared default network must not hand out the bare server name
+	if spec.Network != DefaultNetworkName || len(spec.Aliases) != 2 || spec.Aliases[0] != "broker-alias" || spec.Aliases[1] != "MQTT" {
+		t.Fatalf("unexpected network settings %q %v", spec.Network, spec.Aliases)
+	}
+	if n := (*created)[0].Networks; len(n) != 1 || n[0] != DefaultNetworkName {
+		t.Fatalf("unexpected stored networks %v", n)
+	}
+}
+
+func TestRunContainer_JoinsExistingNetwork(t *testing.T) {
+	rt := useFakeRuntime(t)
+	captureCreatedContainers(t)
+	rt.Images["nginx"] = true
+	rt.CreateNetwork(context.Background(), "ci-run-42", map[string]string{})
+
+	if _, err := RunContainer(ServerConfiguration{Network: "ci-run-42"}, "WEB", "nginx", "web", nil, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rt.Runs[0].Network != "ci-run-42" || len(rt.Runs[0].Aliases) != 1 || rt.Runs[0].Aliases[0] != "web" {
+		t.Fatalf("expected container in ci-run-42 with alias web, got %q %v", rt.Runs[0].Network, rt.Runs[0].Aliases)
+	}
+	if _, ok := rt.Networks[DefaultNetworkName]; ok {
+		t.Fatalf("default network must not be created when joining an existing one")
+	}
+
+	if _, err := RunContainer(ServerConfiguration{Network: "missing"}, "WEB", "nginx", "web", nil, nil, nil, nil); !errors.Is(err, ErrNetworkNotFound) {
+		t.Fatalf("expected ErrNetworkNotFound, got %v", err)
+	}
+	if _, err := RunContainer(ServerConfiguration{Aliases: []string{"not a name"}}, "WEB", "nginx", "web", nil, nil, nil, nil); !errors.Is(err, ErrInvalidNetworkName) {
+		t.Fatalf("expected ErrInvalidNetworkName, got %v", err)
+	}
+}
+
+func TestRunContainer_AttachesSelfToExistingNetwork(t *testing.T) {
+	rt := useFakeRuntime(t)
+	captureCreatedContainers(t)
+	rt.Images["nginx"] = true
+	// the network is left over from before the application was restarted
+	rt.CreateNetwork(context.Background(), DefaultNetworkName, managedLabels())
+	old := selfContainerFn
+	selfContainerFn = func() string { return "simple-test-server-app" }
+	t.Cleanup(func() { selfContainerFn = old })
+
+	for i := 0; i < 2; i++ {
+		if _, err := RunContainer(ServerConfiguration{}, "WEB", "nginx", "web", nil, nil, nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	attached := 0
+	for _, c := range rt.Networks[DefaultNetworkName].Containers {
+		if c == "simple-test-server-app" {
+			attached++
+		}
+	}
+	if attached != 1 {
+		t.Fatalf("expected the application attached once, got %v", rt.Networks[DefaultNetworkName].Containers)
+	}
+}
+
+func TestNetworksAPI(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakeRecords(t,
+		&dtos.Container{ID: "a", Name: "a", Status: dtos.Running, Networks: []string{"team-a"}},
+		&dtos.Container{ID: "b", Name: "b", Status: dtos.Discarded, Networks: []string{"team-b"}},
+	)
+	ctx := context.Background()
+
+	for _, name := range []string{"team-a", "team-b"} {
+		if _, err := CreateNetwork(ctx, name); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	if _, err := CreateNetwork(ctx, "team-a"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for duplicate network, got %v", err)
+	}
+	if _, err := CreateNetwork(ctx, "-bad"); !errors.Is(err, ErrInvalidNetworkName) {
+		t.Fatalf("expected ErrInvalidNetworkName, got %v", err)
+	}
+	rt.CreateNetwork(ctx, "bridge", map[string]string{})
+
+	networks, err := ListNetworks(ctx)
+	if err != nil || len(networks) != 2 {
+		t.Fatalf("expected the two managed networks, got %+v, %v", networks, err)
+	}
+	if err := DeleteNetwork(ctx, "team-a"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for network in use, got %v", err)
+	}
+	if err := DeleteNetwork(ctx, "bridge"); !errors.Is(err, ErrNetworkNotFound) {
+		t.Fatalf("unmanaged networks must not be deleted, got %v", err)
+	}
+	if err := DeleteNetwork(ctx, "team-b"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := rt.Networks["team-b"]; ok {
+		t.Fatalf("network should have been removed")
+	}
+}
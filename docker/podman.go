@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// libpodAPIPrefix is the path prefix of the native Podman (libpod) API.
+const libpodAPIPrefix = "/v4.0.0/libpod"
+
+// rootfulPodmanSocket is the socket of the system-wide podman.socket unit.
+const rootfulPodmanSocket = "/run/podman/podman.sock"
+
+// PodmanRuntime implements ContainerRuntime on top of the Podman API socket.
+// Container, log, exec and archive operations use Podman's Docker-compatible
+// endpoints; Podman-specific behaviour is handled through the libpod API.
+type PodmanRuntime struct {
+	*EngineRuntime
+}
+
+// NewPodmanRuntime creates a runtime talking to the Podman service at host.
+// An empty host selects the default rootless or rootful socket.
+func NewPodmanRuntime(host string) (*PodmanRuntime, error) {
+	if host == "" {
+		host = defaultPodmanHost()
+	}
+	engine, err := NewEngineRuntime(host)
+	if err != nil {
+		return nil, err
+	}
+	engine.name = "podman"
+	return &PodmanRuntime{EngineRuntime: engine}, nil
+}
+
+// defaultPodmanHost returns the rootless user socket if it exists, else the system socket.
+func defaultPodmanHost() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		socket := filepath.Join(dir, "podman", "podman.sock")
+		if _, err := os.Stat(socket); err == nil {
+			return "unix://" + socket
+		}
+	}
+	return "unix://" + rootfulPodmanSocket
+}
+
+func (p *PodmanRuntime) Ping(ctx context.Context) error {
+	resp, err := p.rawRequest(ctx, http.MethodGet, libpodAPIPrefix+"/_ping", nil, nil, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("ping", resp, nil)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ImageExists uses the libpod exists endpoint, which also resolves images built
+// locally by Podman under the localhost/ registry prefix.
+func (p *PodmanRuntime) ImageExists(ctx context.Context, image string) (bool, error) {
+	resp, err := p.rawRequest(ctx, http.MethodGet, libpodAPIPrefix+"/images/"+image+"/exists", nil, nil, "")
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		resp.Body.Close()
+		return true, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return false, nil
+	default:
+		return false, responseError("inspect image", resp, ErrImageNotFound)
+	}
+}
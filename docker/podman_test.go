@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewRuntime_SelectsBackend(t *testing.T) {
+	rt, err := newRuntime("podman", "unix:///run/user/1000/podman/podman.sock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := rt.(*PodmanRuntime); !ok || rt.Name() != "podman" {
+		t.Fatalf("expected podman runtime, got %T (%s)", rt, rt.Name())
+	}
+
+	rt, err = newRuntime("docker", defaultDockerHost)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := rt.(*EngineRuntime); !ok || rt.Name() != "docker" {
+		t.Fatalf("expected docker runtime, got %T (%s)", rt, rt.Name())
+	}
+
+	if _, err := newRuntime("containerd", ""); err == nil {
+		t.Fatalf("expected error for unknown runtime")
+	}
+}
+
+func TestDefaultPodmanHost(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	// no rootless socket yet: fall back to the rootful one
+	if got := defaultPodmanHost(); got != "unix://"+rootfulPodmanSocket {
+		t.Fatalf("expected rootful socket, got %s", got)
+	}
+
+	socket := filepath.Join(dir, "podman", "podman.sock")
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultPodmanHost(); got != "unix://"+socket {
+		t.Fatalf("expected rootless socket %s, got %s", socket, got)
+	}
+}
+
+func TestPodmanRuntime_UsesLibpodAndCompatEndpoints(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch {
+		case r.URL.Path == libpodAPIPrefix+"/_ping":
+			_, _ = w.Write([]byte("OK"))
+		case r.URL.Path == libpodAPIPrefix+"/images/simple-test-server-custom-mqtt:latest/exists":
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasPrefix(r.URL.Path, libpodAPIPrefix+"/images/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/"+engineAPIVersion+"/containers/json":
+			if got := r.URL.Query().Get("filters"); got != `{"label":["managed_by=simple-test-server"]}` {
+				t.Errorf("unexpected filters %q", got)
+			}
+			_, _ = w.Write([]byte(`[{"Id":"p1","Names":["/simple-test-server-mqtt-0"],"Image":"mqtt","Labels":{"managed_by":"simple-test-server"},"State":"running"}]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	rt, err := NewPodmanRuntime("tcp://" + strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := rt.Ping(ctx); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	if ok, err := rt.ImageExists(ctx, "simple-test-server-custom-mqtt:latest"); err != nil || !ok {
+		t.Fatalf("expected image to exist, got %v %v", ok, err)
+	}
+	if ok, err := rt.ImageExists(ctx, "missing:latest"); err != nil || ok {
+		t.Fatalf("expected image to be missing, got %v %v", ok, err)
+	}
+
+	containers, err := rt.ListContainers(ctx, managedLabels())
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Name != "simple-test-server-mqtt-0" || !containers[0].State.Running {
+		t.Fatalf("unexpected containers: %+v", containers)
+	}
+}
@@ -0,0 +1,189 @@
+package docker
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tim0-12432/simple-test-server/config"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// portAvailableFn, freePortFn and portRangeFn are variables so tests can override them.
+var (
+	portAvailableFn = portAvailable
+	freePortFn      = freePort
+	portRangeFn     = configuredPortRange
+)
+
+// reservedPorts holds ports handed out to containers whose record is not stored yet.
+var (
+	portMu        sync.Mutex
+	reservedPorts = map[int]bool{}
+)
+
+// allocatePorts maps every container port to a free host port not in excluded. The returned
+// release function must be called once the container record is stored.
+func allocatePorts(containerPorts []int, requested map[int]int, excluded map[int]bool) (map[int]int, func(), error) {
+	portMu.Lock()
+	defer portMu.Unlock()
+
+	used := usedHostPorts()
+	for p := range reservedPorts {
+		used[p] = true
+	}
+	for p := range excluded {
+		used[p] = true
+	}
+
+	allocated := make(map[int]int, len(containerPorts)+len(requested))
+	for _, cp := range sortedKeys(requested) {
+		hp := requested[cp]
+		if used[hp] || !portAvailableFn(hp) {
+			return nil, nil, fmt.Errorf("%w: host port %d requested for container port %d", ErrPortInUse, hp, cp)
+		}
+		used[hp] = true
+		allocated[cp] = hp
+	}
+
+	sorted := append([]int{}, containerPorts...)
+	sort.Ints(sorted)
+	for _, cp := range sorted {
+		if _, ok := allocated[cp]; ok {
+			continue
+		}
+		hp, err := pickHostPort(cp, used)
+		if err != nil {
+			return nil, nil, err
+		}
+		used[hp] = true
+		allocated[cp] = hp
+	}
+
+	for _, hp := range allocated {
+		reservedPorts[hp] = true
+	}
+	release := func() {
+		portMu.Lock()
+		defer portMu.Unlock()
+		for _, hp := range allocated {
+			delete(reservedPorts, hp)
+		}
+	}
+	return allocated, release, nil
+}
+
+// pickHostPort returns a free host port for containerPort: the next free port of the
+// configured range, or else the container port itself if free, or else a random free port.
+func pickHostPort(containerPort int, used map[int]bool) (int, error) {
+	if start, end := portRangeFn(); start > 0 {
+		for p := start; p <= end; p++ {
+			if !used[p] && portAvailableFn(p) {
+				return p, nil
+			}
+		}
+		return 0, fmt.Errorf("%w: no free host port in range %d-%d", ErrPortInUse, start, end)
+	}
+
+	if !used[containerPort] && portAvailableFn(containerPort) {
+		return containerPort, nil
+	}
+	for i := 0; i < 10; i++ {
+		p, err := freePortFn()
+		if err != nil {
+			return 0, fmt.Errorf("find free port: %w", err)
+		}
+		if !used[p] {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no free host port for container port %d", ErrPortInUse, containerPort)
+}
+
+// usedHostPorts collects the host ports of all containers that are not discarded.
+func usedHostPorts() map[int]bool {
+	used := map[int]bool{}
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		// without records only the host check protects against conflicts
+		return used
+	}
+	for _, r := range records {
+		if r.Status == dtos.Discarded {
+			continue
+		}
+		for _, hp := range r.Ports {
+			used[hp] = true
+		}
+	}
+	return used
+}
+
+// expandEnv replaces ${CONTAINER_NAME} and ${HOST_PORT_<container port>} in the values of env.
+func expandEnv(env map[string]string, containerName string, ports map[int]int) map[string]string {
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		v = strings.ReplaceAll(v, servers.ContainerNamePlaceholder, containerName)
+		out[k] = servers.HostPortPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			containerPort, _ := strconv.Atoi(servers.HostPortPattern.FindStringSubmatch(placeholder)[1])
+			if hostPort, ok := ports[containerPort]; ok {
+				return strconv.Itoa(hostPort)
+			}
+			return placeholder
+		})
+	}
+	return out
+}
+
+// portAvailable reports whether port can be bound. Inside a container the host ports are not
+// visible, so conflicts are only detected when the runtime binds the port.
+func portAvailable(port int) bool {
+	if selfContainerFn() != "" {
+		return true
+	}
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+// freePort asks the kernel for a free ephemeral port.
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func configuredPortRange() (int, int) {
+	if config.EnvConfig == nil || config.EnvConfig.PortRangeStart <= 0 || config.EnvConfig.PortRangeEnd < config.EnvConfig.PortRangeStart {
+		return 0, 0
+	}
+	return config.EnvConfig.PortRangeStart, config.EnvConfig.PortRangeEnd
+}
+
+// formatPorts renders a port mapping as "1883->1883, 9001->32768" ordered by container port.
+func formatPorts(ports map[int]int) string {
+	parts := make([]string, 0, len(ports))
+	for _, cp := range sortedKeys(ports) {
+		parts = append(parts, fmt.Sprintf("%d->%d", cp, ports[cp]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,170 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// stubHostPorts makes the given host ports appear busy and every other port free.
+// Random free ports are handed out from 40000 upwards.
+func stubHostPorts(t *testing.T, busy ...int) {
+	t.Helper()
+	oldAvailable, oldFree, oldRange := portAvailableFn, freePortFn, portRangeFn
+	taken := map[int]bool{}
+	for _, p := range busy {
+		taken[p] = true
+	}
+	next := 40000
+	portAvailableFn = func(port int) bool { return !taken[port] }
+	freePortFn = func() (int, error) {
+		next++
+		return next, nil
+	}
+	portRangeFn = func() (int, int) { return 0, 0 }
+	t.Cleanup(func() { portAvailableFn, freePortFn, portRangeFn = oldAvailable, oldFree, oldRange })
+}
+
+func TestAllocatePorts_KeepsFreeContainerPorts(t *testing.T) {
+	stubHostPorts(t, 1883)
+	useFakeRecords(t)
+
+	ports, release, err := allocatePorts([]int{1883, 9001}, nil, nil)
+	if err != nil {
+		t.Fatalf("allocatePorts: %v", err)
+	}
+	defer release()
+	if ports[9001] != 9001 {
+		t.Fatalf("expected free port to be kept, got %v", ports)
+	}
+	if ports[1883] != 40001 {
+		t.Fatalf("expected busy port to be replaced by a free one, got %v", ports)
+	}
+}
+
+func TestAllocatePorts_SkipsPortsOfManagedContainers(t *testing.T) {
+	stubHostPorts(t)
+	useFakeRecords(t,
+		&dtos.Container{ID: "a", Status: dtos.Running, Ports: map[int]int{80: 8080}},
+		&dtos.Container{ID: "b", Status: dtos.Discarded, Ports: map[int]int{80: 80}},
+	)
+
+	ports, release, err := allocatePorts([]int{80, 8080}, nil, nil)
+	if err != nil {
+		t.Fatalf("allocatePorts: %v", err)
+	}
+	defer release()
+	if ports[80] != 80 {
+		t.Fatalf("expected port of discarded container to be reused, got %v", ports)
+	}
+	if ports[8080] == 8080 {
+		t.Fatalf("expected port of running container to be skipped, got %v", ports)
+	}
+}
+
+func TestAllocatePorts_RejectsBusyRequestedPort(t *testing.T) {
+	stubHostPorts(t, 18025)
+	useFakeRecords(t)
+
+	_, _, err := allocatePorts([]int{8025}, map[int]int{8025: 18025}, nil)
+	if !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+}
+
+func TestAllocatePorts_UsesConfiguredRange(t *testing.T) {
+	stubHostPorts(t, 30001)
+	useFakeRecords(t)
+	portRangeFn = func() (int, int) { return 30000, 30002 }
+
+	ports, release, err := allocatePorts([]int{21, 22}, nil, nil)
+	if err != nil {
+		t.Fatalf("allocatePorts: %v", err)
+	}
+	if ports[21] != 30000 || ports[22] != 30002 {
+		t.Fatalf("expected ports from range, got %v", ports)
+	}
+
+	if _, _, err := allocatePorts([]int{23}, nil, nil); !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected exhausted range to fail, got %v", err)
+	}
+	release()
+	if ports, release, err := allocatePorts([]int{23}, nil, nil); err != nil || ports[23] != 30000 {
+		t.Fatalf("expected released port to be reusable, got %v %v", ports, err)
+	} else {
+		release()
+	}
+}
+
+func TestRunContainer_ReportsPortConflict(t *testing.T) {
+	useFakeRuntime(t)
+	captureCreatedContainers(t)
+	useFakeRecords(t, &dtos.Container{ID: "other", Status: dtos.Running, Ports: map[int]int{1883: 11883}})
+
+	_, err := RunContainer(ServerConfiguration{Ports: []map[string]int{{"11883": 1883}}}, "MQTT", "eclipse-mosquitto", "mqtt", []int{1883}, nil, nil, nil)
+	if !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+}
+
+func TestRunContainer_RetriesAllocatedPortsTakenOnTheHost(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakeRecords(t)
+	created := captureCreatedContainers(t)
+	rt.Images["eclipse-mosquitto"] = true
+	// the probe cannot see the process holding the port, e.g. when the application runs in a container
+	rt.HostPortsInUse = map[int]bool{1883: true}
+
+	if _, err := RunContainer(ServerConfiguration{}, "MQTT", "eclipse-mosquitto", "mqtt", []int{1883, 9001}, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p := (*created)[0].Ports; p[1883] == 1883 || p[1883] == 0 {
+		t.Fatalf("expected a fresh port for 1883, got %v", p)
+	}
+	if len(rt.Runs) != 1 {
+		t.Fatalf("expected a single started container, got %d", len(rt.Runs))
+	}
+
+	// explicitly requested ports are not replaced
+	_, err := RunContainer(ServerConfiguration{Ports: []map[string]int{{"1883": 1883}}}, "MQTT", "eclipse-mosquitto", "mqtt", []int{1883}, nil, nil, nil)
+	if !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+}
+
+func TestRunContainer_AdvertisesKafkaOnTheHostAndTheNetwork(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakeRecords(t)
+	created := captureCreatedContainers(t)
+	rt.Images["apache/kafka"] = true
+	// another broker holds 9092, so the first attempt fails and a fresh port is allocated
+	rt.HostPortsInUse = map[int]bool{9092: true}
+	kafka, err := servers.GetServerByType("KAFKA")
+	if err != nil {
+		t.Fatalf("GetServerByType: %v", err)
+	}
+
+	if _, err := RunContainer(ServerConfiguration{}, "KAFKA", "apache/kafka", "kafka", kafka.Ports, kafka.Env, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hostPort := (*created)[0].Ports[9092]
+	want := fmt.Sprintf("PLAINTEXT://localhost:%d,INTERNAL://%s:19092", hostPort, (*created)[0].Name)
+	if hostPort == 9092 || rt.Runs[0].Env["KAFKA_ADVERTISED_LISTENERS"] != want {
+		t.Fatalf("expected %s to be advertised, got %q", want, rt.Runs[0].Env["KAFKA_ADVERTISED_LISTENERS"])
+	}
+	if (*created)[0].Environment["KAFKA_ADVERTISED_LISTENERS"] != want {
+		t.Fatalf("expected the stored environment to advertise %s, got %v", want, (*created)[0].Environment)
+	}
+
+	// a listener set in the configuration is used as is
+	config := ServerConfiguration{Env: map[string]string{"KAFKA_ADVERTISED_LISTENERS": "PLAINTEXT://kafka:9092"}}
+	if _, err := RunContainer(config, "KAFKA", "apache/kafka", "kafka", kafka.Ports, kafka.Env, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rt.Runs[1].Env["KAFKA_ADVERTISED_LISTENERS"]; got != "PLAINTEXT://kafka:9092" {
+		t.Fatalf("expected the configured listener, got %q", got)
+	}
+}
@@ -0,0 +1,239 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// builtinPresetPrefix starts the ids of the presets derived from the server catalog.
+const builtinPresetPrefix = "builtin-"
+
+// The preset store functions are variables so tests can run without PocketBase.
+var (
+	createPresetFn = services.CreatePreset
+	listPresetsFn  = services.ListPresets
+	getPresetFn    = services.GetPreset
+	updatePresetFn = services.UpdatePreset
+	deletePresetFn = services.DeletePreset
+)
+
+// ListPresets returns the built-in and the stored presets, optionally only those of serverType.
+func ListPresets(serverType string) ([]*dtos.Preset, error) {
+	stored, err := listPresetsFn()
+	if err != nil {
+		return nil, err
+	}
+	out := []*dtos.Preset{}
+	for _, p := range append(builtinPresets(), stored...) {
+		if serverType == "" || p.Type == serverType {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// GetPreset returns the built-in or stored preset id.
+func GetPreset(id string) (*dtos.Preset, error) {
+	if strings.HasPrefix(id, builtinPresetPrefix) {
+		for _, p := range builtinPresets() {
+			if p.ID == id {
+				return p, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", services.ErrPresetNotFound, id)
+	}
+	return getPresetFn(id)
+}
+
+// CreatePreset validates and stores a new preset. Preset names are unique per server type.
+func CreatePreset(preset *dtos.Preset) (*dtos.Preset, error) {
+	if err := validatePreset(preset, ""); err != nil {
+		return nil, err
+	}
+	preset.BuiltIn = false
+	preset.CreatedAt = time.Now().UnixMilli()
+	preset.UpdatedAt = preset.CreatedAt
+	id, err := createPresetFn(preset)
+	if errors.Is(err, services.ErrPresetExists) {
+		// created concurrently since the check in validatePreset
+		return nil, fmt.Errorf("%w: preset %s already exists for %s", ErrConflict, preset.Name, preset.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	preset.ID = id
+	return preset, nil
+}
+
+// UpdatePreset replaces the name, description and configuration of the stored preset id.
+func UpdatePreset(id string, preset *dtos.Preset) (*dtos.Preset, error) {
+	current, err := GetPreset(id)
+	if err != nil {
+		return nil, err
+	}
+	if current.BuiltIn {
+		return nil, fmt.Errorf("%w: %s", ErrReadOnlyPreset, id)
+	}
+	// the configuration was validated against this type
+	preset.Type = current.Type
+	if err := validatePreset(preset, id); err != nil {
+		return nil, err
+	}
+	preset.ID = id
+	preset.BuiltIn = false
+	preset.CreatedAt = current.CreatedAt
+	preset.UpdatedAt = time.Now().UnixMilli()
+	if err := updatePresetFn(id, preset); errors.Is(err, services.ErrPresetExists) {
+		return nil, fmt.Errorf("%w: preset %s already exists for %s", ErrConflict, preset.Name, preset.Type)
+	} else if err != nil {
+		return nil, err
+	}
+	return preset, nil
+}
+
+// DeletePreset removes the stored preset id.
+func DeletePreset(id string) error {
+	current, err := GetPreset(id)
+	if err != nil {
+		return err
+	}
+	if current.BuiltIn {
+		return fmt.Errorf("%w: %s", ErrReadOnlyPreset, id)
+	}
+	return deletePresetFn(id)
+}
+
+// PresetConfiguration returns the server type and configuration of the preset id with the
+// set fields of overrides applied. Env variables are merged; all other fields replace the
+// preset value.
+func PresetConfiguration(id string, overrides ServerConfiguration) (string, ServerConfiguration, error) {
+	preset, err := GetPreset(id)
+	if err != nil {
+		return "", ServerConfiguration{}, err
+	}
+	config, err := decodeConfiguration(preset.Configuration)
+	if err != nil {
+		return "", ServerConfiguration{}, err
+	}
+	return preset.Type, mergeConfiguration(config, overrides), nil
+}
+
+func mergeConfiguration(base ServerConfiguration, overrides ServerConfiguration) ServerConfiguration {
+	if overrides.Name != "" {
+		base.Name = overrides.Name
+	}
+	if len(overrides.Ports) > 0 {
+		base.Ports = overrides.Ports
+	}
+	if len(overrides.Env) > 0 {
+		env := maps.Clone(base.Env)
+		if env == nil {
+			env = map[string]string{}
+		}
+		maps.Copy(env, overrides.Env)
+		base.Env = env
+	}
+	if len(overrides.Volumes) > 0 {
+		base.Volumes = overrides.Volumes
+	}
+	if overrides.Network != "" {
+		base.Network = overrides.Network
+	}
+	if len(overrides.Aliases) > 0 {
+		base.Aliases = overrides.Aliases
+	}
+	if overrides.TTLMinutes != 0 {
+		base.TTLMinutes = overrides.TTLMinutes
+	}
+	if overrides.Limits != nil {
+		base.Limits = overrides.Limits
+	}
+	if overrides.Snapshot != "" {
+		base.Snapshot = overrides.Snapshot
+	}
+	if overrides.Seed != "" {
+		base.Seed = overrides.Seed
+	}
+	return base
+}
+
+// validatePreset checks the name, type and configuration of preset. ignoreId is the preset
+// being updated, which may keep its name.
+func validatePreset(preset *dtos.Preset, ignoreId string) error {
+	preset.Name = strings.TrimSpace(preset.Name)
+	if preset.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPreset)
+	}
+	if _, err := servers.GetServerByType(preset.Type); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPreset, err)
+	}
+	config, err := decodeConfiguration(preset.Configuration)
+	if err != nil {
+		return err
+	}
+	if err := ValidateConfiguration(preset.Type, config); err != nil {
+		return err
+	}
+	// store the configuration in its canonical form
+	if preset.Configuration, err = json.Marshal(config); err != nil {
+		return err
+	}
+
+	existing, err := ListPresets(preset.Type)
+	if err != nil {
+		return err
+	}
+	for _, p := range existing {
+		if p.ID == ignoreId || !strings.EqualFold(p.Name, preset.Name) {
+			continue
+		}
+		if p.BuiltIn {
+			return fmt.Errorf("%w: the name %s is reserved for the built-in preset of %s", ErrConflict, p.Name, preset.Type)
+		}
+		return fmt.Errorf("%w: preset %s already exists for %s", ErrConflict, preset.Name, preset.Type)
+	}
+	return nil
+}
+
+// decodeConfiguration parses a stored configuration and rejects unknown fields.
+func decodeConfiguration(raw json.RawMessage) (ServerConfiguration, error) {
+	var config ServerConfiguration
+	if len(raw) == 0 || string(raw) == "null" {
+		return config, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%w: configuration: %v", ErrInvalidPreset, err)
+	}
+	return config, nil
+}
+
+// builtinPresets returns a read-only preset with the default env of every catalog type.
+func builtinPresets() []*dtos.Preset {
+	out := []*dtos.Preset{}
+	for _, server := range servers.GetAllServers() {
+		config, err := json.Marshal(ServerConfiguration{Env: server.Env})
+		if err != nil {
+			continue
+		}
+		out = append(out, &dtos.Preset{
+			ID:            builtinPresetPrefix + server.Name,
+			Name:          "Default",
+			Type:          server.Type,
+			Description:   "Default configuration of the " + server.Name + " server",
+			Configuration: config,
+			BuiltIn:       true,
+		})
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// fakePresets is an in-memory replacement for the presets collection.
+type fakePresets struct {
+	mu      sync.Mutex
+	presets map[string]*dtos.Preset
+	nextID  int
+}
+
+func useFakePresets(t *testing.T) *fakePresets {
+	t.Helper()
+	f := &fakePresets{presets: map[string]*dtos.Preset{}}
+
+	oldCreate, oldList, oldGet, oldUpdate, oldDelete := createPresetFn, listPresetsFn, getPresetFn, updatePresetFn, deletePresetFn
+	createPresetFn = func(p *dtos.Preset) (string, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// like the unique index of the presets collection
+		for _, other := range f.presets {
+			if other.Type == p.Type && strings.EqualFold(other.Name, p.Name) {
+				return "", fmt.Errorf("%w: %s for %s", services.ErrPresetExists, p.Name, p.Type)
+			}
+		}
+		f.nextID++
+		id := fmt.Sprintf("preset%d", f.nextID)
+		stored := *p
+		stored.ID = id
+		f.presets[id] = &stored
+		return id, nil
+	}
+	listPresetsFn = func() ([]*dtos.Preset, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		out := []*dtos.Preset{}
+		for _, p := range f.presets {
+			stored := *p
+			out = append(out, &stored)
+		}
+		return out, nil
+	}
+	getPresetFn = func(id string) (*dtos.Preset, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		p, ok := f.presets[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", services.ErrPresetNotFound, id)
+		}
+		stored := *p
+		return &stored, nil
+	}
+	updatePresetFn = func(id string, p *dtos.Preset) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		stored := *p
+		f.presets[id] = &stored
+		return nil
+	}
+	deletePresetFn = func(id string) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.presets, id)
+		return nil
+	}
+	t.Cleanup(func() {
+		createPresetFn, listPresetsFn, getPresetFn, updatePresetFn, deletePresetFn = oldCreate, oldList, oldGet, oldUpdate, oldDelete
+	})
+	return f
+}
+
+func TestCreatePreset_ValidatesConfiguration(t *testing.T) {
+	useFakePresets(t)
+
+	preset, err := CreatePreset(&dtos.Preset{
+		Name:          " team broker ",
+		Type:          "MQTT",
+		Configuration: json.RawMessage(`{"env":{"MQTT_USERNAME":"team"},"ports":[{"11883":1883}]}`),
+	})
+	if err != nil {
+		t.Fatalf("CreatePreset: %v", err)
+	}
+	if preset.ID == "" || preset.Name != "team broker" || preset.CreatedAt == 0 || preset.BuiltIn {
+		t.Fatalf("unexpected preset %+v", preset)
+	}
+
+	cases := []struct {
+		preset dtos.Preset
+		want   error
+	}{
+		{dtos.Preset{Name: "", Type: "MQTT"}, ErrInvalidPreset},
+		{dtos.Preset{Name: "x", Type: "NOPE"}, ErrInvalidPreset},
+		{dtos.Preset{Name: "x", Type: "MQTT", Configuration: json.RawMessage(`{"enviroment":{}}`)}, ErrInvalidPreset},
+		{dtos.Preset{Name: "Team Broker", Type: "MQTT"}, ErrConflict},
+		{dtos.Preset{Name: "Default", Type: "MQTT"}, ErrConflict},
+		{dtos.Preset{Name: "x", Type: "MQTT", Configuration: json.RawMessage(`{"limits":{"memory_mb":-1}}`)}, ErrInvalidLimits},
+	}
+	for _, tc := range cases {
+		if _, err := CreatePreset(&tc.preset); !errors.Is(err, tc.want) {
+			t.Errorf("%+v: expected %v, got %v", tc.preset, tc.want, err)
+		}
+	}
+
+	_, err = CreatePreset(&dtos.Preset{Name: "x", Type: "MQTT", Configuration: json.RawMessage(`{"env":{"MQTT_PASSWORD":""}}`)})
+	var configErr *servers.ConfigError
+	if !errors.As(err, &configErr) || configErr.Fields["env.MQTT_PASSWORD"] != "is required" {
+		t.Fatalf("expected field errors, got %v", err)
+	}
+}
+
+func TestCreatePreset_ConcurrentNamesConflict(t *testing.T) {
+	useFakePresets(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := CreatePreset(&dtos.Preset{Name: "Broker", Type: "MQTT"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+		} else if !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected ErrConflict, got %v", err)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("expected a single preset to be created, got %d", created)
+	}
+
+	_, err := CreatePreset(&dtos.Preset{Name: "default", Type: "MQTT"})
+	if !errors.Is(err, ErrConflict) || !strings.Contains(err.Error(), "reserved for the built-in preset") {
+		t.Fatalf("expected the built-in name to be reported as reserved, got %v", err)
+	}
+}
+
+func TestPresets_BuiltInsAreReadOnly(t *testing.T) {
+	useFakePresets(t)
+
+	presets, err := ListPresets("FTP")
+	if err != nil {
+		t.Fatalf("ListPresets: %v", err)
+	}
+	if len(presets) != 1 || presets[0].ID != "builtin-ftp" || !presets[0].BuiltIn {
+		t.Fatalf("expected the built-in ftp preset, got %+v", presets)
+	}
+	if _, err := UpdatePreset("builtin-ftp", &dtos.Preset{Name: "changed"}); !errors.Is(err, ErrReadOnlyPreset) {
+		t.Fatalf("expected ErrReadOnlyPreset on update, got %v", err)
+	}
+	if err := DeletePreset("builtin-ftp"); !errors.Is(err, ErrReadOnlyPreset) {
+		t.Fatalf("expected ErrReadOnlyPreset on delete, got %v", err)
+	}
+	if _, err := GetPreset("builtin-unknown"); !errors.Is(err, services.ErrPresetNotFound) {
+		t.Fatalf("expected ErrPresetNotFound, got %v", err)
+	}
+}
+
+func TestUpdateAndDeletePreset(t *testing.T) {
+	store := useFakePresets(t)
+	preset, err := CreatePreset(&dtos.Preset{Name: "a", Type: "FTP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := UpdatePreset(preset.ID, &dtos.Preset{Name: "a", Type: "MQTT", Description: "renamed", Configuration: json.RawMessage(`{"env":{"FTP_USER":"bob"}}`)})
+	if err != nil {
+		t.Fatalf("UpdatePreset: %v", err)
+	}
+	if updated.Type != "FTP" || updated.Description != "renamed" || updated.CreatedAt != preset.CreatedAt {
+		t.Fatalf("unexpected update %+v", updated)
+	}
+	if err := DeletePreset(preset.ID); err != nil {
+		t.Fatalf("DeletePreset: %v", err)
+	}
+	if len(store.presets) != 0 {
+		t.Fatalf("expected the preset to be deleted, got %v", store.presets)
+	}
+}
+
+func TestPresetConfiguration_AppliesOverrides(t *testing.T) {
+	useFakePresets(t)
+	preset, err := CreatePreset(&dtos.Preset{
+		Name:          "team",
+		Type:          "MQTT",
+		Configuration: json.RawMessage(`{"env":{"MQTT_USERNAME":"team","MQTT_PASSWORD":"secret"},"ports":[{"11883":1883}],"ttl_minutes":30}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverType, config, err := PresetConfiguration(preset.ID, ServerConfiguration{
+		Name: "mqtt-test",
+		Env:  map[string]string{"MQTT_PASSWORD": "other"},
+	})
+	if err != nil {
+		t.Fatalf("PresetConfiguration: %v", err)
+	}
+	if serverType != "MQTT" || config.Name != "mqtt-test" || config.TTLMinutes != 30 {
+		t.Fatalf("unexpected configuration %+v", config)
+	}
+	if config.Env["MQTT_USERNAME"] != "team" || config.Env["MQTT_PASSWORD"] != "other" {
+		t.Fatalf("expected env to be merged, got %v", config.Env)
+	}
+	if len(config.Ports) != 1 || config.Ports[0]["11883"] != 1883 {
+		t.Fatalf("expected the preset ports, got %v", config.Ports)
+	}
+
+	if _, config, err = PresetConfiguration("builtin-mqtt", ServerConfiguration{}); err != nil || config.Env["MQTT_USERNAME"] != "user" {
+		t.Fatalf("expected the default env from the built-in preset, got %+v (%v)", config, err)
+	}
+}
@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/config"
+)
+
+// defaultPullTimeout is used when PULL_TIMEOUT_SECONDS is not configured.
+const defaultPullTimeout = 180 * time.Second
+
+// Pull progress is reported between these percentages of a start request.
+const (
+	pullStartPercent = 30
+	pullEndPercent   = 50
+)
+
+// pullTracker turns the per-layer messages of a pull into an overall percentage. Every layer
+// has the same weight; downloading and extracting are half of a layer each.
+type pullTracker struct {
+	layers  map[string]*layerProgress
+	percent int
+}
+
+type layerProgress struct {
+	total      int64
+	downloaded int64
+	extracted  int64
+	done       bool
+}
+
+func newPullTracker() *pullTracker {
+	return &pullTracker{layers: map[string]*layerProgress{}, percent: pullStartPercent}
+}
+
+// update records p and reports whether the overall percentage increased.
+func (t *pullTracker) update(p PullProgress) bool {
+	l, ok := t.layers[p.Layer]
+	if !ok {
+		l = &layerProgress{}
+		t.layers[p.Layer] = l
+	}
+	if p.Total > 0 {
+		l.total = p.Total
+	}
+	switch p.Status {
+	case "Downloading":
+		l.downloaded = p.Current
+	case "Download complete", "Verifying Checksum":
+		l.downloaded = l.total
+	case "Extracting":
+		l.downloaded = l.total
+		l.extracted = p.Current
+	case "Pull complete", "Already exists":
+		l.done = true
+	}
+
+	percent := pullStartPercent + int(t.fraction()*float64(pullEndPercent-pullStartPercent))
+	if percent <= t.percent {
+		return false
+	}
+	t.percent = percent
+	return true
+}
+
+func (t *pullTracker) fraction() float64 {
+	if len(t.layers) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, l := range t.layers {
+		switch {
+		case l.done:
+			sum++
+		case l.total > 0:
+			sum += float64(min(l.downloaded, l.total)+min(l.extracted, l.total)) / float64(2*l.total)
+		}
+	}
+	return sum / float64(len(t.layers))
+}
+
+// message summarises the pull, e.g. "Pulling image: 2/5 layers, 12.3 MB downloaded".
+func (t *pullTracker) message() string {
+	done := 0
+	var downloaded int64
+	for _, l := range t.layers {
+		if l.done {
+			done++
+		}
+		downloaded += l.downloaded
+	}
+	return fmt.Sprintf("Pulling image: %d/%d layers, %.1f MB downloaded", done, len(t.layers), float64(downloaded)/(1<<20))
+}
+
+func pullTimeout() time.Duration {
+	if config.EnvConfig == nil || config.EnvConfig.PullTimeoutSeconds <= 0 {
+		return defaultPullTimeout
+	}
+	return time.Duration(config.EnvConfig.PullTimeoutSeconds) * time.Second
+}
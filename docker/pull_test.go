@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPullTracker_MapsLayersToPercent(t *testing.T) {
+	tracker := newPullTracker()
+
+	steps := []struct {
+		progress PullProgress
+		percent  int
+	}{
+		{PullProgress{Layer: "a", Status: "Pulling fs layer"}, 30},
+		{PullProgress{Layer: "b", Status: "Already exists"}, 40},
+		{PullProgress{Layer: "a", Status: "Downloading", Current: 50, Total: 100}, 42},
+		{PullProgress{Layer: "a", Status: "Download complete"}, 45},
+		{PullProgress{Layer: "a", Status: "Extracting", Current: 100, Total: 100}, 50},
+		{PullProgress{Layer: "a", Status: "Pull complete"}, 50},
+	}
+	for _, step := range steps {
+		tracker.update(step.progress)
+		if tracker.percent != step.percent {
+			t.Fatalf("after %+v expected %d%%, got %d%%", step.progress, step.percent, tracker.percent)
+		}
+	}
+	if msg := tracker.message(); msg != "Pulling image: 2/2 layers, 0.0 MB downloaded" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
+
+func TestStartServerWithProgress_ReportsPullProgress(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.PullProgress = []PullProgress{
+		{Layer: "a", Status: "Downloading", Current: 1 << 20, Total: 2 << 20},
+		{Layer: "a", Status: "Pull complete"},
+	}
+	captureCreatedContainers(t)
+	stubProbe(t, nil)
+
+	StartServerWithProgress("req-pull-progress", "FTP", ServerConfiguration{})
+	events := collectEvents(t, "req-pull-progress")
+
+	if !hasEvent(events, "Pulling image: 0/1 layers, 1.0 MB downloaded") || !hasEvent(events, "Pulling image: 1/1 layers, 1.0 MB downloaded") {
+		t.Fatalf("expected layer progress in progress stream, got %+v", events)
+	}
+	for _, ev := range events {
+		if strings.HasPrefix(ev.Message, "Pulling image") && (ev.Percent < 30 || ev.Percent > 50) {
+			t.Fatalf("pull progress outside of 30-50%%: %+v", ev)
+		}
+	}
+}
+
+func TestCancelStart_AbortsPull(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	pulling := make(chan struct{})
+	rt.PullFn = func(ctx context.Context, image string) error {
+		close(pulling)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		StartServerWithProgress("req-cancel", "FTP", ServerConfiguration{})
+		close(done)
+	}()
+	<-pulling
+	if !CancelStart("req-cancel") {
+		t.Fatal("expected running start request to be cancelled")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("start request did not stop after cancellation")
+	}
+
+	events := collectEvents(t, "req-cancel")
+	if last := events[len(events)-1]; !last.Error || last.Message != "pull failed: start cancelled" {
+		t.Fatalf("unexpected final event: %+v", last)
+	}
+	if len(rt.Containers) != 0 || len(*created) != 0 {
+		t.Fatalf("expected no container after cancellation")
+	}
+	if CancelStart("req-cancel") {
+		t.Fatal("finished start request must not be cancellable")
+	}
+}
+
+func TestCancelStart_AbortsBuild(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	useCustomImageDir(t, "simple-test-server-custom-mqtt")
+	building := make(chan struct{})
+	rt.BuildFn = func(ctx context.Context, opts BuildOptions) error {
+		close(building)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		StartServerWithProgress("req-cancel-build", "MQTT", ServerConfiguration{})
+		close(done)
+	}()
+	<-building
+	if !CancelStart("req-cancel-build") {
+		t.Fatal("expected running start request to be cancelled")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("start request did not stop after cancellation")
+	}
+
+	events := collectEvents(t, "req-cancel-build")
+	if last := events[len(events)-1]; !last.Error || last.Message != "build failed: start cancelled" {
+		t.Fatalf("unexpected final event: %+v", last)
+	}
+	if len(rt.Containers) != 0 || len(*created) != 0 {
+		t.Fatalf("expected no container after cancellation")
+	}
+}
@@ -0,0 +1,218 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+	"github.com/tim0-12432/simple-test-server/progress"
+)
+
+// probeHost is the host on which published container ports are probed.
+var probeHost = "localhost"
+
+// readinessPollInterval is the delay between two readiness probes.
+var readinessPollInterval = 500 * time.Millisecond
+
+// probeFn is a variable so tests can replace the network probes.
+var probeFn = probe
+
+// waitUntilReady polls the readiness check of a started container until it succeeds or times out.
+func waitUntilReady(ctx context.Context, reqId string, container *dtos.Container, check *servers.ReadinessCheck) error {
+	if check == nil {
+		return nil
+	}
+
+	timeout := check.GetTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	lastReport := time.Time{}
+	attempt := 0
+	for {
+		attempt++
+		err := probeFn(ctx, check, container)
+		if err == nil {
+			return nil
+		}
+
+		if time.Since(lastReport) >= 2*time.Second {
+			lastReport = time.Now()
+			percent := 85 + int(14*time.Since(start)/timeout)
+			if percent > 99 {
+				percent = 99
+			}
+			progress.Default.Send(reqId, progress.Event{Percent: percent, Message: fmt.Sprintf("Waiting for %s readiness (attempt %d)", check.Kind, attempt), Error: false})
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return ctx.Err()
+			}
+			return fmt.Errorf("not ready after %s: %w", timeout, err)
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
+
+// probe runs a single readiness check against the container.
+func probe(ctx context.Context, check *servers.ReadinessCheck, container *dtos.Container) error {
+	if check.Kind == servers.ReadinessLog {
+		return probeLogs(ctx, container.Name, check.Pattern)
+	}
+
+	address, err := probeAddress(container, check.Port)
+	if err != nil {
+		return err
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	switch check.Kind {
+	case servers.ReadinessTCP:
+		conn, err := dial(probeCtx, address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case servers.ReadinessHTTP:
+		return probeHTTP(probeCtx, "http://"+address+check.Path)
+	case servers.ReadinessMQTT:
+		return probeMQTT(probeCtx, address)
+	case servers.ReadinessSMTP:
+		return probeSMTP(probeCtx, address)
+	default:
+		return fmt.Errorf("unknown readiness check %q", check.Kind)
+	}
+}
+
+// probeAddress returns the address of containerPort; inside a container it is probed by name.
+func probeAddress(container *dtos.Container, containerPort int) (string, error) {
+	if selfContainerFn() != "" {
+		return net.JoinHostPort(container.Name, strconv.Itoa(containerPort)), nil
+	}
+	hostPort, ok := container.Ports[containerPort]
+	if !ok {
+		return "", fmt.Errorf("port %d is not published", containerPort)
+	}
+	return net.JoinHostPort(probeHost, strconv.Itoa(hostPort)), nil
+}
+
+func dial(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+func probeHTTP(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// mqttConnectPacket is an MQTT 3.1.1 CONNECT with clean session, a 10s keep alive
+// and the client id "sts-ready".
+var mqttConnectPacket = []byte{
+	0x10, 0x15, // CONNECT, remaining length 21
+	0x00, 0x04, 'M', 'Q', 'T', 'T', // protocol name
+	0x04,       // protocol level 3.1.1
+	0x02,       // clean session
+	0x00, 0x0a, // keep alive
+	0x00, 0x09, 's', 't', 's', '-', 'r', 'e', 'a', 'd', 'y',
+}
+
+// probeMQTT succeeds once the broker answers with a CONNACK, regardless of its return code.
+func probeMQTT(ctx context.Context, address string) error {
+	conn, err := dial(ctx, address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(mqttConnectPacket); err != nil {
+		return err
+	}
+	ack := make([]byte, 4)
+	if _, err := conn.Read(ack[:1]); err != nil {
+		return fmt.Errorf("no CONNACK: %w", err)
+	}
+	if ack[0] != 0x20 {
+		return fmt.Errorf("unexpected packet type 0x%02x", ack[0])
+	}
+	// be polite and disconnect
+	_, _ = conn.Write([]byte{0xe0, 0x00})
+	return nil
+}
+
+// probeSMTP succeeds once the server sends a 220 greeting.
+func probeSMTP(ctx context.Context, address string) error {
+	conn, err := dial(ctx, address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	banner, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no banner: %w", err)
+	}
+	if !strings.HasPrefix(banner, "220") {
+		return fmt.Errorf("unexpected banner %q", strings.TrimSpace(banner))
+	}
+	_, _ = conn.Write([]byte("QUIT\r\n"))
+	return nil
+}
+
+// probeLogs succeeds once any log line of the container matches pattern.
+func probeLogs(ctx context.Context, containerName string, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid log pattern: %w", err)
+	}
+
+	rc, err := Runtime.Logs(ctx, containerName, LogsOptions{})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineLen)
+	for scanner.Scan() {
+		if re.MatchString(scanner.Text()) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return err
+	}
+	return fmt.Errorf("no log line matches %q yet", pattern)
+}
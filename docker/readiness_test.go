@@ -0,0 +1,142 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// serveTCP accepts connections on a local port and hands each to handle.
+func serveTCP(t *testing.T, handle func(conn net.Conn)) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func probeLocal(t *testing.T, check *servers.ReadinessCheck, hostPort int) error {
+	t.Helper()
+	old := probeHost
+	probeHost = "127.0.0.1"
+	defer func() { probeHost = old }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return probe(ctx, check, &dtos.Container{Name: "probe", Ports: map[int]int{check.Port: hostPort}})
+}
+
+func TestProbe_TCP(t *testing.T) {
+	port := serveTCP(t, func(conn net.Conn) {})
+	if err := probeLocal(t, &servers.ReadinessCheck{Kind: servers.ReadinessTCP, Port: 21}, port); err != nil {
+		t.Fatalf("expected tcp probe to succeed, got %v", err)
+	}
+}
+
+func TestProbe_ContainerPortInsideContainer(t *testing.T) {
+	old := selfContainerFn
+	selfContainerFn = func() string { return "simple-test-server" }
+	defer func() { selfContainerFn = old }()
+	port := serveTCP(t, func(conn net.Conn) {})
+	check := &servers.ReadinessCheck{Kind: servers.ReadinessTCP, Port: port}
+
+	// the container port is dialled by container name, the published host port is ignored
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := probe(ctx, check, &dtos.Container{Name: "127.0.0.1", Ports: map[int]int{port: 1}}); err != nil {
+		t.Fatalf("expected tcp probe of the container port to succeed, got %v", err)
+	}
+	if err := probe(ctx, check, &dtos.Container{Name: "127.0.0.1"}); err != nil {
+		t.Fatalf("unpublished ports are reachable on the network, got %v", err)
+	}
+}
+
+func TestProbe_HTTP(t *testing.T) {
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	port, _ := strconv.Atoi(srv.URL[len("http://127.0.0.1:"):])
+	check := &servers.ReadinessCheck{Kind: servers.ReadinessHTTP, Port: 80, Path: "/"}
+
+	if err := probeLocal(t, check, port); err != nil {
+		t.Fatalf("a 404 still means the server is up, got %v", err)
+	}
+	status = http.StatusBadGateway
+	if err := probeLocal(t, check, port); err == nil {
+		t.Fatalf("expected 502 to fail the probe")
+	}
+}
+
+func TestProbe_MQTT(t *testing.T) {
+	port := serveTCP(t, func(conn net.Conn) {
+		buf := make([]byte, len(mqttConnectPacket))
+		if _, err := conn.Read(buf); err != nil || buf[0] != 0x10 {
+			return
+		}
+		conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+	})
+	if err := probeLocal(t, &servers.ReadinessCheck{Kind: servers.ReadinessMQTT, Port: 1883}, port); err != nil {
+		t.Fatalf("expected mqtt probe to succeed, got %v", err)
+	}
+}
+
+func TestProbe_SMTP(t *testing.T) {
+	banner := "220 mailhog ESMTP\r\n"
+	port := serveTCP(t, func(conn net.Conn) {
+		conn.Write([]byte(banner))
+	})
+	check := &servers.ReadinessCheck{Kind: servers.ReadinessSMTP, Port: 1025}
+	if err := probeLocal(t, check, port); err != nil {
+		t.Fatalf("expected smtp probe to succeed, got %v", err)
+	}
+	banner = "421 not yet\r\n"
+	if err := probeLocal(t, check, port); err == nil {
+		t.Fatalf("expected non-220 banner to fail the probe")
+	}
+}
+
+func TestProbe_UnpublishedPort(t *testing.T) {
+	ctx := context.Background()
+	err := probe(ctx, &servers.ReadinessCheck{Kind: servers.ReadinessTCP, Port: 21}, &dtos.Container{Ports: map[int]int{}})
+	if err == nil {
+		t.Fatalf("expected error for unpublished port")
+	}
+}
+
+func TestProbe_Log(t *testing.T) {
+	rt := useFakeRuntime(t)
+	c := rt.AddContainer("otel", "otel", "grafana/otel-lgtm")
+	check := &servers.ReadinessCheck{Kind: servers.ReadinessLog, Pattern: "Everything is ready"}
+
+	c.Logs = []string{"starting grafana"}
+	if err := probe(context.Background(), check, &dtos.Container{Name: "otel"}); err == nil {
+		t.Fatalf("expected log probe to fail before the pattern appears")
+	}
+	c.Logs = append(c.Logs, "The OpenTelemetry collector and the Grafana LGTM stack are up and running. Everything is ready.")
+	if err := probe(context.Background(), check, &dtos.Container{Name: "otel"}); err != nil {
+		t.Fatalf("expected log probe to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package docker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+// listContainerRecordsFn, updateContainerFn and updateContainerStateFn are variables so
+// tests can run the reconciler without PocketBase.
+var (
+	listContainerRecordsFn = services.ListContainers
+	updateContainerFn      = services.UpdateContainer
+	updateContainerStateFn = services.UpdateContainerState
+)
+
+var (
+	// reconcileInterval is how often the records are fully compared with the runtime,
+	// in case events were missed.
+	reconcileInterval = time.Minute
+	// eventsRetryDelay is the pause before reconnecting to a broken event stream.
+	eventsRetryDelay = 5 * time.Second
+	// orphanGracePeriod protects containers that were just created and whose record
+	// has not been written yet from being adopted as orphans.
+	orphanGracePeriod = time.Minute
+)
+
+// StartReconciler fails the starts of containers and environments interrupted by the last
+// shutdown and keeps the containers collection in sync with the runtime until ctx is
+// cancelled. Records of earlier runs are kept, the reconcile discards only those whose
+// container is gone.
+func StartReconciler(ctx context.Context) {
+	failInterruptedStarts()
+	failInterruptedEnvironmentStarts()
+	go WatchContainers(ctx)
+}
+
+// failInterruptedStarts marks the records that are still starting as failed, since no start
+// survives a restart of the application.
+func failInterruptedStarts() {
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		log.Printf("List containers: %v", err)
+		return
+	}
+	for _, record := range records {
+		if record.Status == dtos.Starting {
+			log.Printf("Start of container %s was interrupted, marking it as failed", record.Name)
+			record.Status = dtos.Failed
+			saveRecord(record)
+		}
+	}
+}
+
+// WatchContainers reconciles once and then applies runtime events of managed containers
+// to their records. The event stream is reopened when it breaks.
+func WatchContainers(ctx context.Context) {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := Reconcile(ctx); err != nil {
+			log.Printf("Reconcile containers: %v", err)
+		}
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		events, errs := Runtime.Events(streamCtx, managedLabels())
+	stream:
+		for {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case ev, ok := <-events:
+				if !ok {
+					break stream
+				}
+				handleContainerEvent(ev)
+			case err := <-errs:
+				log.Printf("Container event stream: %v", err)
+				break stream
+			case <-ticker.C:
+				if err := Reconcile(ctx); err != nil {
+					log.Printf("Reconcile containers: %v", err)
+				}
+			}
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryDelay):
+		}
+	}
+}
+
+// Reconcile compares all container records with the managed containers known to the runtime.
+// Records whose container is gone are discarded and flagged as orphaned; managed containers
+// without a live record are recorded as orphaned so they show up and can be removed.
+func Reconcile(ctx context.Context) error {
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		return err
+	}
+	containers, err := Runtime.ListContainers(ctx, managedLabels())
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]ContainerInfo, len(containers))
+	for _, c := range containers {
+		byID[c.ID] = c
+	}
+	known := make(map[string]*dtos.Container, len(records))
+
+	for _, record := range records {
+		known[record.ID] = record
+		info, exists := byID[record.ID]
+		if record.Status == dtos.Discarded {
+			if exists && info.State.Running && !record.Orphaned {
+				log.Printf("Container %s is running but its record is discarded", record.Name)
+				record.Orphaned = true
+				saveRecord(record)
+			}
+			continue
+		}
+		if !exists {
+			log.Printf("Container %s no longer exists, discarding its record", record.Name)
+			markGone(record, time.Now())
+			continue
+		}
+		applyContainerState(ctx, record, info)
+	}
+
+	for _, info := range containers {
+		if _, ok := known[info.ID]; !ok {
+			adoptContainer(ctx, info)
+		}
+	}
+	return nil
+}
+
+// applyContainerState updates record from the runtime state and saves it when it changed.
+func applyContainerState(ctx context.Context, record *dtos.Container, info ContainerInfo) {
+	before := *record
+	copyContainerState(ctx, record, info)
+	if before.Status != record.Status || before.ExitCode != record.ExitCode ||
+		before.FinishedAt != record.FinishedAt || before.Orphaned != record.Orphaned {
+		saveRecord(record)
+	}
+}
+
+// copyContainerState sets status, exit code and finish time of record from the runtime state.
+// A starting container stays starting until the runner has seen it become ready.
+func copyContainerState(ctx context.Context, record *dtos.Container, info ContainerInfo) {
+	switch {
+	case info.State.Paused:
+		record.Status = dtos.Paused
+	case info.State.Running:
+		if record.Status != dtos.Starting {
+			record.Status = dtos.Running
+		}
+	default:
+		// list results carry no exit code, so look at the stopped container in detail
+		if full, err := Runtime.InspectContainer(ctx, info.ID); err == nil {
+			info = *full
+		}
+		record.ExitCode = info.State.ExitCode
+		if !info.State.FinishedAt.IsZero() {
+			record.FinishedAt = info.State.FinishedAt.UnixMilli()
+		}
+		record.Status = exitStatus(record.Status, record.ExitCode)
+	}
+}
+
+// adoptContainer records a managed container the database does not know about.
+func adoptContainer(ctx context.Context, info ContainerInfo) {
+	full, err := Runtime.InspectContainer(ctx, info.ID)
+	if err != nil {
+		return
+	}
+	if time.Since(full.State.StartedAt) < orphanGracePeriod && full.State.Running {
+		return
+	}
+
+	log.Printf("Container %s is managed but has no record, adding it as orphaned", full.Name)
+	record := &dtos.Container{
+		ID:          full.ID,
+		Name:        full.Name,
+		Image:       full.Image,
+		CreatedAt:   full.State.StartedAt.UnixMilli(),
+		Environment: map[string]string{},
+		Ports:       map[int]int{},
+		Volumes:     map[string]string{},
+		Networks:    []string{},
+		Type:        full.Labels[ServerTypeLabel],
+		Orphaned:    true,
+	}
+	copyContainerState(ctx, record, *full)
+	if _, err := createContainerFn(record); err != nil {
+		log.Printf("Failed to store orphaned container %s: %v", full.Name, err)
+	}
+}
+
+// handleContainerEvent applies a single runtime event to the matching record.
+func handleContainerEvent(ev ContainerEvent) {
+	record, err := getContainerFn(ev.ID)
+	if err != nil {
+		// containers that were just created have no record yet; the next reconcile picks them up
+		return
+	}
+
+	switch ev.Action {
+	case "start", "restart":
+		if record.Status == dtos.Running || record.Status == dtos.Starting {
+			return
+		}
+		record.Status = dtos.Running
+	case "pause":
+		if record.Status != dtos.Running {
+			return
+		}
+		record.Status = dtos.Paused
+	case "unpause":
+		if record.Status != dtos.Paused {
+			return
+		}
+		record.Status = dtos.Running
+	case "die":
+		if record.Status == dtos.Discarded {
+			return
+		}
+		record.ExitCode = ev.ExitCode
+		record.FinishedAt = ev.Time.UnixMilli()
+		record.Status = exitStatus(record.Status, ev.ExitCode)
+	case "destroy":
+		if record.Status == dtos.Discarded {
+			return
+		}
+		log.Printf("Container %s was removed outside of the application", record.Name)
+		markGone(record, ev.Time)
+		return
+	default:
+		return
+	}
+	saveRecord(record)
+}
+
+// markGone discards the record of a container that disappeared from the runtime.
+func markGone(record *dtos.Container, at time.Time) {
+	record.Status = dtos.Discarded
+	record.Orphaned = true
+	if record.FinishedAt == 0 {
+		record.FinishedAt = at.UnixMilli()
+	}
+	saveRecord(record)
+}
+
+// exitStatus returns the status of a container that exited while its record had status current.
+func exitStatus(current dtos.Status, exitCode int) dtos.Status {
+	switch {
+	case current == dtos.Stopped || current == dtos.Starting:
+		return current
+	case exitCode != 0:
+		return dtos.Failed
+	default:
+		return dtos.Stopped
+	}
+}
+
+// saveRecord writes the runtime state of record. Status changes written since the record was
+// read are validated against rather than overwritten.
+func saveRecord(record *dtos.Container) {
+	state := dtos.ContainerState{Status: record.Status, ExitCode: record.ExitCode, FinishedAt: record.FinishedAt, Orphaned: record.Orphaned}
+	if err := updateContainerStateFn(record.ID, state); err != nil {
+		log.Printf("Failed to update container %s: %v", record.Name, err)
+	}
+}
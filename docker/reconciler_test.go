@@ -0,0 +1,262 @@
+package docker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+// fakeRecords replaces the PocketBase container records used by the reconciler.
+type fakeRecords struct {
+	mu      sync.Mutex
+	records map[string]*dtos.Container
+}
+
+func useFakeRecords(t *testing.T, records ...*dtos.Container) *fakeRecords {
+	t.Helper()
+	f := &fakeRecords{records: map[string]*dtos.Container{}}
+	for _, r := range records {
+		f.records[r.ID] = r
+	}
+
+	oldList, oldGet, oldUpdate, oldCreate := listContainerRecordsFn, getContainerFn, updateContainerFn, createContainerFn
+	oldStatus, oldState := updateContainerStatusFn, updateContainerStateFn
+	listContainerRecordsFn = func() ([]*dtos.Container, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		out := []*dtos.Container{}
+		for _, r := range f.records {
+			c := *r
+			out = append(out, &c)
+		}
+		return out, nil
+	}
+	getContainerFn = func(id string) (*dtos.Container, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		r, ok := f.records[id]
+		if !ok {
+			return nil, ErrContainerNotFound
+		}
+		c := *r
+		return &c, nil
+	}
+	updateContainerFn = func(id string, c *dtos.Container) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.records[id] = c
+		return nil
+	}
+	createContainerFn = func(c *dtos.Container) (string, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.records[c.ID] = c
+		return c.ID, nil
+	}
+	updateContainerStatusFn = func(id string, status dtos.Status) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		r, ok := f.records[id]
+		if !ok {
+			return services.ErrContainerNotFound
+		}
+		if err := services.ValidateTransition(r.Status, status); err != nil {
+			return err
+		}
+		r.Status = status
+		return nil
+	}
+	updateContainerStateFn = func(id string, state dtos.ContainerState) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		r, ok := f.records[id]
+		if !ok {
+			return services.ErrContainerNotFound
+		}
+		if r.Status != state.Status {
+			if err := services.ValidateTransition(r.Status, state.Status); err != nil {
+				return err
+			}
+		}
+		c := *r
+		c.Status, c.ExitCode, c.FinishedAt, c.Orphaned = state.Status, state.ExitCode, state.FinishedAt, state.Orphaned
+		f.records[id] = &c
+		return nil
+	}
+	t.Cleanup(func() {
+		listContainerRecordsFn, getContainerFn, updateContainerFn, createContainerFn = oldList, oldGet, oldUpdate, oldCreate
+		updateContainerStatusFn, updateContainerStateFn = oldStatus, oldState
+	})
+	return f
+}
+
+func (f *fakeRecords) get(id string) *dtos.Container {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.records[id]
+}
+
+func TestReconcile_DiscardsMissingContainers(t *testing.T) {
+	useFakeRuntime(t)
+	records := useFakeRecords(t, &dtos.Container{ID: "gone", Name: "gone", Status: dtos.Running})
+
+	if err := Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := records.get("gone")
+	if r.Status != dtos.Discarded || !r.Orphaned || r.FinishedAt == 0 {
+		t.Fatalf("expected orphaned discarded record, got %+v", r)
+	}
+}
+
+func TestReconcile_RecordsExitCode(t *testing.T) {
+	rt := useFakeRuntime(t)
+	c := rt.AddContainer("crashed", "crashed", "nginx")
+	finished := time.Now().Add(-time.Minute)
+	c.Info.State = ContainerState{Status: "exited", ExitCode: 2, FinishedAt: finished}
+	records := useFakeRecords(t, &dtos.Container{ID: "crashed", Name: "crashed", Status: dtos.Running})
+
+	if err := Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := records.get("crashed")
+	if r.Status != dtos.Failed || r.ExitCode != 2 || r.FinishedAt != finished.UnixMilli() || r.Orphaned {
+		t.Fatalf("unexpected record %+v", r)
+	}
+}
+
+func TestContainerEvent_KeepsConcurrentDiscard(t *testing.T) {
+	useFakeRuntime(t)
+	records := useFakeRecords(t, &dtos.Container{ID: "web", Name: "web", Status: dtos.Discarded, Image: "nginx"})
+	// the event handler read the record before it was discarded
+	getContainerFn = func(id string) (*dtos.Container, error) {
+		return &dtos.Container{ID: "web", Name: "web", Status: dtos.Running}, nil
+	}
+
+	handleContainerEvent(ContainerEvent{ID: "web", Action: "die", ExitCode: 137, Time: time.Now()})
+	if r := records.get("web"); r.Status != dtos.Discarded || r.ExitCode != 0 || r.Image != "nginx" {
+		t.Fatalf("expected the discarded record to be kept, got %+v", r)
+	}
+}
+
+func TestReconcile_KeepsStoppedAndStartingStatus(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("stopped", "stopped", "nginx").Info.State = ContainerState{Status: "exited", ExitCode: 143}
+	rt.AddContainer("starting", "starting", "nginx")
+	rt.AddContainer("paused", "paused", "nginx").Info.State = ContainerState{Status: "paused", Running: true, Paused: true}
+	records := useFakeRecords(t,
+		&dtos.Container{ID: "stopped", Name: "stopped", Status: dtos.Stopped},
+		&dtos.Container{ID: "starting", Name: "starting", Status: dtos.Starting},
+		&dtos.Container{ID: "paused", Name: "paused", Status: dtos.Running},
+	)
+
+	if err := Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := records.get("stopped"); r.Status != dtos.Stopped || r.ExitCode != 143 {
+		t.Fatalf("a stopped container must stay stopped, got %+v", r)
+	}
+	if r := records.get("starting"); r.Status != dtos.Starting {
+		t.Fatalf("a starting container must stay starting, got %+v", r)
+	}
+	if r := records.get("paused"); r.Status != dtos.Paused {
+		t.Fatalf("expected paused status, got %+v", r)
+	}
+}
+
+func TestStartup_KeepsRecordsOfExistingContainers(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("stopped", "stopped", "nginx").Info.State = ContainerState{Status: "exited", ExitCode: 143}
+	rt.AddContainer("paused", "paused", "nginx").Info.State = ContainerState{Status: "paused", Running: true, Paused: true}
+	rt.AddContainer("starting", "starting", "nginx")
+	records := useFakeRecords(t,
+		&dtos.Container{ID: "stopped", Name: "stopped", Status: dtos.Stopped},
+		&dtos.Container{ID: "paused", Name: "paused", Status: dtos.Paused},
+		&dtos.Container{ID: "starting", Name: "starting", Status: dtos.Starting},
+		&dtos.Container{ID: "interrupted", Name: "interrupted", Status: dtos.Starting},
+	)
+
+	failInterruptedStarts()
+	if err := Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := records.get("stopped"); r.Status != dtos.Stopped || r.Orphaned {
+		t.Fatalf("a stopped container must stay stopped, got %+v", r)
+	}
+	if r := records.get("paused"); r.Status != dtos.Paused {
+		t.Fatalf("a paused container must stay paused, got %+v", r)
+	}
+	if r := records.get("starting"); r.Status != dtos.Running {
+		t.Fatalf("an interrupted start of a running container must become running, got %+v", r)
+	}
+	if r := records.get("interrupted"); r.Status != dtos.Discarded {
+		t.Fatalf("an interrupted start without container must be discarded, got %+v", r)
+	}
+}
+
+func TestReconcile_AdoptsUntrackedContainers(t *testing.T) {
+	rt := useFakeRuntime(t)
+	old := rt.AddContainer("untracked", "untracked", "nginx")
+	old.Info.State.StartedAt = time.Now().Add(-time.Hour)
+	old.Info.Labels[ServerTypeLabel] = "WEB"
+	rt.AddContainer("fresh", "fresh", "nginx")
+	records := useFakeRecords(t)
+
+	if err := Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := records.get("untracked")
+	if r == nil || !r.Orphaned || r.Status != dtos.Running || r.Type != "WEB" {
+		t.Fatalf("expected untracked container to be adopted, got %+v", r)
+	}
+	if records.get("fresh") != nil {
+		t.Fatalf("containers inside the grace period must not be adopted")
+	}
+}
+
+func TestWatchContainers_AppliesEvents(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("web", "web", "nginx")
+	records := useFakeRecords(t, &dtos.Container{ID: "web", Name: "web", Status: dtos.Running})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchContainers(ctx)
+
+	waitFor := func(cond func(*dtos.Container) bool) *dtos.Container {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if r := records.get("web"); cond(r) {
+				return r
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("record did not reach expected state: %+v", records.get("web"))
+		return nil
+	}
+
+	// wait until the watcher has subscribed before emitting
+	for i := 0; i < 200; i++ {
+		rt.mu.Lock()
+		n := len(rt.subscribers)
+		rt.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rt.Emit(ContainerEvent{ID: "web", Action: "die", ExitCode: 137, Time: time.Now()})
+	waitFor(func(r *dtos.Container) bool { return r.Status == dtos.Failed && r.ExitCode == 137 })
+
+	rt.Emit(ContainerEvent{ID: "web", Action: "start", Time: time.Now()})
+	waitFor(func(r *dtos.Container) bool { return r.Status == dtos.Running })
+
+	rt.Emit(ContainerEvent{ID: "web", Action: "destroy", Time: time.Now()})
+	waitFor(func(r *dtos.Container) bool { return r.Status == dtos.Discarded && r.Orphaned })
+}
@@ -1,11 +1,14 @@
 package docker
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/tim0-12432/simple-test-server/db/dtos"
 	"github.com/tim0-12432/simple-test-server/docker/servers"
 	"github.com/tim0-12432/simple-test-server/progress"
 )
@@ -14,58 +17,235 @@ type ServerConfiguration struct {
 	Name  string            `json:"name"`
 	Ports []map[string]int  `json:"ports"`
 	Env   map[string]string `json:"env"`
+	// Volumes mounts named volumes; paths of the server type not listed here get managed volumes.
+	Volumes []VolumeSpec `json:"volumes,omitempty"`
+	// Network is an existing network to join instead of the default managed network.
+	Network string `json:"network,omitempty"`
+	// Aliases are DNS names of the container in its network in addition to the server name.
+	Aliases []string `json:"aliases,omitempty"`
+	// TTLMinutes is the lifetime of the container. 0 uses the configured default, a negative value never expires.
+	TTLMinutes int `json:"ttl_minutes,omitempty"`
+	// Limits override the default resource limits of the server type.
+	Limits *dtos.ResourceLimits `json:"limits,omitempty"`
+	// Snapshot starts the container from the image and volumes of a snapshot of the same type.
+	Snapshot string `json:"snapshot,omitempty"`
+	// Seed is a script loaded into the server once it is ready, e.g. SQL for database servers.
+	Seed string `json:"seed,omitempty"`
+}
+
+// startCancels holds the cancel functions of the start requests in progress.
+var (
+	startMu      sync.Mutex
+	startCancels = map[string]context.CancelFunc{}
+)
+
+// CancelStart aborts the start request reqId. It reports false if no such request is running.
+func CancelStart(reqId string) bool {
+	startMu.Lock()
+	defer startMu.Unlock()
+	cancel, ok := startCancels[reqId]
+	if ok {
+		cancel()
+	}
+	return ok
 }
 
 func StartServerWithProgress(reqId string, serverType string, config ServerConfiguration) {
 	progress.Default.New(reqId)
+	ctx, done := trackStart(reqId)
+	defer done()
+
+	if _, err := runServer(ctx, reqId, serverType, config); err != nil {
+		return
+	}
+
+	go func() {
+		time.Sleep(30 * time.Second)
+		progress.Default.Remove(reqId)
+	}()
+}
+
+// trackStart returns the context of the start request reqId, which CancelStart cancels.
+// The returned function must be called once the request is finished.
+func trackStart(reqId string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	startMu.Lock()
+	startCancels[reqId] = cancel
+	startMu.Unlock()
+	return ctx, func() {
+		startMu.Lock()
+		delete(startCancels, reqId)
+		startMu.Unlock()
+		cancel()
+	}
+}
+
+// runServer builds or pulls the image of serverType, runs the container and waits until it
+// is ready. Failures are sent to reqId as error events before they are returned.
+func runServer(ctx context.Context, reqId string, serverType string, config ServerConfiguration) (*dtos.Container, error) {
+	fail := func(percent int, msg string) error {
+		progress.Default.Send(reqId, progress.Event{Percent: percent, Message: msg, Error: true})
+		return errors.New(msg)
+	}
 	progress.Default.Send(reqId, progress.Event{Percent: 10, Message: "Starting", Error: false})
 
-	var server servers.ServerDefinition
-	switch serverType {
-	case "MQTT":
-		server = servers.MqttServer{}
-	case "WEB":
-		server = servers.WebServer{}
-	case "FTP":
-		server = servers.FtpServer{}
-	case "SMB":
-		server = servers.SmbServer{}
-	case "MAIL":
-		server = servers.MailServer{}
-	case "OTEL":
-		server = servers.OtelServer{}
-	default:
+	server, err := servers.GetServerByType(serverType)
+	if err != nil {
 		msg := fmt.Sprintf("Unknown server type: %s", serverType)
 		log.Print(msg)
-		progress.Default.Send(reqId, progress.Event{Percent: 100, Message: msg, Error: true})
+		return nil, fail(100, msg)
+	}
+	if err := validateServerConfig(server, config); err != nil {
+		return nil, fail(100, err.Error())
+	}
+
+	if config.Name != "" {
+		if err := CheckContainerName(ctx, config.Name); err != nil {
+			return nil, fail(100, err.Error())
+		}
 	}
 
-	if strings.Contains(server.GetImage(), "simple-test-server-custom-") {
+	image := server.GetImage()
+	releaseVolumes := func() {}
+	if config.Snapshot != "" {
+		progress.Default.Send(reqId, progress.Event{Percent: 30, Message: "Restoring snapshot " + config.Snapshot, Error: false})
+		snapshot, err := loadSnapshot(ctx, config.Snapshot, serverType)
+		if err != nil {
+			return nil, fail(50, fmt.Sprintf("restore failed: %v", err))
+		}
+		volumes, release, err := restoreSnapshotVolumes(ctx, snapshot, config.Volumes)
+		if err != nil {
+			return nil, fail(50, fmt.Sprintf("restore failed: %v", err))
+		}
+		image, config.Volumes, releaseVolumes = snapshot.Image, volumes, release
+		progress.Default.Send(reqId, progress.Event{Percent: 50, Message: "Snapshot restored", Error: false})
+	} else if server.GetBuildContext() != "" {
 		progress.Default.Send(reqId, progress.Event{Percent: 30, Message: "Building image", Error: false})
-		if err := BuildCustomDockerImage(server.GetImage()); err != nil {
-			progress.Default.Send(reqId, progress.Event{Percent: 50, Message: fmt.Sprintf("build failed: %v", err), Error: true})
-			return
+		output := func(line string) {
+			progress.Default.Send(reqId, progress.Event{Percent: 30, Message: line, Error: false})
+		}
+		if err := BuildCustomDockerImage(ctx, server.GetImage(), server.GetBuildContext(), output); err != nil {
+			if ctx.Err() != nil {
+				err = errors.New("start cancelled")
+			}
+			return nil, fail(50, fmt.Sprintf("build failed: %v", err))
 		}
 		progress.Default.Send(reqId, progress.Event{Percent: 50, Message: "Build successful", Error: false})
 	} else {
 		progress.Default.Send(reqId, progress.Event{Percent: 30, Message: "Pulling image", Error: false})
-		if err := CheckAndPullImage(server.GetImage()); err != nil {
-			progress.Default.Send(reqId, progress.Event{Percent: 50, Message: fmt.Sprintf("pull failed: %v", err), Error: true})
-			return
+		tracker := newPullTracker()
+		onProgress := func(p PullProgress) {
+			if tracker.update(p) {
+				progress.Default.Send(reqId, progress.Event{Percent: tracker.percent, Message: tracker.message(), Error: false})
+			}
+		}
+		if err := CheckAndPullImage(ctx, server.GetImage(), onProgress); err != nil {
+			if ctx.Err() != nil {
+				err = errors.New("start cancelled")
+			}
+			return nil, fail(50, fmt.Sprintf("pull failed: %v", err))
 		}
 		progress.Default.Send(reqId, progress.Event{Percent: 50, Message: "Pull successful", Error: false})
 	}
 
+	if ctx.Err() != nil {
+		releaseVolumes()
+		return nil, fail(50, "start cancelled")
+	}
+
 	progress.Default.Send(reqId, progress.Event{Percent: 80, Message: "Starting container", Error: false})
-	if err := RunContainer(config, serverType, server.GetImage(), server.GetName(), server.GetPorts(), server.GetEnv()); err != nil {
-		progress.Default.Send(reqId, progress.Event{Percent: 90, Message: fmt.Sprintf("run failed: %v", err), Error: true})
-		return
+	container, err := RunContainer(config, serverType, image, server.GetName(), server.GetPorts(), server.GetEnv(), server.GetVolumes(), server.GetLimits())
+	if err != nil {
+		releaseVolumes()
+		return nil, fail(90, fmt.Sprintf("run failed: %v", err))
+	}
+	if len(container.Ports) > 0 {
+		progress.Default.Send(reqId, progress.Event{Percent: 82, Message: "Ports: " + formatPorts(container.Ports), Error: false})
+	}
+
+	if err := awaitReadiness(ctx, reqId, container, server.GetReadiness()); err != nil {
+		return container, err
+	}
+	if config.Seed != "" {
+		progress.Default.Send(reqId, progress.Event{Percent: 95, Message: "Loading seed", Error: false})
+		if err := seederFor(server)(ctx, container, config.Seed); err != nil {
+			updateContainerStatusFn(container.ID, dtos.Failed)
+			return container, fail(100, fmt.Sprintf("seed failed: %v", err))
+		}
 	}
 
 	progress.Default.Send(reqId, progress.Event{Percent: 100, Message: "Started", Error: false})
+	return container, nil
+}
 
-	go func() {
-		time.Sleep(30 * time.Second)
-		progress.Default.Remove(reqId)
-	}()
+// ValidateConfiguration checks config against the schema and the default limits of
+// serverType, so invalid requests are rejected before a server is started. Invalid env
+// variables and ports are reported as a *servers.ConfigError.
+func ValidateConfiguration(serverType string, config ServerConfiguration) error {
+	server, err := servers.GetServerByType(serverType)
+	if err != nil {
+		return err
+	}
+	if err := validateServerConfig(server, config); err != nil {
+		return err
+	}
+	return ValidateLimits(server.Limits, config.Limits)
+}
+
+// validateServerConfig checks config against the schema of server and reports a seed for a
+// server type that cannot load one as a field error.
+func validateServerConfig(server *servers.ServerInformation, config ServerConfiguration) error {
+	err := server.ValidateConfig(config.Env, config.Ports)
+	if config.Seed == "" || seederFor(server) != nil {
+		return err
+	}
+	fields := map[string]string{}
+	var configErr *servers.ConfigError
+	if errors.As(err, &configErr) {
+		fields = configErr.Fields
+	}
+	fields["seed"] = "is not supported by " + server.Type
+	return &servers.ConfigError{Fields: fields}
+}
+
+// loadSnapshot returns the snapshot name if it was taken of a serverType container and its
+// image still exists.
+func loadSnapshot(ctx context.Context, name string, serverType string) (*dtos.Snapshot, error) {
+	snapshot, err := GetSnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Type != serverType {
+		return nil, fmt.Errorf("snapshot %s was taken of a %s server", name, snapshot.Type)
+	}
+	exists, err := Runtime.ImageExists(ctx, snapshot.Image)
+	if err != nil {
+		return nil, fmt.Errorf("inspect image failed: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: snapshot image %s", ErrImageNotFound, snapshot.Image)
+	}
+	return snapshot, nil
+}
+
+// awaitReadiness waits for the readiness check of a started container and marks it as
+// running, or as failed if it does not become ready.
+func awaitReadiness(ctx context.Context, reqId string, container *dtos.Container, check *servers.ReadinessCheck) error {
+	progress.Default.Send(reqId, progress.Event{Percent: 85, Message: "Waiting for server to become ready", Error: false})
+	if err := waitUntilReady(ctx, reqId, container, check); err != nil {
+		updateContainerStatusFn(container.ID, dtos.Failed)
+		if ctx.Err() != nil {
+			log.Printf("Start of container %s was cancelled while waiting for readiness", container.Name)
+			err = errors.New("start cancelled")
+		} else {
+			log.Printf("Container %s did not become ready: %v", container.Name, err)
+			err = fmt.Errorf("readiness check failed: %v", err)
+		}
+		progress.Default.Send(reqId, progress.Event{Percent: 100, Message: err.Error(), Error: true})
+		return err
+	}
+	if err := updateContainerStatusFn(container.ID, dtos.Running); err != nil {
+		log.Printf("Failed to mark container %s as running: %v", container.Name, err)
+	}
+	return nil
 }
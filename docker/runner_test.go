@@ -0,0 +1,306 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+	"github.com/tim0-12432/simple-test-server/progress"
+)
+
+// useFakeRuntime installs a FakeRuntime for the duration of the test.
+func useFakeRuntime(t *testing.T) *FakeRuntime {
+	t.Helper()
+	old := Runtime
+	rt := NewFakeRuntime()
+	Runtime = rt
+	t.Cleanup(func() { Runtime = old })
+	return rt
+}
+
+// captureCreatedContainers replaces the PocketBase writes made by RunContainer and the
+// status updates that follow it.
+func captureCreatedContainers(t *testing.T) *[]*dtos.Container {
+	t.Helper()
+	stubHostPorts(t)
+	oldCreate, oldUpdate := createContainerFn, updateContainerStatusFn
+	created := []*dtos.Container{}
+	createContainerFn = func(c *dtos.Container) (string, error) {
+		stored := *c
+		created = append(created, &stored)
+		return c.ID, nil
+	}
+	updateContainerStatusFn = func(id string, status dtos.Status) error {
+		for _, c := range created {
+			if c.ID == id {
+				c.Status = status
+			}
+		}
+		return nil
+	}
+	t.Cleanup(func() { createContainerFn, updateContainerStatusFn = oldCreate, oldUpdate })
+	return &created
+}
+
+// stubProbe replaces the readiness probes with one returning err.
+func stubProbe(t *testing.T, err error) {
+	t.Helper()
+	oldProbe, oldInterval := probeFn, readinessPollInterval
+	probeFn = func(ctx context.Context, check *servers.ReadinessCheck, container *dtos.Container) error {
+		return err
+	}
+	readinessPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { probeFn, readinessPollInterval = oldProbe, oldInterval })
+}
+
+// collectEvents drains the progress channel of reqId.
+func collectEvents(t *testing.T, reqId string) []progress.Event {
+	t.Helper()
+	ch, ok := progress.Default.Get(reqId)
+	if !ok {
+		t.Fatalf("no progress channel for %s", reqId)
+	}
+	events := []progress.Event{}
+	for {
+		select {
+		case ev := <-ch:
+			events = append(events, ev)
+		case <-time.After(50 * time.Millisecond):
+			return events
+		}
+	}
+}
+
+func hasEvent(events []progress.Event, message string) bool {
+	for _, ev := range events {
+		if ev.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStartServerWithProgress_PullsAndRuns(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	stubProbe(t, nil)
+
+	StartServerWithProgress("req-mail", "MAIL", ServerConfiguration{
+		Ports:      []map[string]int{{"18025": 8025}},
+		Env:        map[string]string{"MH_HOSTNAME": "test"},
+		TTLMinutes: 15,
+	})
+	events := collectEvents(t, "req-mail")
+
+	last := events[len(events)-1]
+	if last.Percent != 100 || last.Error || last.Message != "Started" {
+		t.Fatalf("unexpected final event: %+v (all: %+v)", last, events)
+	}
+	if !hasEvent(events, "Ports: 1025->1025, 8025->18025") {
+		t.Fatalf("expected port mapping in progress stream, got %+v", events)
+	}
+	if len(rt.Pulled) != 1 || rt.Pulled[0] != "mailhog/mailhog:latest" {
+		t.Fatalf("expected mailhog image to be pulled, got %v", rt.Pulled)
+	}
+	if len(rt.Containers) != 1 {
+		t.Fatalf("expected 1 container, got %d", len(rt.Containers))
+	}
+	for _, c := range rt.Containers {
+		if c.Spec.Ports[8025] != 18025 || c.Spec.Ports[1025] != 1025 {
+			t.Fatalf("unexpected port mapping: %v", c.Spec.Ports)
+		}
+		if c.Spec.Env["MH_HOSTNAME"] != "test" {
+			t.Fatalf("expected env from configuration, got %v", c.Spec.Env)
+		}
+		if c.Spec.Labels[ManagedByLabel] != ManagedByValue {
+			t.Fatalf("expected managed_by label, got %v", c.Spec.Labels)
+		}
+	}
+	if len(*created) != 1 || (*created)[0].Type != "MAIL" || (*created)[0].Status != dtos.Running {
+		t.Fatalf("unexpected stored containers: %+v", *created)
+	}
+	if expiry := (*created)[0].ExpiresAt; expiry < time.Now().Add(14*time.Minute).UnixMilli() || expiry > time.Now().Add(15*time.Minute).UnixMilli() {
+		t.Fatalf("expected expiry in 15 minutes, got %d", expiry)
+	}
+}
+
+func TestStartServerWithProgress_BuildsCustomImage(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.BuildOutput = []string{"Step 1/3 : FROM eclipse-mosquitto"}
+	captureCreatedContainers(t)
+	stubProbe(t, nil)
+	dir := useCustomImageDir(t, "simple-test-server-custom-mqtt")
+
+	StartServerWithProgress("req-mqtt", "MQTT", ServerConfiguration{})
+	events := collectEvents(t, "req-mqtt")
+
+	if last := events[len(events)-1]; last.Error || last.Percent != 100 {
+		t.Fatalf("unexpected final event: %+v", last)
+	}
+	if len(rt.Built) != 1 || rt.Built[0].Tag != "simple-test-server-custom-mqtt:latest" {
+		t.Fatalf("expected custom mqtt image build, got %+v", rt.Built)
+	}
+	if !hasEvent(events, "Step 1/3 : FROM eclipse-mosquitto") {
+		t.Fatalf("expected build output in progress stream, got %+v", events)
+	}
+	if rt.Built[0].ContextDir != filepath.Join(dir, "simple-test-server-custom-mqtt") {
+		t.Fatalf("unexpected build context: %s", rt.Built[0].ContextDir)
+	}
+	if len(rt.Pulled) != 0 {
+		t.Fatalf("custom images must not be pulled, got %v", rt.Pulled)
+	}
+}
+
+func TestStartServerWithProgress_PullFailure(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	rt.ErrPull = &RuntimeError{Op: "pull image", StatusCode: 404, Message: "manifest unknown", Err: ErrImageNotFound}
+
+	StartServerWithProgress("req-pull-fail", "FTP", ServerConfiguration{})
+	events := collectEvents(t, "req-pull-fail")
+
+	last := events[len(events)-1]
+	if !last.Error {
+		t.Fatalf("expected error event, got %+v", last)
+	}
+	if len(rt.Containers) != 0 || len(*created) != 0 {
+		t.Fatalf("no container should be started after a failed pull")
+	}
+}
+
+func TestAwaitReadiness_MarksContainerFailed(t *testing.T) {
+	stubProbe(t, errors.New("connection refused"))
+	oldUpdate := updateContainerStatusFn
+	statuses := map[string]dtos.Status{}
+	updateContainerStatusFn = func(id string, status dtos.Status) error {
+		statuses[id] = status
+		return nil
+	}
+	defer func() { updateContainerStatusFn = oldUpdate }()
+
+	progress.Default.New("req-not-ready")
+	container := &dtos.Container{ID: "c1", Name: "c1", Ports: map[int]int{1883: 1883}}
+	check := &servers.ReadinessCheck{Kind: servers.ReadinessMQTT, Port: 1883, TimeoutSeconds: 1}
+	if awaitReadiness(context.Background(), "req-not-ready", container, check) == nil {
+		t.Fatalf("expected readiness to fail")
+	}
+	events := collectEvents(t, "req-not-ready")
+
+	if statuses["c1"] != dtos.Failed {
+		t.Fatalf("expected container to be marked failed, got %v", statuses)
+	}
+	last := events[len(events)-1]
+	if !last.Error || last.Percent != 100 {
+		t.Fatalf("expected final error event, got %+v", last)
+	}
+}
+
+func TestAwaitReadiness_StopsWhenTheStartIsCancelled(t *testing.T) {
+	stubProbe(t, errors.New("connection refused"))
+	oldUpdate := updateContainerStatusFn
+	statuses := map[string]dtos.Status{}
+	updateContainerStatusFn = func(id string, status dtos.Status) error {
+		statuses[id] = status
+		return nil
+	}
+	defer func() { updateContainerStatusFn = oldUpdate }()
+
+	progress.Default.New("req-cancelled")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	container := &dtos.Container{ID: "c1", Name: "c1", Ports: map[int]int{9092: 9092}}
+	check := &servers.ReadinessCheck{Kind: servers.ReadinessTCP, Port: 9092, TimeoutSeconds: 90}
+
+	started := time.Now()
+	if awaitReadiness(ctx, "req-cancelled", container, check) == nil {
+		t.Fatalf("expected readiness to fail")
+	}
+	if time.Since(started) > 5*time.Second {
+		t.Fatalf("the wait must end with the start, took %s", time.Since(started))
+	}
+	events := collectEvents(t, "req-cancelled")
+	if last := events[len(events)-1]; !last.Error || last.Message != "start cancelled" {
+		t.Fatalf("expected a cancelled start, got %+v", last)
+	}
+	if statuses["c1"] != dtos.Failed {
+		t.Fatalf("expected container to be marked failed, got %v", statuses)
+	}
+}
+
+func TestStartServerWithProgress_UnknownType(t *testing.T) {
+	rt := useFakeRuntime(t)
+	captureCreatedContainers(t)
+
+	StartServerWithProgress("req-unknown", "NOPE", ServerConfiguration{})
+	events := collectEvents(t, "req-unknown")
+
+	if len(events) == 0 || !events[len(events)-1].Error {
+		t.Fatalf("expected error event for unknown type, got %+v", events)
+	}
+	if len(rt.Pulled) != 0 || len(rt.Built) != 0 || len(rt.Containers) != 0 {
+		t.Fatalf("runtime must not be used for unknown server types")
+	}
+}
+
+func TestRunContainer_NameConflict(t *testing.T) {
+	rt := useFakeRuntime(t)
+	captureCreatedContainers(t)
+	rt.Images["nginx"] = true
+	rt.AddContainer("existing", "taken", "nginx")
+
+	_, err := RunContainer(ServerConfiguration{Name: "taken"}, "WEB", "nginx", "web", []int{80}, nil, nil, nil)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+}
+
+func TestStopAllContainers_RemovesManagedOnly(t *testing.T) {
+	rt := useFakeRuntime(t)
+	oldUpdate := updateContainerStatusFn
+	discarded := []string{}
+	updateContainerStatusFn = func(id string, status dtos.Status) error {
+		discarded = append(discarded, id)
+		return nil
+	}
+	defer func() { updateContainerStatusFn = oldUpdate }()
+
+	rt.AddContainer("managed", "managed", "nginx")
+	rt.AddContainer("foreign", "foreign", "nginx").Info.Labels = map[string]string{}
+
+	if err := StopAllContainers(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := rt.Containers["managed"]; ok {
+		t.Fatalf("managed container should have been removed")
+	}
+	if _, ok := rt.Containers["foreign"]; !ok {
+		t.Fatalf("foreign container must not be removed")
+	}
+	if len(discarded) != 1 || discarded[0] != "managed" {
+		t.Fatalf("expected managed container to be discarded, got %v", discarded)
+	}
+}
+
+func TestStartServerWithProgress_RejectsInvalidConfiguration(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+
+	StartServerWithProgress("req-invalid", "FTP", ServerConfiguration{
+		Ports: []map[string]int{{"abc": 21}},
+		Env:   map[string]string{"FTP_PASS": ""},
+	})
+	events := collectEvents(t, "req-invalid")
+
+	last := events[len(events)-1]
+	if !last.Error || !strings.Contains(last.Message, "env.FTP_PASS: is required") || !strings.Contains(last.Message, "ports.abc: host port must be a number") {
+		t.Fatalf("expected field errors, got %+v", last)
+	}
+	if len(rt.Pulled) != 0 || len(*created) != 0 {
+		t.Fatal("nothing should be pulled or started for an invalid configuration")
+	}
+}
@@ -0,0 +1,305 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/config"
+)
+
+// ManagedByLabel and ManagedByValue mark every container started by this application.
+const (
+	ManagedByLabel = "managed_by"
+	ManagedByValue = "simple-test-server"
+)
+
+// ServerTypeLabel records the server type a managed container was started as.
+const ServerTypeLabel = "server_type"
+
+// defaultDockerHost is used when no DOCKER_HOST is configured.
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// ContainerRuntime is the set of operations the application needs from a container engine.
+// Implementations return the sentinel errors from types.go (wrapped in a *RuntimeError)
+// so callers can use errors.Is instead of inspecting engine output.
+type ContainerRuntime interface {
+	// Name returns a short identifier of the runtime, e.g. "docker".
+	Name() string
+	// Ping checks that the runtime is reachable.
+	Ping(ctx context.Context) error
+
+	ImageExists(ctx context.Context, image string) (bool, error)
+	InspectImage(ctx context.Context, image string) (*ImageInfo, error)
+	// PullImage pulls image and reports the per-layer progress to onProgress if set.
+	PullImage(ctx context.Context, image string, onProgress func(PullProgress)) error
+	BuildImage(ctx context.Context, opts BuildOptions) error
+	// CommitContainer creates an image from the filesystem of a container and returns the image id.
+	CommitContainer(ctx context.Context, id string, opts CommitOptions) (string, error)
+	RemoveImage(ctx context.Context, image string, force bool) error
+
+	// RunContainer creates and starts a container and returns its id.
+	RunContainer(ctx context.Context, spec ContainerSpec) (string, error)
+	RemoveContainer(ctx context.Context, id string, force bool) error
+	StartContainer(ctx context.Context, id string) error
+	// StopContainer sends SIGTERM and kills the container after timeout.
+	StopContainer(ctx context.Context, id string, timeout time.Duration) error
+	RestartContainer(ctx context.Context, id string, timeout time.Duration) error
+	PauseContainer(ctx context.Context, id string) error
+	UnpauseContainer(ctx context.Context, id string) error
+	InspectContainer(ctx context.Context, id string) (*ContainerInfo, error)
+	// ListContainers returns all containers (running or not) carrying the given labels.
+	ListContainers(ctx context.Context, labels map[string]string) ([]ContainerInfo, error)
+
+	// Logs returns the combined stdout/stderr of a container, following it with opts.Follow.
+	Logs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error)
+	// Exec runs cmd inside the container and waits for it to finish.
+	Exec(ctx context.Context, id string, cmd []string) (*ExecResult, error)
+	// AttachExec starts cmd inside the container with a TTY and returns the attached session.
+	AttachExec(ctx context.Context, id string, cmd []string) (ExecSession, error)
+	// CopyToContainer extracts the tar archive content into destDir inside the container.
+	CopyToContainer(ctx context.Context, id string, destDir string, content io.Reader) error
+
+	// ContainerStats returns a resource usage sample of a running container.
+	ContainerStats(ctx context.Context, id string) (*ContainerStats, error)
+
+	// Events streams lifecycle events of containers carrying the given labels until ctx is cancelled.
+	Events(ctx context.Context, labels map[string]string) (<-chan ContainerEvent, <-chan error)
+	// WaitContainer blocks until the container exits and returns its exit code.
+	WaitContainer(ctx context.Context, id string) (int, error)
+
+	CreateVolume(ctx context.Context, name string, labels map[string]string) (*VolumeInfo, error)
+	InspectVolume(ctx context.Context, name string) (*VolumeInfo, error)
+	// ListVolumes returns all volumes carrying the given labels.
+	ListVolumes(ctx context.Context, labels map[string]string) ([]VolumeInfo, error)
+	RemoveVolume(ctx context.Context, name string, force bool) error
+
+	CreateNetwork(ctx context.Context, name string, labels map[string]string) (*NetworkInfo, error)
+	InspectNetwork(ctx context.Context, name string) (*NetworkInfo, error)
+	// ListNetworks returns all networks carrying the given labels.
+	ListNetworks(ctx context.Context, labels map[string]string) ([]NetworkInfo, error)
+	RemoveNetwork(ctx context.Context, name string) error
+	// ConnectNetwork attaches a running container to a network under the given DNS aliases.
+	ConnectNetwork(ctx context.Context, network string, containerId string, aliases []string) error
+	DisconnectNetwork(ctx context.Context, network string, containerId string) error
+}
+
+// ContainerSpec describes a container to be created by RunContainer.
+type ContainerSpec struct {
+	Name   string
+	Image  string
+	Env    map[string]string
+	Ports  map[int]int // container port -> host port
+	Labels map[string]string
+	Mounts []Mount
+	Cmd    []string // overrides the image command when set
+	// Network is joined instead of the default bridge. Aliases are extra DNS names in that network.
+	Network string
+	Aliases []string
+	// Resources restricts memory, CPU and processes of the container.
+	Resources Resources
+}
+
+// Resources are the runtime resource limits of a container. Zero values mean unlimited.
+type Resources struct {
+	Memory    int64 // bytes
+	NanoCPUs  int64 // CPU quota in units of 1e-9 CPUs
+	CPUShares int64
+	PidsLimit int64
+	Ulimits   []Ulimit
+}
+
+// Ulimit is a process resource limit such as nofile.
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// Mount attaches a named volume to a container.
+type Mount struct {
+	Source   string // volume name
+	Target   string // path inside the container
+	ReadOnly bool
+}
+
+// NetworkInfo is the runtime view of a network.
+type NetworkInfo struct {
+	ID         string
+	Name       string
+	Driver     string
+	Labels     map[string]string
+	CreatedAt  time.Time
+	Containers []string // names of the attached containers
+}
+
+// VolumeInfo is the runtime view of a named volume.
+type VolumeInfo struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	Labels     map[string]string
+	CreatedAt  time.Time
+}
+
+// ContainerInfo is the runtime view of a container.
+type ContainerInfo struct {
+	ID     string
+	Name   string
+	Image  string
+	Labels map[string]string
+	State  ContainerState
+}
+
+// ContainerState holds the lifecycle state reported by the runtime.
+type ContainerState struct {
+	Status     string // created, running, paused, restarting, removing, exited or dead
+	Running    bool
+	Paused     bool
+	ExitCode   int
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ImageInfo is the runtime view of a local image.
+type ImageInfo struct {
+	ID     string
+	Tags   []string
+	Labels map[string]string
+}
+
+// PullProgress is a progress message of one image layer during a pull.
+type PullProgress struct {
+	Layer   string
+	Status  string // e.g. Downloading, Download complete, Extracting, Pull complete, Already exists
+	Current int64  // bytes downloaded or extracted so far
+	Total   int64
+}
+
+// BuildOptions describes an image build from a local context directory.
+type BuildOptions struct {
+	Tag        string
+	ContextDir string
+	Dockerfile string // relative to ContextDir
+	Labels     map[string]string
+	// Output receives the build output line by line if set.
+	Output func(line string)
+}
+
+// CommitOptions describes the image created by CommitContainer.
+type CommitOptions struct {
+	Repository string
+	Tag        string
+	Labels     map[string]string
+	// Pause freezes the container while it is committed so the image is consistent.
+	Pause bool
+}
+
+// LogsOptions controls which log lines are returned by Logs.
+type LogsOptions struct {
+	Follow     bool
+	Tail       int
+	Since      *time.Time
+	Timestamps bool
+}
+
+// ContainerEvent is a container lifecycle event such as start, die or destroy.
+type ContainerEvent struct {
+	ID       string
+	Name     string
+	Action   string
+	ExitCode int // only set for die events
+	Labels   map[string]string
+	Time     time.Time
+}
+
+// ContainerStats is a resource usage sample of a container; byte counters are totals.
+type ContainerStats struct {
+	ID            string    `json:"container_id"`
+	Name          string    `json:"name"`
+	Time          time.Time `json:"time"`
+	CPUPercent    float64   `json:"cpu_percent"` // 100 per fully used CPU
+	MemoryUsage   int64     `json:"memory_usage"`
+	MemoryLimit   int64     `json:"memory_limit"`
+	MemoryPercent float64   `json:"memory_percent"`
+	NetworkRx     int64     `json:"network_rx"`
+	NetworkTx     int64     `json:"network_tx"`
+	BlockRead     int64     `json:"block_read"`
+	BlockWrite    int64     `json:"block_write"`
+	Pids          int64     `json:"pids"`
+}
+
+// ExecResult is the outcome of a finished Exec call.
+type ExecResult struct {
+	Output   []byte
+	ExitCode int
+}
+
+// ExecSession is an interactive command attached to a TTY.
+type ExecSession interface {
+	io.ReadWriteCloser
+	// Resize changes the size of the TTY.
+	Resize(ctx context.Context, rows uint, cols uint) error
+}
+
+// Runtime is the container runtime used by the package. Tests replace it with a FakeRuntime.
+var Runtime ContainerRuntime = mustEngineRuntime(defaultDockerHost)
+
+// InitializeRuntime selects the container runtime according to the environment configuration.
+func InitializeRuntime() {
+	kind, host := "docker", defaultDockerHost
+	if config.EnvConfig != nil {
+		if config.EnvConfig.ContainerRuntime != "" {
+			kind = strings.ToLower(config.EnvConfig.ContainerRuntime)
+		}
+		switch kind {
+		case "podman":
+			host = config.EnvConfig.ContainerHost
+		default:
+			if config.EnvConfig.DockerHost != "" {
+				host = config.EnvConfig.DockerHost
+			}
+		}
+	}
+
+	rt, err := newRuntime(kind, host)
+	if err != nil {
+		log.Fatalf("Container runtime: %v", err)
+	}
+	Runtime = rt
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Runtime.Ping(ctx); err != nil {
+		log.Printf("Container runtime %s not reachable: %v", Runtime.Name(), err)
+		return
+	}
+	log.Printf("Using container runtime %s", Runtime.Name())
+}
+
+// newRuntime creates the runtime named kind ("docker" or "podman") for the given host address.
+func newRuntime(kind string, host string) (ContainerRuntime, error) {
+	switch kind {
+	case "docker":
+		return NewEngineRuntime(host)
+	case "podman":
+		return NewPodmanRuntime(host)
+	default:
+		return nil, fmt.Errorf("unknown container runtime %q", kind)
+	}
+}
+
+func managedLabels() map[string]string {
+	return map[string]string{ManagedByLabel: ManagedByValue}
+}
+
+func mustEngineRuntime(host string) *EngineRuntime {
+	rt, err := NewEngineRuntime(host)
+	if err != nil {
+		panic(err)
+	}
+	return rt
+}
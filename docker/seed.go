@@ -0,0 +1,38 @@
+package docker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// Seeder loads the seed script of a configuration into a container that just became ready.
+type Seeder func(ctx context.Context, container *dtos.Container, script string) error
+
+// seeders holds the registered seeders by capability.
+var (
+	seedersMu sync.RWMutex
+	seeders   = map[string]Seeder{}
+)
+
+// RegisterSeeder makes servers with capability accept a seed script. The protocol packages
+// register their seeders, so the runner does not depend on them.
+func RegisterSeeder(capability string, seeder Seeder) {
+	seedersMu.Lock()
+	defer seedersMu.Unlock()
+	seeders[capability] = seeder
+}
+
+// seederFor returns the seeder of the first capability of server that has one, or nil.
+func seederFor(server *servers.ServerInformation) Seeder {
+	seedersMu.RLock()
+	defer seedersMu.RUnlock()
+	for _, c := range server.Capabilities {
+		if seeder, ok := seeders[c]; ok {
+			return seeder
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// useSeeder registers seeder for capability for the duration of the test.
+func useSeeder(t *testing.T, capability string, seeder Seeder) {
+	t.Helper()
+	RegisterSeeder(capability, seeder)
+	t.Cleanup(func() {
+		seedersMu.Lock()
+		defer seedersMu.Unlock()
+		delete(seeders, capability)
+	})
+}
+
+func TestStartServerWithProgress_LoadsSeed(t *testing.T) {
+	useFakeRuntime(t)
+	captureCreatedContainers(t)
+	stubProbe(t, nil)
+	var seeded string
+	useSeeder(t, "sql", func(ctx context.Context, container *dtos.Container, script string) error {
+		seeded = container.Type + ":" + script
+		return nil
+	})
+
+	StartServerWithProgress("req-seed", "POSTGRES", ServerConfiguration{Seed: "CREATE TABLE t (id int);"})
+	events := collectEvents(t, "req-seed")
+
+	if last := events[len(events)-1]; last.Error || last.Message != "Started" {
+		t.Fatalf("unexpected final event: %+v (all: %+v)", last, events)
+	}
+	if !hasEvent(events, "Loading seed") || seeded != "POSTGRES:CREATE TABLE t (id int);" {
+		t.Fatalf("expected the seed to be loaded, got %q (events: %+v)", seeded, events)
+	}
+}
+
+func TestStartServerWithProgress_SeedFailureMarksFailed(t *testing.T) {
+	useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	stubProbe(t, nil)
+	useSeeder(t, "sql", func(ctx context.Context, container *dtos.Container, script string) error {
+		return errors.New("syntax error")
+	})
+
+	StartServerWithProgress("req-seed-fail", "MYSQL", ServerConfiguration{Seed: "CREATE TABLE"})
+	events := collectEvents(t, "req-seed-fail")
+
+	if last := events[len(events)-1]; !last.Error || last.Message != "seed failed: syntax error" {
+		t.Fatalf("unexpected final event: %+v", last)
+	}
+	if len(*created) != 1 || (*created)[0].Status != dtos.Failed {
+		t.Fatalf("expected the container to be marked failed, got %+v", *created)
+	}
+}
+
+func TestValidateConfiguration_RejectsUnsupportedSeed(t *testing.T) {
+	err := ValidateConfiguration("FTP", ServerConfiguration{Seed: "x", Env: map[string]string{"FTP_PASS": ""}})
+	var configErr *servers.ConfigError
+	if !errors.As(err, &configErr) || configErr.Fields["seed"] != "is not supported by FTP" || configErr.Fields["env.FTP_PASS"] == "" {
+		t.Fatalf("expected seed and env field errors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "seed") {
+		t.Fatalf("expected the seed field in the message, got %v", err)
+	}
+}
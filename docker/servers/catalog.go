@@ -0,0 +1,273 @@
+package servers
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinCatalog holds the default server types, loaded in file name order.
+//
+//go:embed catalog/*.yaml
+var builtinCatalog embed.FS
+
+// catalogMu guards catalog, the server types in display order.
+var (
+	catalogMu sync.RWMutex
+	catalog   = mustLoadBuiltinCatalog()
+)
+
+var (
+	typePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+)
+
+// HostPortPattern matches the placeholders ${HOST_PORT_<container port>} in env values. They
+// are replaced with the host port the container port is published on when a server starts.
+var HostPortPattern = regexp.MustCompile(`\$\{HOST_PORT_([0-9]+)\}`)
+
+// ContainerNamePlaceholder in env values is replaced with the name of the started container,
+// under which other containers on its network reach it.
+const ContainerNamePlaceholder = "${CONTAINER_NAME}"
+
+// knownCapabilities are the features a catalog entry may announce.
+var knownCapabilities = []string{"logs", "files", "mqtt", "web", "ftp", "smb", "mail", "otel", "sql", "redis", "kafka", "amqp"}
+
+// LoadCatalog replaces the catalog with the built-in server types and the entries of all
+// .yaml and .yml files in dir. An entry with the type of a built-in replaces it; other
+// entries are appended in file name order. A missing dir leaves only the built-ins. All
+// invalid entries are reported together and leave the catalog unchanged.
+func LoadCatalog(dir string) error {
+	entries, err := loadCatalogFS(builtinCatalog, "catalog")
+	if err != nil {
+		return err
+	}
+
+	if dir != "" {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			custom, err := loadCatalogFS(os.DirFS(dir), ".")
+			if err != nil {
+				return fmt.Errorf("catalog %s: %w", dir, err)
+			}
+			entries = mergeCatalog(entries, custom)
+		} else if !errors.Is(statErr, fs.ErrNotExist) {
+			return fmt.Errorf("catalog %s: %w", dir, statErr)
+		}
+	}
+
+	catalogMu.Lock()
+	catalog = entries
+	catalogMu.Unlock()
+	log.Printf("Loaded %d server types from the catalog", len(entries))
+	return nil
+}
+
+func mustLoadBuiltinCatalog() []ServerInformation {
+	entries, err := loadCatalogFS(builtinCatalog, "catalog")
+	if err != nil {
+		panic(err)
+	}
+	return entries
+}
+
+// mergeCatalog replaces the entries of base with custom entries of the same type and
+// appends the others.
+func mergeCatalog(base []ServerInformation, custom []ServerInformation) []ServerInformation {
+	out := slices.Clone(base)
+	for _, entry := range custom {
+		i := slices.IndexFunc(out, func(s ServerInformation) bool { return s.Type == entry.Type })
+		if i >= 0 {
+			log.Printf("Catalog entry %s replaces the built-in server type", entry.Type)
+			out[i] = entry
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// loadCatalogFS parses and validates all catalog files in dir of fsys.
+func loadCatalogFS(fsys fs.FS, dir string) ([]ServerInformation, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, f := range files {
+		ext := strings.ToLower(path.Ext(f.Name()))
+		if !f.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	entries := make([]ServerInformation, 0, len(names))
+	seen := map[string]string{}
+	var errs []error
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		entry, err := ParseCatalogEntry(content)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if other, ok := seen[entry.Type]; ok {
+			errs = append(errs, fmt.Errorf("%s: type %s is already defined in %s", name, entry.Type, other))
+			continue
+		}
+		seen[entry.Type] = name
+		entries = append(entries, *entry)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return entries, nil
+}
+
+// ParseCatalogEntry decodes a YAML catalog entry and validates it. The YAML keys are the
+// JSON names of the servers API, so an entry looks like the API response for its type.
+func ParseCatalogEntry(content []byte) (*ServerInformation, error) {
+	var raw any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	var entry ServerInformation
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entry); err != nil {
+		return nil, fmt.Errorf("invalid entry: %w", err)
+	}
+
+	if entry.Env == nil {
+		entry.Env = map[string]string{}
+	}
+	if entry.Volumes == nil {
+		entry.Volumes = []string{}
+	}
+	if entry.ExecCommands == nil {
+		entry.ExecCommands = []string{}
+	}
+	if entry.Capabilities == nil {
+		entry.Capabilities = []string{}
+	}
+	if err := entry.validate(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// validate reports all problems of the entry at once.
+func (s *ServerInformation) validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !typePattern.MatchString(s.Type) {
+		fail("type %q must be upper case letters, digits and underscores", s.Type)
+	}
+	if !namePattern.MatchString(s.Name) {
+		fail("name %q must be lower case letters, digits and dashes", s.Name)
+	}
+	if s.Image == "" {
+		fail("image is required")
+	}
+	if s.Build != "" && !filepath.IsLocal(s.Build) {
+		fail("build %q must be a directory below custom_images", s.Build)
+	}
+
+	ports := map[int]bool{}
+	for _, p := range s.Ports {
+		if p < 1 || p > 65535 {
+			fail("port %d is out of range", p)
+		}
+		if ports[p] {
+			fail("port %d is listed twice", p)
+		}
+		ports[p] = true
+	}
+	for name, value := range s.Env {
+		for _, m := range HostPortPattern.FindAllStringSubmatch(value, -1) {
+			if p, _ := strconv.Atoi(m[1]); !ports[p] {
+				fail("env %s refers to port %s, which is not one of the ports", name, m[1])
+			}
+		}
+	}
+	s.completeSchema(fail)
+	for _, v := range s.Volumes {
+		if !path.IsAbs(v) || path.Clean(v) == "/" {
+			fail("volume %q must be an absolute path", v)
+		}
+	}
+	for _, c := range s.Capabilities {
+		if !slices.Contains(knownCapabilities, c) {
+			fail("unknown capability %q, expected one of %s", c, strings.Join(knownCapabilities, ", "))
+		}
+	}
+	for _, c := range s.ExecCommands {
+		if c == "" || strings.ContainsAny(c, " /") {
+			fail("exec command %q must be a plain executable name", c)
+		}
+	}
+
+	if r := s.Readiness; r != nil {
+		switch r.Kind {
+		case ReadinessTCP, ReadinessHTTP, ReadinessMQTT, ReadinessSMTP:
+			if !ports[r.Port] {
+				fail("readiness port %d is not one of the ports", r.Port)
+			}
+			if r.Kind == ReadinessHTTP && !strings.HasPrefix(r.Path, "/") {
+				fail("readiness path %q must start with /", r.Path)
+			}
+		case ReadinessLog:
+			if _, err := regexp.Compile(r.Pattern); err != nil || r.Pattern == "" {
+				fail("readiness pattern %q is not a valid regular expression", r.Pattern)
+			}
+		default:
+			fail("unknown readiness kind %q", r.Kind)
+		}
+		if r.TimeoutSeconds < 0 {
+			fail("readiness timeout must not be negative")
+		}
+	}
+
+	if l := s.Limits; l != nil {
+		if l.MemoryMB < 0 || l.CPUs < 0 || l.CPUShares < 0 || l.PidsLimit < 0 {
+			fail("limits must not be negative")
+		}
+		for _, u := range l.Ulimits {
+			if u.Name == "" || u.Soft < 0 || u.Hard < u.Soft {
+				fail("invalid ulimit %s=%d:%d", u.Name, u.Soft, u.Hard)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("type %s: %w", s.Type, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package servers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useCatalog restores the catalog after a test loaded another one.
+func useCatalog(t *testing.T) {
+	t.Helper()
+	catalogMu.RLock()
+	old := catalog
+	catalogMu.RUnlock()
+	t.Cleanup(func() {
+		catalogMu.Lock()
+		catalog = old
+		catalogMu.Unlock()
+	})
+}
+
+func writeEntry(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuiltinCatalog(t *testing.T) {
+	var types []string
+	for _, s := range GetAllServers() {
+		types = append(types, s.Type)
+	}
+	if strings.Join(types, ",") != "MQTT,WEB,FTP,SMB,MAIL,OTEL,POSTGRES,MYSQL,REDIS,KAFKA,AMQP" {
+		t.Fatalf("unexpected built-in types %v", types)
+	}
+
+	mqtt, err := GetServerByType("MQTT")
+	if err != nil {
+		t.Fatalf("GetServerByType: %v", err)
+	}
+	if mqtt.Build != "simple-test-server-custom-mqtt" || mqtt.Readiness.Kind != ReadinessMQTT || mqtt.Limits.MemoryMB != 128 || mqtt.Env["MQTT_USERNAME"] != "user" {
+		t.Fatalf("unexpected mqtt entry %+v", mqtt)
+	}
+	mqtt.Env["MQTT_USERNAME"] = "changed"
+	if again, _ := GetServerByType("MQTT"); again.Env["MQTT_USERNAME"] != "user" {
+		t.Fatal("callers must not be able to change the catalog")
+	}
+	if _, err := GetServerByType("UNKNOWN"); err == nil {
+		t.Fatal("expected an error for unknown types")
+	}
+}
+
+func TestLoadCatalog_AddsAndReplacesTypes(t *testing.T) {
+	useCatalog(t)
+	builtins := len(GetAllServers())
+	dir := t.TempDir()
+	writeEntry(t, dir, "echo.yaml", "type: ECHO\nname: echo\nimage: ealen/echo-server:latest\nports: [80]\nreadiness: {kind: http, port: 80, path: /}\ncapabilities: [logs]\n")
+	writeEntry(t, dir, "mail.yml", "type: MAIL\nname: mail\nimage: axllent/mailpit:latest\nports: [1025, 8025]\n")
+	writeEntry(t, dir, "README.md", "not a catalog entry")
+
+	if err := LoadCatalog(dir); err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	all := GetAllServers()
+	if len(all) != builtins+1 || all[builtins].Type != "ECHO" || all[builtins].Env == nil {
+		t.Fatalf("expected the new type to be appended, got %+v", all)
+	}
+	if mail, _ := GetServerByType("MAIL"); mail.Image != "axllent/mailpit:latest" {
+		t.Fatalf("expected the built-in mail type to be replaced, got %+v", mail)
+	}
+}
+
+func TestLoadCatalog_MissingDirKeepsBuiltins(t *testing.T) {
+	useCatalog(t)
+	builtins := len(GetAllServers())
+	if err := LoadCatalog(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	if len(GetAllServers()) != builtins {
+		t.Fatal("expected the built-in types")
+	}
+}
+
+func TestLoadCatalog_ReportsAllInvalidEntries(t *testing.T) {
+	useCatalog(t)
+	builtins := len(GetAllServers())
+	dir := t.TempDir()
+	writeEntry(t, dir, "a.yaml", "type: lower\nname: x\nimage: x\nports: [0, 80, 80]\nvolumes: [data]\nreadiness: {kind: tcp, port: 21}\ncapabilities: [teleport]\n")
+	writeEntry(t, dir, "b.yaml", "type: B\nname: b\nimage: b\nportz: [80]\n")
+	writeEntry(t, dir, "c.yaml", "type: C\nname: c\nimage: c\nbuild: ../outside\nreadiness: {kind: log, pattern: \"(\"}\nenv: {URL: \"http://localhost:${HOST_PORT_8080}\"}\n")
+	writeEntry(t, dir, "d.yaml", "type: D\nname: d\nimage: d\n")
+	writeEntry(t, dir, "e.yaml", "type: D\nname: e\nimage: e\n")
+
+	err := LoadCatalog(dir)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`type "lower"`, "port 0 is out of range", "port 80 is listed twice", `volume "data"`,
+		"readiness port 21", `unknown capability "teleport"`, `unknown field "portz"`,
+		`build "../outside"`, "readiness pattern", "env URL refers to port 8080", "type D is already defined in d.yaml",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+	if len(GetAllServers()) != builtins {
+		t.Fatal("an invalid catalog must not replace the current one")
+	}
+}
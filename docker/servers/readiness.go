@@ -0,0 +1,42 @@
+package servers
+
+import "time"
+
+// ReadinessKind selects how a server is probed before it is reported as started.
+type ReadinessKind string
+
+const (
+	// ReadinessTCP waits until the port accepts TCP connections.
+	ReadinessTCP ReadinessKind = "tcp"
+	// ReadinessHTTP waits until a GET request on Path answers with a non-5xx status.
+	ReadinessHTTP ReadinessKind = "http"
+	// ReadinessMQTT waits until the broker answers a CONNECT packet with a CONNACK.
+	ReadinessMQTT ReadinessKind = "mqtt"
+	// ReadinessSMTP waits until the server greets with a 220 banner.
+	ReadinessSMTP ReadinessKind = "smtp"
+	// ReadinessLog waits until a container log line matches Pattern.
+	ReadinessLog ReadinessKind = "log"
+)
+
+// defaultReadinessTimeout is used when a check does not set TimeoutSeconds.
+const defaultReadinessTimeout = 60 * time.Second
+
+// ReadinessCheck describes how to find out that a started server accepts connections.
+type ReadinessCheck struct {
+	Kind ReadinessKind `json:"kind"`
+	// Port is the container port to probe; it is resolved to the published host port.
+	Port int `json:"port,omitempty"`
+	// Path is the request path of HTTP checks.
+	Path string `json:"path,omitempty"`
+	// Pattern is the regular expression of log checks.
+	Pattern        string `json:"pattern,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+// GetTimeout returns how long the runner waits for the check to succeed.
+func (r *ReadinessCheck) GetTimeout() time.Duration {
+	if r.TimeoutSeconds <= 0 {
+		return defaultReadinessTimeout
+	}
+	return time.Duration(r.TimeoutSeconds) * time.Second
+}
@@ -0,0 +1,259 @@
+package servers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldType is the type of a configurable environment variable.
+type FieldType string
+
+const (
+	FieldString  FieldType = "string"
+	FieldInteger FieldType = "integer"
+	FieldBoolean FieldType = "boolean"
+	// FieldPort is an integer between 1 and 65535, optionally limited by the port range.
+	FieldPort FieldType = "port"
+)
+
+// ConfigSchema describes the configuration a server type accepts.
+type ConfigSchema struct {
+	Env   []ConfigField `json:"env"`
+	Ports []PortField   `json:"ports"`
+	// AdditionalEnv allows environment variables that are not listed in Env.
+	AdditionalEnv bool `json:"additional_env,omitempty"`
+}
+
+// ConfigField is a configurable environment variable.
+type ConfigField struct {
+	Name        string    `json:"name"`
+	Type        FieldType `json:"type"`
+	Default     string    `json:"default,omitempty"`
+	Description string    `json:"description,omitempty"`
+	// Required fields must have a value; the default counts as one.
+	Required bool `json:"required,omitempty"`
+	// Secret values should be masked in the UI.
+	Secret bool     `json:"secret,omitempty"`
+	Enum   []string `json:"enum,omitempty"`
+	// PortRange limits the values of port fields.
+	PortRange *PortRange `json:"port_range,omitempty"`
+}
+
+// PortField describes a container port and the host ports it may be published on.
+type PortField struct {
+	Port        int        `json:"port"`
+	Description string     `json:"description,omitempty"`
+	PortRange   *PortRange `json:"port_range,omitempty"`
+}
+
+// PortRange is an inclusive range of ports.
+type PortRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func (r *PortRange) contains(port int) bool {
+	if r == nil {
+		return port >= 1 && port <= 65535
+	}
+	return port >= r.Min && port <= r.Max
+}
+
+func (r *PortRange) String() string {
+	if r == nil {
+		return "1-65535"
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ConfigError reports the invalid fields of a server configuration. Keys are env.<NAME> or
+// ports.<host port>.
+type ConfigError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (e *ConfigError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+e.Fields[k])
+	}
+	return "invalid configuration: " + strings.Join(parts, "; ")
+}
+
+// ValidateConfig checks the environment variables and port mappings of a configuration
+// against the schema of the server type. ports maps host ports to container ports as sent
+// by the API. It returns a *ConfigError listing every invalid field.
+func (s ServerInformation) ValidateConfig(env map[string]string, ports []map[string]int) error {
+	fields := map[string]string{}
+
+	for name, value := range env {
+		field := s.envField(name)
+		if field == nil {
+			if !s.Schema.AdditionalEnv {
+				fields["env."+name] = "is not a setting of " + s.Type
+			}
+			continue
+		}
+		if msg := field.check(value); msg != "" {
+			fields["env."+name] = msg
+		}
+	}
+	for _, field := range s.Schema.Env {
+		if _, ok := env[field.Name]; !ok && field.Required && field.Default == "" {
+			fields["env."+field.Name] = "is required"
+		}
+	}
+
+	hostPorts := map[int]bool{}
+	for _, mapping := range ports {
+		for hostStr, containerPort := range mapping {
+			key := "ports." + hostStr
+			hostPort, err := strconv.Atoi(hostStr)
+			if err != nil {
+				fields[key] = "host port must be a number"
+				continue
+			}
+			field := s.portField(containerPort)
+			switch {
+			case field == nil:
+				fields[key] = fmt.Sprintf("container port %d is not exposed by %s", containerPort, s.Type)
+			case !field.PortRange.contains(hostPort):
+				fields[key] = fmt.Sprintf("host port must be in %s", field.PortRange)
+			case hostPorts[hostPort]:
+				fields[key] = "host port is used twice"
+			}
+			hostPorts[hostPort] = true
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ConfigError{Fields: fields}
+	}
+	return nil
+}
+
+func (s ServerInformation) envField(name string) *ConfigField {
+	for i := range s.Schema.Env {
+		if s.Schema.Env[i].Name == name {
+			return &s.Schema.Env[i]
+		}
+	}
+	return nil
+}
+
+func (s ServerInformation) portField(port int) *PortField {
+	for i := range s.Schema.Ports {
+		if s.Schema.Ports[i].Port == port {
+			return &s.Schema.Ports[i]
+		}
+	}
+	return nil
+}
+
+// check returns why value is invalid for the field, or an empty string.
+func (f *ConfigField) check(value string) string {
+	if value == "" {
+		if f.Required {
+			return "is required"
+		}
+		return ""
+	}
+	if len(f.Enum) > 0 && !slices.Contains(f.Enum, value) {
+		return "must be one of " + strings.Join(f.Enum, ", ")
+	}
+	switch f.Type {
+	case FieldInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an integer"
+		}
+	case FieldBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+	case FieldPort:
+		port, err := strconv.Atoi(value)
+		if err != nil || !f.PortRange.contains(port) {
+			return "must be a port in " + f.PortRange.String()
+		}
+	}
+	return ""
+}
+
+// completeSchema fills the schema defaults from the env defaults and vice versa, adds a port
+// field for every port without one and reports inconsistencies of the catalog entry.
+func (s *ServerInformation) completeSchema(fail func(format string, args ...any)) {
+	names := map[string]bool{}
+	for i := range s.Schema.Env {
+		field := &s.Schema.Env[i]
+		if field.Name == "" {
+			fail("schema env field %d has no name", i)
+			continue
+		}
+		if names[field.Name] {
+			fail("schema env field %s is listed twice", field.Name)
+		}
+		names[field.Name] = true
+
+		switch field.Type {
+		case "":
+			field.Type = FieldString
+		case FieldString, FieldInteger, FieldBoolean, FieldPort:
+		default:
+			fail("schema env field %s has unknown type %q", field.Name, field.Type)
+		}
+		checkRange(field.PortRange, "schema env field "+field.Name, fail)
+
+		if value, ok := s.Env[field.Name]; ok {
+			if field.Default != "" && field.Default != value {
+				fail("schema env field %s has default %q but env sets %q", field.Name, field.Default, value)
+			}
+			field.Default = value
+		} else if field.Default != "" {
+			s.Env[field.Name] = field.Default
+		}
+		if field.Default != "" {
+			if msg := field.check(field.Default); msg != "" {
+				fail("default of schema env field %s %s", field.Name, msg)
+			}
+		}
+	}
+	if !s.Schema.AdditionalEnv {
+		for name := range s.Env {
+			if !names[name] {
+				fail("env %s is not described in the schema", name)
+			}
+		}
+	}
+
+	for _, field := range s.Schema.Ports {
+		if !slices.Contains(s.Ports, field.Port) {
+			fail("schema port %d is not one of the ports", field.Port)
+		}
+		checkRange(field.PortRange, fmt.Sprintf("schema port %d", field.Port), fail)
+	}
+	for _, port := range s.Ports {
+		if s.portField(port) == nil {
+			s.Schema.Ports = append(s.Schema.Ports, PortField{Port: port})
+		}
+	}
+	if s.Schema.Env == nil {
+		s.Schema.Env = []ConfigField{}
+	}
+	if s.Schema.Ports == nil {
+		s.Schema.Ports = []PortField{}
+	}
+}
+
+func checkRange(r *PortRange, what string, fail func(format string, args ...any)) {
+	if r != nil && (r.Min < 1 || r.Max > 65535 || r.Min > r.Max) {
+		fail("%s has invalid port range %d-%d", what, r.Min, r.Max)
+	}
+}
@@ -0,0 +1,127 @@
+package servers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	mail, err := GetServerByType("MAIL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mail.ValidateConfig(map[string]string{"MH_STORAGE": "maildir"}, []map[string]int{{"18025": 8025}}); err != nil {
+		t.Fatalf("expected a valid configuration, got %v", err)
+	}
+
+	err = mail.ValidateConfig(
+		map[string]string{"MH_STORAGE": "mongodb", "UNKNOWN": "x"},
+		[]map[string]int{{"NaN": 8025}, {"2525": 25}, {"70000": 1025}},
+	)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expected a ConfigError, got %v", err)
+	}
+	want := map[string]string{
+		"env.MH_STORAGE": "must be one of memory, maildir",
+		"env.UNKNOWN":    "is not a setting of MAIL",
+		"ports.NaN":      "host port must be a number",
+		"ports.2525":     "container port 25 is not exposed by MAIL",
+		"ports.70000":    "host port must be in 1-65535",
+	}
+	for field, msg := range want {
+		if configErr.Fields[field] != msg {
+			t.Errorf("%s: expected %q, got %q", field, msg, configErr.Fields[field])
+		}
+	}
+	if len(configErr.Fields) != len(want) {
+		t.Errorf("unexpected fields %v", configErr.Fields)
+	}
+}
+
+func TestValidateConfig_TypesAndRanges(t *testing.T) {
+	entry, err := ParseCatalogEntry([]byte(`
+type: DB
+name: db
+image: db
+ports: [5432]
+env:
+  DB_USER: admin
+schema:
+  env:
+    - {name: DB_USER, required: true}
+    - {name: DB_PASSWORD, required: true, secret: true}
+    - {name: DB_POOL, type: integer}
+    - {name: DB_DEBUG, type: boolean}
+    - {name: DB_METRICS_PORT, type: port, port_range: {min: 9000, max: 9100}}
+  ports:
+    - {port: 5432, port_range: {min: 15000, max: 15999}}
+`))
+	if err != nil {
+		t.Fatalf("ParseCatalogEntry: %v", err)
+	}
+	if entry.Schema.Env[0].Default != "admin" || entry.Schema.Env[0].Type != FieldString {
+		t.Fatalf("expected the env default and string type to be filled in, got %+v", entry.Schema.Env[0])
+	}
+
+	err = entry.ValidateConfig(
+		map[string]string{"DB_USER": "", "DB_POOL": "many", "DB_DEBUG": "yes", "DB_METRICS_PORT": "8080"},
+		[]map[string]int{{"5432": 5432}},
+	)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expected a ConfigError, got %v", err)
+	}
+	for field, msg := range map[string]string{
+		"env.DB_USER":         "is required",
+		"env.DB_PASSWORD":     "is required",
+		"env.DB_POOL":         "must be an integer",
+		"env.DB_DEBUG":        "must be true or false",
+		"env.DB_METRICS_PORT": "must be a port in 9000-9100",
+		"ports.5432":          "host port must be in 15000-15999",
+	} {
+		if configErr.Fields[field] != msg {
+			t.Errorf("%s: expected %q, got %q", field, msg, configErr.Fields[field])
+		}
+	}
+
+	ok := map[string]string{"DB_PASSWORD": "secret", "DB_POOL": "4", "DB_DEBUG": "true", "DB_METRICS_PORT": "9090"}
+	if err := entry.ValidateConfig(ok, []map[string]int{{"15432": 5432}}); err != nil {
+		t.Fatalf("expected a valid configuration, got %v", err)
+	}
+}
+
+func TestParseCatalogEntry_RejectsInconsistentSchema(t *testing.T) {
+	_, err := ParseCatalogEntry([]byte(`
+type: DB
+name: db
+image: db
+ports: [5432]
+env:
+  UNDESCRIBED: x
+  DB_MODE: fast
+schema:
+  env:
+    - {name: DB_MODE, enum: [safe, slow]}
+    - {name: DB_SIZE, type: bytes}
+  ports:
+    - {port: 3306}
+    - {port: 5432, port_range: {min: 2000, max: 1000}}
+`))
+	if err == nil {
+		t.Fatal("expected schema errors")
+	}
+	for _, want := range []string{
+		"env UNDESCRIBED is not described in the schema",
+		"default of schema env field DB_MODE must be one of safe, slow",
+		`schema env field DB_SIZE has unknown type "bytes"`,
+		"schema port 3306 is not one of the ports",
+		"schema port 5432 has invalid port range 2000-1000",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
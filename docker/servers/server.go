@@ -2,69 +2,131 @@ package servers
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
 )
 
 type ServerDefinition interface {
 	GetImage() string
+	// GetBuildContext returns the directory below custom_images the image is built from, or
+	// an empty string if the image is pulled.
+	GetBuildContext() string
 	GetName() string
 	GetPorts() []int
 	GetEnv() map[string]string
+	// GetVolumes returns the container paths that are persisted in named volumes.
+	GetVolumes() []string
+	GetReadiness() *ReadinessCheck
+	// GetLimits returns the default resource limits of the server type.
+	GetLimits() *dtos.ResourceLimits
+	// GetExecCommands returns the executables that may be run interactively in the container.
+	GetExecCommands() []string
 }
 
+// ServerInformation is a server type of the catalog. It is read from the catalog files and
+// returned by the servers API.
 type ServerInformation struct {
-	Name  string            `json:"name"`
-	Image string            `json:"image"`
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// Build is the build context of the image below custom_images; empty for pulled images.
+	Build string            `json:"build,omitempty"`
 	Ports []int             `json:"ports"`
 	Env   map[string]string `json:"env"`
+	// Volumes are the container paths persisted in managed volumes by default.
+	Volumes []string `json:"volumes"`
+	// Readiness is the check used before a started server is reported as ready.
+	Readiness *ReadinessCheck `json:"readiness,omitempty"`
+	// Limits are the default resource limits applied when a configuration sets none.
+	Limits *dtos.ResourceLimits `json:"limits,omitempty"`
+	// ExecCommands are the executables allowed in interactive exec sessions.
+	ExecCommands []string `json:"exec_commands"`
+	// Schema describes the env variables and host ports a configuration may set.
+	Schema ConfigSchema `json:"schema"`
+	// Capabilities name the features the UI offers for the type, e.g. logs, files or mqtt.
+	Capabilities []string `json:"capabilities"`
+}
+
+func (s ServerInformation) GetImage() string {
+	return s.Image
+}
+
+func (s ServerInformation) GetBuildContext() string {
+	return s.Build
+}
+
+func (s ServerInformation) GetName() string {
+	return s.Name
+}
+
+func (s ServerInformation) GetPorts() []int {
+	return s.Ports
+}
+
+func (s ServerInformation) GetEnv() map[string]string {
+	return s.Env
+}
+
+func (s ServerInformation) GetVolumes() []string {
+	return s.Volumes
+}
+
+func (s ServerInformation) GetReadiness() *ReadinessCheck {
+	return s.Readiness
+}
+
+func (s ServerInformation) GetLimits() *dtos.ResourceLimits {
+	return s.Limits
+}
+
+func (s ServerInformation) GetExecCommands() []string {
+	return s.ExecCommands
 }
 
 func GetAllServers() []ServerInformation {
-	servers := []ServerDefinition{
-		MqttServer{},
-		WebServer{},
-		FtpServer{},
-		SmbServer{},
-		MailServer{},
-		OtelServer{},
-	}
-	var serverInfo []ServerInformation
-	for _, server := range servers {
-		info := ServerInformation{
-			Name:  server.GetName(),
-			Image: server.GetImage(),
-			Ports: server.GetPorts(),
-			Env:   server.GetEnv(),
-		}
-		serverInfo = append(serverInfo, info)
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	serverInfo := make([]ServerInformation, 0, len(catalog))
+	for _, server := range catalog {
+		serverInfo = append(serverInfo, server.clone())
 	}
 	return serverInfo
 }
 
 func GetServerByType(serverType string) (*ServerInformation, error) {
-	var serverDefinition ServerDefinition
-	switch serverType {
-	case "MQTT":
-		serverDefinition = MqttServer{}
-	case "WEB":
-		serverDefinition = WebServer{}
-	case "FTP":
-		serverDefinition = FtpServer{}
-	case "SMB":
-		serverDefinition = SmbServer{}
-	case "MAIL":
-		serverDefinition = MailServer{}
-	case "OTEL":
-		serverDefinition = OtelServer{}
-	default:
-		return nil, fmt.Errorf("unknown server type: %s", serverType)
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	for _, server := range catalog {
+		if server.Type == serverType {
+			info := server.clone()
+			return &info, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown server type: %s", serverType)
+}
+
+// clone returns a deep copy so callers cannot change the catalog.
+func (s ServerInformation) clone() ServerInformation {
+	s.Ports = slices.Clone(s.Ports)
+	s.Env = maps.Clone(s.Env)
+	s.Volumes = slices.Clone(s.Volumes)
+	s.ExecCommands = slices.Clone(s.ExecCommands)
+	s.Capabilities = slices.Clone(s.Capabilities)
+	s.Schema.Env = slices.Clone(s.Schema.Env)
+	for i := range s.Schema.Env {
+		s.Schema.Env[i].Enum = slices.Clone(s.Schema.Env[i].Enum)
+	}
+	s.Schema.Ports = slices.Clone(s.Schema.Ports)
+	if s.Readiness != nil {
+		readiness := *s.Readiness
+		s.Readiness = &readiness
 	}
-	if serverDefinition == nil {
-		return nil, fmt.Errorf("server type %s not found", serverType)
+	if s.Limits != nil {
+		limits := *s.Limits
+		limits.Ulimits = slices.Clone(limits.Ulimits)
+		s.Limits = &limits
 	}
-	return &ServerInformation{
-		Name:  serverDefinition.GetName(),
-		Image: serverDefinition.GetImage(),
-		Ports: serverDefinition.GetPorts(),
-		Env:   serverDefinition.GetEnv(),
-	}, nil
+	return s
 }
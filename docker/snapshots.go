@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+// SnapshotLabel records the snapshot an image or volume belongs to.
+const SnapshotLabel = "snapshot"
+
+// snapshotRepository is the image repository of all snapshots; the tag is the snapshot name.
+const snapshotRepository = "simple-test-server-snapshot"
+
+// snapshotNamePattern matches the snapshot names that are valid image tags.
+var snapshotNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// snapshotTimeout bounds committing a container and copying its volumes.
+const snapshotTimeout = 10 * time.Minute
+
+// The snapshot store functions are variables so tests can run without PocketBase.
+var (
+	createSnapshotFn = services.CreateSnapshot
+	listSnapshotsFn  = services.ListSnapshots
+	getSnapshotFn    = services.GetSnapshot
+	deleteSnapshotFn = services.DeleteSnapshot
+)
+
+// CreateSnapshot commits the managed container containerId to the image
+// simple-test-server-snapshot:<name> and copies each of its volumes into a volume owned by
+// the snapshot. The container keeps running; it is only paused while it is committed.
+func CreateSnapshot(ctx context.Context, containerId string, name string) (*dtos.Snapshot, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSnapshotName, name)
+	}
+	container, err := getContainerFn(containerId)
+	if err != nil {
+		return nil, err
+	}
+	if container.Status == dtos.Discarded || container.Status == dtos.Starting {
+		return nil, fmt.Errorf("%w: container %s is %s", ErrConflict, container.Name, container.Status)
+	}
+	if _, err := getSnapshotFn(name); err == nil {
+		return nil, fmt.Errorf("%w: snapshot %s already exists", ErrConflict, name)
+	} else if !errors.Is(err, services.ErrSnapshotNotFound) {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	labels := managedLabels()
+	labels[ServerTypeLabel] = container.Type
+	labels[SnapshotLabel] = name
+	image := snapshotRepository + ":" + name
+	if _, err := Runtime.CommitContainer(ctx, container.ID, CommitOptions{Repository: snapshotRepository, Tag: name, Labels: labels, Pause: true}); err != nil {
+		return nil, fmt.Errorf("commit container failed: %w", err)
+	}
+
+	snapshot := &dtos.Snapshot{
+		Name:          name,
+		Image:         image,
+		Type:          container.Type,
+		ContainerID:   container.ID,
+		ContainerName: container.Name,
+		Volumes:       map[string]string{},
+		CreatedAt:     time.Now().UnixMilli(),
+	}
+	for source, target := range container.Volumes {
+		volume := managedVolumeName(snapshotRepository+"-"+name, target)
+		if _, err := cloneVolume(ctx, source, volume, map[string]string{SnapshotLabel: name}); err != nil {
+			removeSnapshotResources(snapshot)
+			return nil, fmt.Errorf("snapshot volume %s failed: %w", source, err)
+		}
+		snapshot.Volumes[volume] = target
+	}
+
+	if err := createSnapshotFn(snapshot); err != nil {
+		removeSnapshotResources(snapshot)
+		return nil, err
+	}
+	log.Printf("Created snapshot %s of container %s", name, container.Name)
+	return snapshot, nil
+}
+
+// ListSnapshots returns all snapshots, newest first.
+func ListSnapshots() ([]*dtos.Snapshot, error) {
+	return listSnapshotsFn()
+}
+
+// GetSnapshot returns the snapshot name or an error wrapping services.ErrSnapshotNotFound.
+func GetSnapshot(name string) (*dtos.Snapshot, error) {
+	return getSnapshotFn(name)
+}
+
+// DeleteSnapshot removes the image, the volumes and the record of a snapshot. Snapshots
+// whose image is used by a container that has not been discarded are kept.
+func DeleteSnapshot(name string) error {
+	snapshot, err := getSnapshotFn(name)
+	if err != nil {
+		return err
+	}
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if r.Status != dtos.Discarded && r.Image == snapshot.Image {
+			return fmt.Errorf("%w: snapshot %s is used by %s", ErrConflict, name, r.Name)
+		}
+	}
+
+	removeSnapshotResources(snapshot)
+	return deleteSnapshotFn(name)
+}
+
+// removeSnapshotResources removes the image and volumes of a snapshot. Resources that are
+// already gone are ignored.
+func removeSnapshotResources(snapshot *dtos.Snapshot) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := Runtime.RemoveImage(ctx, snapshot.Image, false); err != nil && !errors.Is(err, ErrImageNotFound) {
+		log.Printf("Remove snapshot image %s failed: %v", snapshot.Image, err)
+	}
+	for volume := range snapshot.Volumes {
+		if err := Runtime.RemoveVolume(ctx, volume, false); err != nil && !errors.Is(err, ErrVolumeNotFound) {
+			log.Printf("Remove snapshot volume %s failed: %v", volume, err)
+		}
+	}
+}
+
+// restoreSnapshotVolumes clones the volumes of snapshot into volumes. The returned function
+// removes the clones if the container cannot be started.
+func restoreSnapshotVolumes(ctx context.Context, snapshot *dtos.Snapshot, volumes []VolumeSpec) ([]VolumeSpec, func(), error) {
+	requested := map[string]bool{}
+	for _, v := range volumes {
+		requested[v.Target] = true
+	}
+
+	clones := []string{}
+	cleanup := func() {
+		for _, name := range clones {
+			_ = Runtime.RemoveVolume(context.Background(), name, true)
+		}
+	}
+	out := append([]VolumeSpec{}, volumes...)
+	for source, target := range snapshot.Volumes {
+		if requested[target] {
+			continue
+		}
+		suffix, err := nameSuffixFn()
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		clone, err := cloneVolume(ctx, source, source+"-"+suffix, nil)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("restore volume %s failed: %w", source, err)
+		}
+		clones = append(clones, clone.Name)
+		out = append(out, VolumeSpec{Source: clone.Name, Target: target})
+	}
+	return out, cleanup, nil
+}
@@ -0,0 +1,200 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+// fakeSnapshots is an in-memory replacement for the snapshots collection.
+type fakeSnapshots struct {
+	mu        sync.Mutex
+	snapshots map[string]*dtos.Snapshot
+}
+
+func useFakeSnapshots(t *testing.T, snapshots ...*dtos.Snapshot) *fakeSnapshots {
+	t.Helper()
+	f := &fakeSnapshots{snapshots: map[string]*dtos.Snapshot{}}
+	for _, s := range snapshots {
+		f.snapshots[s.Name] = s
+	}
+
+	oldCreate, oldList, oldGet, oldDelete := createSnapshotFn, listSnapshotsFn, getSnapshotFn, deleteSnapshotFn
+	createSnapshotFn = func(s *dtos.Snapshot) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.snapshots[s.Name] = s
+		return nil
+	}
+	listSnapshotsFn = func() ([]*dtos.Snapshot, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		out := []*dtos.Snapshot{}
+		for _, s := range f.snapshots {
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	getSnapshotFn = func(name string) (*dtos.Snapshot, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		s, ok := f.snapshots[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", services.ErrSnapshotNotFound, name)
+		}
+		return s, nil
+	}
+	deleteSnapshotFn = func(name string) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.snapshots, name)
+		return nil
+	}
+	t.Cleanup(func() {
+		createSnapshotFn, listSnapshotsFn, getSnapshotFn, deleteSnapshotFn = oldCreate, oldList, oldGet, oldDelete
+	})
+	return f
+}
+
+func ftpVolumeLabels() map[string]string {
+	return map[string]string{ManagedByLabel: ManagedByValue, ServerTypeLabel: "FTP", VolumeTargetLabel: "/home/user"}
+}
+
+func TestCreateSnapshot_CommitsContainerAndVolumes(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("ftp-1", "ftp-1", "ftp")
+	rt.CreateVolume(context.Background(), "ftp-1-home-user", ftpVolumeLabels())
+	useFakeRecords(t, &dtos.Container{ID: "ftp-1", Name: "ftp-1", Type: "FTP", Status: dtos.Running, Volumes: map[string]string{"ftp-1-home-user": "/home/user"}})
+	store := useFakeSnapshots(t)
+
+	snapshot, err := CreateSnapshot(context.Background(), "ftp-1", "with-files")
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if snapshot.Image != "simple-test-server-snapshot:with-files" || snapshot.Type != "FTP" || snapshot.ContainerName != "ftp-1" {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	if len(rt.Commits) != 1 || !rt.Commits[0].Pause || rt.Commits[0].Labels[SnapshotLabel] != "with-files" || rt.Commits[0].Labels[ServerTypeLabel] != "FTP" {
+		t.Fatalf("unexpected commits %+v", rt.Commits)
+	}
+	volume := rt.Volumes["simple-test-server-snapshot-with-files-home-user"]
+	if volume == nil || volume.Labels[SnapshotLabel] != "with-files" || snapshot.Volumes[volume.Name] != "/home/user" {
+		t.Fatalf("expected snapshot volume, got %+v (snapshot volumes %v)", rt.Volumes, snapshot.Volumes)
+	}
+	if store.snapshots["with-files"] == nil {
+		t.Fatal("expected the snapshot to be stored")
+	}
+}
+
+func TestCreateSnapshot_RejectsInvalidRequests(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("ftp-1", "ftp-1", "ftp")
+	useFakeRecords(t,
+		&dtos.Container{ID: "ftp-1", Name: "ftp-1", Type: "FTP", Status: dtos.Running},
+		&dtos.Container{ID: "ftp-2", Name: "ftp-2", Type: "FTP", Status: dtos.Discarded},
+	)
+	useFakeSnapshots(t, &dtos.Snapshot{Name: "taken", Type: "FTP"})
+
+	cases := []struct {
+		container, name string
+		want            error
+	}{
+		{"ftp-1", "no spaces", ErrInvalidSnapshotName},
+		{"ftp-1", "", ErrInvalidSnapshotName},
+		{"ftp-1", "taken", ErrConflict},
+		{"ftp-2", "discarded", ErrConflict},
+		{"missing", "missing", ErrContainerNotFound},
+	}
+	for _, tc := range cases {
+		if _, err := CreateSnapshot(context.Background(), tc.container, tc.name); !errors.Is(err, tc.want) {
+			t.Errorf("%s/%q: expected %v, got %v", tc.container, tc.name, tc.want, err)
+		}
+	}
+	if len(rt.Commits) != 0 {
+		t.Fatalf("no container should be committed, got %+v", rt.Commits)
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.Images["simple-test-server-snapshot:base"] = true
+	labels := ftpVolumeLabels()
+	labels[SnapshotLabel] = "base"
+	rt.CreateVolume(context.Background(), "simple-test-server-snapshot-base-home-user", labels)
+	records := useFakeRecords(t, &dtos.Container{ID: "ftp-3", Name: "ftp-3", Image: "simple-test-server-snapshot:base", Status: dtos.Running})
+	store := useFakeSnapshots(t, &dtos.Snapshot{
+		Name:    "base",
+		Image:   "simple-test-server-snapshot:base",
+		Type:    "FTP",
+		Volumes: map[string]string{"simple-test-server-snapshot-base-home-user": "/home/user"},
+	})
+
+	if err := DeleteVolume(context.Background(), "simple-test-server-snapshot-base-home-user"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected snapshot volumes to be protected, got %v", err)
+	}
+	if err := DeleteSnapshot("base"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected snapshots in use to be kept, got %v", err)
+	}
+
+	records.get("ftp-3").Status = dtos.Discarded
+	if err := DeleteSnapshot("base"); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+	if rt.Images["simple-test-server-snapshot:base"] || len(rt.Volumes) != 0 || len(store.snapshots) != 0 {
+		t.Fatalf("expected image, volumes and record to be removed: images %v volumes %v", rt.Images, rt.Volumes)
+	}
+	if err := DeleteSnapshot("base"); !errors.Is(err, services.ErrSnapshotNotFound) {
+		t.Fatalf("expected ErrSnapshotNotFound, got %v", err)
+	}
+}
+
+func TestStartServerWithProgress_FromSnapshot(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	stubProbe(t, nil)
+	rt.Images["simple-test-server-snapshot:base"] = true
+	labels := ftpVolumeLabels()
+	labels[SnapshotLabel] = "base"
+	rt.CreateVolume(context.Background(), "simple-test-server-snapshot-base-home-user", labels)
+	useFakeSnapshots(t, &dtos.Snapshot{
+		Name:    "base",
+		Image:   "simple-test-server-snapshot:base",
+		Type:    "FTP",
+		Volumes: map[string]string{"simple-test-server-snapshot-base-home-user": "/home/user"},
+	})
+
+	StartServerWithProgress("req-snapshot-web", "WEB", ServerConfiguration{Snapshot: "base"})
+	events := collectEvents(t, "req-snapshot-web")
+	if last := events[len(events)-1]; !last.Error {
+		t.Fatalf("expected snapshots of another type to be rejected, got %+v", last)
+	}
+
+	StartServerWithProgress("req-snapshot", "FTP", ServerConfiguration{Snapshot: "base"})
+	events = collectEvents(t, "req-snapshot")
+	if last := events[len(events)-1]; last.Error || last.Percent != 100 {
+		t.Fatalf("unexpected final event: %+v (all: %+v)", last, events)
+	}
+	if len(rt.Pulled) != 1 || rt.Pulled[0] != volumeHelperImage {
+		t.Fatalf("only the volume helper should be pulled, got %v", rt.Pulled)
+	}
+	if len(*created) != 1 || (*created)[0].Image != "simple-test-server-snapshot:base" {
+		t.Fatalf("expected a container of the snapshot image, got %+v", *created)
+	}
+	var restored string
+	for name, target := range (*created)[0].Volumes {
+		if target == "/home/user" {
+			restored = name
+		}
+	}
+	if restored == "" || restored == "simple-test-server-snapshot-base-home-user" {
+		t.Fatalf("expected a clone of the snapshot volume, got %v", (*created)[0].Volumes)
+	}
+	if _, ok := rt.Volumes[restored].Labels[SnapshotLabel]; ok {
+		t.Fatalf("restored volumes must not belong to the snapshot: %v", rt.Volumes[restored].Labels)
+	}
+}
@@ -0,0 +1,212 @@
+package docker
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// statsInterval is how often the collector samples all running containers.
+var statsInterval = 5 * time.Second
+
+// statsHistoryWindow is how long samples are kept for graphs.
+const statsHistoryWindow = 15 * time.Minute
+
+// StatsSnapshot holds one sample of every running managed container and their sum.
+type StatsSnapshot struct {
+	Time       time.Time        `json:"time"`
+	Total      ContainerStats   `json:"total"`
+	Containers []ContainerStats `json:"containers"`
+}
+
+// statsCollector keeps the recent samples of each container and of the aggregate, and
+// fans new snapshots out to subscribers.
+type statsCollector struct {
+	mu          sync.Mutex
+	history     map[string][]ContainerStats
+	totals      []ContainerStats
+	subscribers map[chan StatsSnapshot]struct{}
+}
+
+var collector = newStatsCollector()
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		history:     map[string][]ContainerStats{},
+		subscribers: map[chan StatsSnapshot]struct{}{},
+	}
+}
+
+// RunStatsCollector samples the running managed containers until ctx is cancelled.
+func RunStatsCollector(ctx context.Context) {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, err := collectStats(ctx)
+			if err != nil {
+				log.Printf("Stats: %v", err)
+				continue
+			}
+			collector.add(snapshot)
+		}
+	}
+}
+
+// GetContainerStats returns a fresh sample of the managed container containerId.
+func GetContainerStats(ctx context.Context, containerId string) (*ContainerStats, error) {
+	container, err := getContainerFn(containerId)
+	if err != nil {
+		return nil, err
+	}
+	return Runtime.ContainerStats(ctx, container.ID)
+}
+
+// GetAggregatedStats returns a fresh sample of every running managed container.
+func GetAggregatedStats(ctx context.Context) (*StatsSnapshot, error) {
+	return collectStats(ctx)
+}
+
+// GetStatsHistory returns the samples of the last 15 minutes of the container containerId.
+func GetStatsHistory(containerId string) ([]ContainerStats, error) {
+	container, err := getContainerFn(containerId)
+	if err != nil {
+		return nil, err
+	}
+	return collector.samples(container.ID), nil
+}
+
+// GetAggregatedStatsHistory returns the summed samples of the last 15 minutes.
+func GetAggregatedStatsHistory() []ContainerStats {
+	return collector.samples("")
+}
+
+// SubscribeStats returns a channel receiving every snapshot taken by the collector. The
+// returned function ends the subscription.
+func SubscribeStats() (<-chan StatsSnapshot, func()) {
+	return collector.subscribe()
+}
+
+// collectStats samples all running managed containers in parallel.
+func collectStats(ctx context.Context) (*StatsSnapshot, error) {
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	snapshot := &StatsSnapshot{Time: time.Now(), Containers: []ContainerStats{}}
+	for _, record := range records {
+		if record.Status != dtos.Running {
+			continue
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			stats, err := Runtime.ContainerStats(ctx, id)
+			if err != nil {
+				log.Printf("Stats: container %s: %v", id, err)
+				return
+			}
+			mu.Lock()
+			snapshot.Containers = append(snapshot.Containers, *stats)
+			mu.Unlock()
+		}(record.ID)
+	}
+	wg.Wait()
+
+	sort.Slice(snapshot.Containers, func(i, j int) bool { return snapshot.Containers[i].Name < snapshot.Containers[j].Name })
+	snapshot.Total = sumStats(snapshot.Time, snapshot.Containers)
+	return snapshot, nil
+}
+
+// sumStats adds up the samples of several containers. The memory percentage is relative to
+// the summed limits.
+func sumStats(at time.Time, samples []ContainerStats) ContainerStats {
+	total := ContainerStats{Name: "total", Time: at}
+	for _, s := range samples {
+		total.CPUPercent += s.CPUPercent
+		total.MemoryUsage += s.MemoryUsage
+		total.MemoryLimit += s.MemoryLimit
+		total.NetworkRx += s.NetworkRx
+		total.NetworkTx += s.NetworkTx
+		total.BlockRead += s.BlockRead
+		total.BlockWrite += s.BlockWrite
+		total.Pids += s.Pids
+	}
+	if total.MemoryLimit > 0 {
+		total.MemoryPercent = float64(total.MemoryUsage) / float64(total.MemoryLimit) * 100
+	}
+	return total
+}
+
+// add stores snapshot, drops samples older than the history window and the history of
+// containers that are no longer sampled, and notifies subscribers.
+func (c *statsCollector) add(snapshot *StatsSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := snapshot.Time.Add(-statsHistoryWindow)
+	seen := map[string]bool{}
+	for _, s := range snapshot.Containers {
+		seen[s.ID] = true
+		c.history[s.ID] = trimStats(append(c.history[s.ID], s), cutoff)
+	}
+	for id, samples := range c.history {
+		if !seen[id] {
+			if samples = trimStats(samples, cutoff); len(samples) == 0 {
+				delete(c.history, id)
+			} else {
+				c.history[id] = samples
+			}
+		}
+	}
+	c.totals = trimStats(append(c.totals, snapshot.Total), cutoff)
+
+	for ch := range c.subscribers {
+		select {
+		case ch <- *snapshot:
+		default:
+			// slow subscriber, it gets the next snapshot
+		}
+	}
+}
+
+func (c *statsCollector) samples(containerId string) []ContainerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if containerId == "" {
+		return append([]ContainerStats{}, c.totals...)
+	}
+	return append([]ContainerStats{}, c.history[containerId]...)
+}
+
+func (c *statsCollector) subscribe() (<-chan StatsSnapshot, func()) {
+	ch := make(chan StatsSnapshot, 1)
+	c.mu.Lock()
+	c.subscribers[ch] = struct{}{}
+	c.mu.Unlock()
+	return ch, func() {
+		c.mu.Lock()
+		delete(c.subscribers, ch)
+		c.mu.Unlock()
+	}
+}
+
+// trimStats drops the samples taken before cutoff; samples are ordered by time.
+func trimStats(samples []ContainerStats, cutoff time.Time) []ContainerStats {
+	i := 0
+	for i < len(samples) && samples[i].Time.Before(cutoff) {
+		i++
+	}
+	return samples[i:]
+}
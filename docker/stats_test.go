@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+func TestGetAggregatedStats_SumsRunningContainers(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.AddContainer("a", "a", "nginx")
+	rt.AddContainer("b", "b", "nginx")
+	rt.AddContainer("c", "c", "nginx")
+	rt.Stats["a"] = ContainerStats{CPUPercent: 10, MemoryUsage: 100, MemoryLimit: 1000, NetworkRx: 5}
+	rt.Stats["b"] = ContainerStats{CPUPercent: 30, MemoryUsage: 300, MemoryLimit: 1000, NetworkRx: 7}
+	useFakeRecords(t,
+		&dtos.Container{ID: "a", Status: dtos.Running},
+		&dtos.Container{ID: "b", Status: dtos.Running},
+		&dtos.Container{ID: "c", Status: dtos.Stopped},
+	)
+
+	snapshot, err := GetAggregatedStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetAggregatedStats: %v", err)
+	}
+	if len(snapshot.Containers) != 2 || snapshot.Containers[0].ID != "a" {
+		t.Fatalf("expected samples of the running containers, got %+v", snapshot.Containers)
+	}
+	total := snapshot.Total
+	if total.CPUPercent != 40 || total.MemoryUsage != 400 || total.MemoryPercent != 20 || total.NetworkRx != 12 {
+		t.Fatalf("unexpected total: %+v", total)
+	}
+}
+
+func TestStatsCollector_KeepsFifteenMinutes(t *testing.T) {
+	c := newStatsCollector()
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		c.add(&StatsSnapshot{
+			Time:       at,
+			Total:      ContainerStats{Time: at},
+			Containers: []ContainerStats{{ID: "a", Time: at}},
+		})
+	}
+	if got := len(c.samples("a")); got != 16 {
+		t.Fatalf("expected 16 samples within 15 minutes, got %d", got)
+	}
+	if got := len(c.samples("")); got != 16 {
+		t.Fatalf("expected 16 aggregated samples, got %d", got)
+	}
+
+	// a container that is no longer sampled loses its history once it ages out
+	at := start.Add(40 * time.Minute)
+	c.add(&StatsSnapshot{Time: at, Total: ContainerStats{Time: at}})
+	if got := len(c.samples("a")); got != 0 {
+		t.Fatalf("expected history of removed container to expire, got %d samples", got)
+	}
+}
+
+func TestSubscribeStats_ReceivesSnapshots(t *testing.T) {
+	c := newStatsCollector()
+	ch, unsubscribe := c.subscribe()
+
+	c.add(&StatsSnapshot{Time: time.Now(), Containers: []ContainerStats{{ID: "a"}}})
+	select {
+	case snapshot := <-ch:
+		if len(snapshot.Containers) != 1 {
+			t.Fatalf("unexpected snapshot %+v", snapshot)
+		}
+	default:
+		t.Fatal("expected a snapshot")
+	}
+
+	unsubscribe()
+	c.add(&StatsSnapshot{Time: time.Now()})
+	select {
+	case <-ch:
+		t.Fatal("unsubscribed channel must not receive snapshots")
+	default:
+	}
+}
@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// demuxReader strips the frame headers of the stdout/stderr stream of containers without a
+// TTY. Other streams are passed through unchanged.
+type demuxReader struct {
+	src       io.Reader
+	raw       io.Reader
+	started   bool
+	remaining int
+	header    [8]byte
+}
+
+func (d *demuxReader) Read(p []byte) (int, error) {
+	for d.remaining == 0 && d.raw == nil {
+		n, err := io.ReadFull(d.src, d.header[:])
+		if !d.started {
+			d.started = true
+			if n > 0 && (n < len(d.header) || !validFrameHeader(d.header)) {
+				d.raw = io.MultiReader(bytes.NewReader(append([]byte(nil), d.header[:n]...)), d.src)
+				break
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		d.remaining = int(binary.BigEndian.Uint32(d.header[4:]))
+	}
+
+	if d.raw != nil {
+		return d.raw.Read(p)
+	}
+	if len(p) > d.remaining {
+		p = p[:d.remaining]
+	}
+	n, err := d.src.Read(p)
+	d.remaining -= n
+	return n, err
+}
+
+// validFrameHeader reports whether h looks like a stdin/stdout/stderr frame header.
+func validFrameHeader(h [8]byte) bool {
+	return h[0] <= 2 && h[1] == 0 && h[2] == 0 && h[3] == 0
+}
+
+// demuxReadCloser closes the underlying response body of a demultiplexed stream.
+type demuxReadCloser struct {
+	demuxReader
+	closer io.Closer
+}
+
+func (d *demuxReadCloser) Close() error {
+	return d.closer.Close()
+}
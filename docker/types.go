@@ -2,15 +2,51 @@ package docker
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
 // sentinel errors for docker operations
 var (
-	ErrContainerNotFound   = errors.New("container not found")
-	ErrContainerNotRunning = errors.New("container not running")
+	ErrContainerNotFound    = errors.New("container not found")
+	ErrContainerNotRunning  = errors.New("container not running")
+	ErrImageNotFound        = errors.New("image not found")
+	ErrConflict             = errors.New("conflict with existing container state")
+	ErrRuntimeUnavailable   = errors.New("container runtime unavailable")
+	ErrInvalidPath          = errors.New("invalid path")
+	ErrVolumeNotFound       = errors.New("volume not found")
+	ErrInvalidVolumeName    = errors.New("invalid volume name")
+	ErrVolumeTypeMismatch   = errors.New("volume belongs to another server type")
+	ErrNetworkNotFound      = errors.New("network not found")
+	ErrInvalidNetworkName   = errors.New("invalid network name")
+	ErrPortInUse            = errors.New("port already in use")
+	ErrInvalidLimits        = errors.New("invalid resource limits")
+	ErrInvalidContainerName = errors.New("invalid container name")
+	ErrCommandNotAllowed    = errors.New("command not allowed")
+	ErrInvalidSnapshotName  = errors.New("invalid snapshot name")
+	ErrInvalidPreset        = errors.New("invalid preset")
+	ErrReadOnlyPreset       = errors.New("built-in presets are read-only")
+	ErrInvalidEnvironment   = errors.New("invalid environment")
+	ErrInvalidDefinition    = errors.New("invalid definition")
 )
 
+// RuntimeError is returned when the container runtime rejects a request.
+// It wraps one of the sentinel errors above when the failure maps to one.
+type RuntimeError struct {
+	Op         string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s failed (status %d): %s", e.Op, e.StatusCode, e.Message)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
 // FileEntry represents a file or directory inside a container
 type FileEntry struct {
 	Name       string    `json:"name"`
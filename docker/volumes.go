@@ -0,0 +1,298 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// VolumeTargetLabel records the container path a managed volume was created for.
+const VolumeTargetLabel = "volume_target"
+
+// volumeHelperImage runs the copy when a volume is cloned.
+const volumeHelperImage = "busybox:stable"
+
+// resourceNamePattern matches the container, volume and network names accepted by Docker and Podman.
+var resourceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// VolumeSpec requests a volume mounted at Target. Without Source a managed volume is
+// created for the container; with Source the named volume (e.g. a clone) is reused.
+type VolumeSpec struct {
+	Source   string `json:"source,omitempty"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// VolumeDetails is a managed volume as returned by the volumes API.
+type VolumeDetails struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Mountpoint string            `json:"mountpoint"`
+	Labels     map[string]string `json:"labels"`
+	CreatedAt  int64             `json:"created_at"`
+	ServerType string            `json:"server_type"`
+	Target     string            `json:"target"`
+	UsedBy     []string          `json:"used_by"`
+}
+
+// resolveMounts creates the managed volumes for a new container and validates reused ones.
+// The returned function removes the created volumes if the container cannot be started.
+func resolveMounts(ctx context.Context, containerName string, cType string, defaults []string, specs []VolumeSpec) ([]Mount, map[string]string, func(), error) {
+	requested := make([]VolumeSpec, 0, len(specs)+len(defaults))
+	targets := map[string]bool{}
+	for _, spec := range specs {
+		target := path.Clean(spec.Target)
+		if !path.IsAbs(target) || target == "/" {
+			return nil, nil, nil, fmt.Errorf("%w: volume target %q must be an absolute path", ErrInvalidPath, spec.Target)
+		}
+		if targets[target] {
+			return nil, nil, nil, fmt.Errorf("%w: volume target %q is used twice", ErrInvalidPath, target)
+		}
+		targets[target] = true
+		spec.Target = target
+		requested = append(requested, spec)
+	}
+	for _, target := range defaults {
+		if !targets[target] {
+			targets[target] = true
+			requested = append(requested, VolumeSpec{Target: target})
+		}
+	}
+
+	created := []string{}
+	cleanup := func() {
+		for _, name := range created {
+			_ = Runtime.RemoveVolume(context.Background(), name, true)
+		}
+	}
+	mounts := make([]Mount, 0, len(requested))
+	volumes := make(map[string]string, len(requested))
+	for _, spec := range requested {
+		name := spec.Source
+		if name == "" {
+			name = managedVolumeName(containerName, spec.Target)
+			// a volume left behind by an earlier container of the same name is reused, not removed
+			_, err := Runtime.InspectVolume(ctx, name)
+			existed := err == nil
+			labels := managedLabels()
+			labels[ServerTypeLabel] = cType
+			labels[VolumeTargetLabel] = spec.Target
+			if _, err := Runtime.CreateVolume(ctx, name, labels); err != nil {
+				cleanup()
+				return nil, nil, nil, fmt.Errorf("create volume %s failed: %w", name, err)
+			}
+			if !existed {
+				created = append(created, name)
+			}
+		} else {
+			info, err := Runtime.InspectVolume(ctx, name)
+			if err != nil {
+				cleanup()
+				return nil, nil, nil, fmt.Errorf("volume %s: %w", name, err)
+			}
+			if t, ok := info.Labels[ServerTypeLabel]; ok && t != cType {
+				cleanup()
+				return nil, nil, nil, fmt.Errorf("%w: %s was created for %s", ErrVolumeTypeMismatch, name, t)
+			}
+		}
+		mounts = append(mounts, Mount{Source: name, Target: spec.Target, ReadOnly: spec.ReadOnly})
+		volumes[name] = spec.Target
+	}
+	return mounts, volumes, cleanup, nil
+}
+
+// managedVolumeName derives the volume name for target of the given container,
+// e.g. simple-test-server-web-0-usr-share-nginx-html.
+func managedVolumeName(containerName string, target string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, strings.Trim(target, "/"))
+	return containerName + "-" + slug
+}
+
+// ListVolumes returns all managed volumes together with the containers using them.
+func ListVolumes(ctx context.Context) ([]VolumeDetails, error) {
+	volumes, err := Runtime.ListVolumes(ctx, managedLabels())
+	if err != nil {
+		return nil, fmt.Errorf("list volumes failed: %w", err)
+	}
+	users := volumeUsers()
+
+	out := make([]VolumeDetails, 0, len(volumes))
+	for _, v := range volumes {
+		out = append(out, volumeDetails(v, users))
+	}
+	return out, nil
+}
+
+// GetVolume returns a single managed volume.
+func GetVolume(ctx context.Context, name string) (*VolumeDetails, error) {
+	info, err := inspectManagedVolume(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	details := volumeDetails(*info, volumeUsers())
+	return &details, nil
+}
+
+// CloneVolume copies the content of the managed volume source into a new volume named
+// target. An empty target derives a name from source. The clone keeps the server type
+// of source, so it can be mounted by a new container of the same type.
+func CloneVolume(ctx context.Context, source string, target string) (*VolumeDetails, error) {
+	if target == "" {
+		target = source + "-clone-" + time.Now().Format("20060102150405")
+	}
+	return cloneVolume(ctx, source, target, nil)
+}
+
+// cloneVolume copies source into the new volume target. A clone never inherits the snapshot
+// label, so it can be deleted like any other volume.
+func cloneVolume(ctx context.Context, source string, target string, extra map[string]string) (*VolumeDetails, error) {
+	info, err := inspectManagedVolume(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if !resourceNamePattern.MatchString(target) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVolumeName, target)
+	}
+	if _, err := Runtime.InspectVolume(ctx, target); err == nil {
+		return nil, fmt.Errorf("%w: volume %s already exists", ErrConflict, target)
+	}
+
+	if err := CheckAndPullImage(ctx, volumeHelperImage, nil); err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for k, v := range info.Labels {
+		labels[k] = v
+	}
+	delete(labels, SnapshotLabel)
+	labels["cloned_from"] = source
+	for k, v := range extra {
+		labels[k] = v
+	}
+	clone, err := Runtime.CreateVolume(ctx, target, labels)
+	if err != nil {
+		return nil, fmt.Errorf("create volume %s failed: %w", target, err)
+	}
+
+	if err := copyVolume(ctx, source, target); err != nil {
+		_ = Runtime.RemoveVolume(context.Background(), target, true)
+		return nil, err
+	}
+
+	log.Printf("Cloned volume %s to %s", source, target)
+	details := volumeDetails(*clone, nil)
+	return &details, nil
+}
+
+// copyVolume copies all files from one volume to another with a short-lived helper container.
+func copyVolume(ctx context.Context, source string, target string) error {
+	helperId, err := Runtime.RunContainer(ctx, ContainerSpec{
+		Image: volumeHelperImage,
+		Cmd:   []string{"sh", "-c", "cp -a /from/. /to/"},
+		Mounts: []Mount{
+			{Source: source, Target: "/from", ReadOnly: true},
+			{Source: target, Target: "/to"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("start copy container failed: %w", err)
+	}
+	defer func() {
+		_ = Runtime.RemoveContainer(context.Background(), helperId, true)
+	}()
+
+	exitCode, err := Runtime.WaitContainer(ctx, helperId)
+	if err != nil {
+		return fmt.Errorf("copy volume failed: %w", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("copy volume failed with exit code %d", exitCode)
+	}
+	return nil
+}
+
+// DeleteVolume removes a managed volume that is not used by any container or snapshot.
+func DeleteVolume(ctx context.Context, name string) error {
+	info, err := inspectManagedVolume(ctx, name)
+	if err != nil {
+		return err
+	}
+	if snapshot, ok := info.Labels[SnapshotLabel]; ok {
+		return fmt.Errorf("%w: volume %s belongs to snapshot %s", ErrConflict, name, snapshot)
+	}
+	if users := volumeUsers()[name]; len(users) > 0 {
+		return fmt.Errorf("%w: volume %s is used by %s", ErrConflict, name, strings.Join(users, ", "))
+	}
+	if err := Runtime.RemoveVolume(ctx, name, false); err != nil {
+		return fmt.Errorf("remove volume failed: %w", err)
+	}
+	return nil
+}
+
+// inspectManagedVolume returns the volume name, or ErrVolumeNotFound if it is not managed
+// by the application.
+func inspectManagedVolume(ctx context.Context, name string) (*VolumeInfo, error) {
+	info, err := Runtime.InspectVolume(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !hasLabels(info.Labels, managedLabels()) {
+		return nil, fmt.Errorf("%w: %s is not managed", ErrVolumeNotFound, name)
+	}
+	return info, nil
+}
+
+// volumeUsers maps volume names to the names of the containers using them.
+// Discarded containers do not hold on to their volumes.
+func volumeUsers() map[string][]string {
+	users := map[string][]string{}
+	records, err := listContainerRecordsFn()
+	if err != nil {
+		log.Printf("List containers failed: %v", err)
+		return users
+	}
+	for _, r := range records {
+		if r.Status == dtos.Discarded {
+			continue
+		}
+		for name := range r.Volumes {
+			users[name] = append(users[name], r.Name)
+		}
+	}
+	return users
+}
+
+func volumeDetails(v VolumeInfo, users map[string][]string) VolumeDetails {
+	usedBy := users[v.Name]
+	if usedBy == nil {
+		usedBy = []string{}
+	}
+	var created int64
+	if !v.CreatedAt.IsZero() {
+		created = v.CreatedAt.UnixMilli()
+	}
+	return VolumeDetails{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		Labels:     v.Labels,
+		CreatedAt:  created,
+		ServerType: v.Labels[ServerTypeLabel],
+		Target:     v.Labels[VolumeTargetLabel],
+		UsedBy:     usedBy,
+	}
+}
@@ -0,0 +1,157 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+func TestRunContainer_CreatesManagedVolumes(t *testing.T) {
+	rt := useFakeRuntime(t)
+	created := captureCreatedContainers(t)
+	rt.Images["nginx"] = true
+	rt.CreateVolume(context.Background(), "ftp-data", map[string]string{ManagedByLabel: ManagedByValue, ServerTypeLabel: "WEB"})
+
+	config := ServerConfiguration{
+		Name:    "web-1",
+		Volumes: []VolumeSpec{{Source: "ftp-data", Target: "/srv/data/", ReadOnly: true}},
+	}
+	if _, err := RunContainer(config, "WEB", "nginx", "web", []int{80}, nil, []string{"/usr/share/nginx/html"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	managed := rt.Volumes["web-1-usr-share-nginx-html"]
+	if managed == nil || managed.Labels[ServerTypeLabel] != "WEB" || managed.Labels[VolumeTargetLabel] != "/usr/share/nginx/html" {
+		t.Fatalf("expected managed volume for the default path, got %+v", rt.Volumes)
+	}
+	mounts := rt.Runs[0].Mounts
+	if len(mounts) != 2 || mounts[0] != (Mount{Source: "ftp-data", Target: "/srv/data", ReadOnly: true}) || mounts[1].Source != "web-1-usr-share-nginx-html" {
+		t.Fatalf("unexpected mounts %+v", mounts)
+	}
+	if v := (*created)[0].Volumes; v["ftp-data"] != "/srv/data" || v["web-1-usr-share-nginx-html"] != "/usr/share/nginx/html" {
+		t.Fatalf("unexpected stored volumes %v", v)
+	}
+}
+
+func TestRunContainer_FailureRemovesCreatedVolumes(t *testing.T) {
+	rt := useFakeRuntime(t)
+	captureCreatedContainers(t)
+	rt.Images["nginx"] = true
+	rt.CreateVolume(context.Background(), "web-1-data", map[string]string{ManagedByLabel: ManagedByValue, ServerTypeLabel: "WEB"})
+	rt.ErrRun = errors.New("boom")
+
+	config := ServerConfiguration{Name: "web-1"}
+	if _, err := RunContainer(config, "WEB", "nginx", "web", nil, nil, []string{"/usr/share/nginx/html", "/data"}, nil); err == nil {
+		t.Fatalf("expected the run to fail")
+	}
+	if _, ok := rt.Volumes["web-1-usr-share-nginx-html"]; ok {
+		t.Fatalf("the volume created for the failed container must be removed")
+	}
+	if _, ok := rt.Volumes["web-1-data"]; !ok {
+		t.Fatalf("an existing volume must be kept")
+	}
+}
+
+func TestRunContainer_RejectsInvalidVolumes(t *testing.T) {
+	rt := useFakeRuntime(t)
+	captureCreatedContainers(t)
+	rt.Images["nginx"] = true
+	rt.CreateVolume(context.Background(), "mqtt-data", map[string]string{ManagedByLabel: ManagedByValue, ServerTypeLabel: "MQTT"})
+
+	cases := []struct {
+		volume VolumeSpec
+		want   error
+	}{
+		{VolumeSpec{Source: "mqtt-data", Target: "/data"}, ErrVolumeTypeMismatch},
+		{VolumeSpec{Source: "missing", Target: "/data"}, ErrVolumeNotFound},
+		{VolumeSpec{Target: "relative/path"}, ErrInvalidPath},
+	}
+	for _, tc := range cases {
+		_, err := RunContainer(ServerConfiguration{Volumes: []VolumeSpec{tc.volume}}, "WEB", "nginx", "web", nil, nil, nil, nil)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%+v: expected %v, got %v", tc.volume, tc.want, err)
+		}
+	}
+	if len(rt.Runs) != 0 {
+		t.Fatalf("no container should be started with invalid volumes")
+	}
+}
+
+func TestCloneVolume(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakeRecords(t)
+	labels := map[string]string{ManagedByLabel: ManagedByValue, ServerTypeLabel: "FTP", VolumeTargetLabel: "/home/user"}
+	rt.CreateVolume(context.Background(), "ftp-0-home-user", labels)
+
+	clone, err := CloneVolume(context.Background(), "ftp-0-home-user", "ftp-copy")
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if clone.Name != "ftp-copy" || clone.ServerType != "FTP" || clone.Target != "/home/user" || clone.Labels["cloned_from"] != "ftp-0-home-user" {
+		t.Fatalf("unexpected clone %+v", clone)
+	}
+	if len(rt.Runs) != 1 || rt.Runs[0].Image != volumeHelperImage {
+		t.Fatalf("expected one helper container, got %+v", rt.Runs)
+	}
+	if m := rt.Runs[0].Mounts; m[0].Source != "ftp-0-home-user" || !m[0].ReadOnly || m[1].Source != "ftp-copy" {
+		t.Fatalf("unexpected helper mounts %+v", m)
+	}
+	if len(rt.Containers) != 0 {
+		t.Fatalf("helper container must be removed")
+	}
+
+	if _, err := CloneVolume(context.Background(), "ftp-0-home-user", "ftp-copy"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for existing target, got %v", err)
+	}
+	if _, err := CloneVolume(context.Background(), "ftp-0-home-user", "../etc"); !errors.Is(err, ErrInvalidVolumeName) {
+		t.Fatalf("expected ErrInvalidVolumeName, got %v", err)
+	}
+}
+
+func TestCloneVolume_CopyFailureRemovesClone(t *testing.T) {
+	rt := useFakeRuntime(t)
+	useFakeRecords(t)
+	rt.CreateVolume(context.Background(), "src", managedLabels())
+	rt.WaitFn = func(c *FakeContainer) (int, error) { return 1, nil }
+
+	if _, err := CloneVolume(context.Background(), "src", "dst"); err == nil {
+		t.Fatalf("expected copy failure")
+	}
+	if _, ok := rt.Volumes["dst"]; ok {
+		t.Fatalf("failed clone must be removed")
+	}
+}
+
+func TestDeleteVolume(t *testing.T) {
+	rt := useFakeRuntime(t)
+	rt.CreateVolume(context.Background(), "used", managedLabels())
+	rt.CreateVolume(context.Background(), "free", managedLabels())
+	rt.CreateVolume(context.Background(), "foreign", map[string]string{})
+	useFakeRecords(t,
+		&dtos.Container{ID: "web", Name: "web", Status: dtos.Stopped, Volumes: map[string]string{"used": "/data"}},
+		&dtos.Container{ID: "old", Name: "old", Status: dtos.Discarded, Volumes: map[string]string{"free": "/data"}},
+	)
+
+	if err := DeleteVolume(context.Background(), "used"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a volume in use, got %v", err)
+	}
+	if err := DeleteVolume(context.Background(), "foreign"); !errors.Is(err, ErrVolumeNotFound) {
+		t.Fatalf("unmanaged volumes must not be deleted, got %v", err)
+	}
+	if err := DeleteVolume(context.Background(), "free"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := rt.Volumes["free"]; ok {
+		t.Fatalf("volume should have been removed")
+	}
+
+	volumes, err := ListVolumes(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].Name != "used" || len(volumes[0].UsedBy) != 1 || volumes[0].UsedBy[0] != "web" {
+		t.Fatalf("unexpected volumes %+v", volumes)
+	}
+}
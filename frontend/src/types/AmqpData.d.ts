@@ -0,0 +1,79 @@
+export type AmqpExchange = {
+    name: string;
+    vhost: string;
+    type: string;
+    durable: boolean;
+    auto_delete: boolean;
+    internal: boolean;
+    arguments: Record<string, unknown>;
+};
+
+export type AmqpQueue = {
+    name: string;
+    vhost: string;
+    durable: boolean;
+    auto_delete: boolean;
+    exclusive: boolean;
+    state: string;
+    messages: number;
+    messages_ready: number;
+    messages_unacknowledged: number;
+    consumers: number;
+    arguments: Record<string, unknown>;
+};
+
+export type AmqpBinding = {
+    source: string;
+    vhost: string;
+    destination: string;
+    destination_type: 'queue' | 'exchange';
+    routing_key: string;
+    arguments: Record<string, unknown>;
+};
+
+export type AmqpProperties = {
+    content_type?: string;
+    content_encoding?: string;
+    headers?: Record<string, unknown>;
+    delivery_mode?: 1 | 2;
+    priority?: number;
+    correlation_id?: string;
+    reply_to?: string;
+    expiration?: string;
+    message_id?: string;
+    timestamp?: string;
+    type?: string;
+    user_id?: string;
+    app_id?: string;
+};
+
+export type AmqpPublishRequest = {
+    vhost?: string;
+    exchange: string;
+    routing_key: string;
+    payload: string;
+    properties?: AmqpProperties;
+};
+
+export type AmqpPublishResult = {
+    routed: boolean;
+};
+
+export type AmqpPeekedMessage = {
+    exchange: string;
+    routing_key: string;
+    redelivered: boolean;
+    message_count: number;
+    payload: string;
+    payload_encoding: 'string' | 'base64';
+    payload_bytes: number;
+    properties: Record<string, unknown>;
+};
+
+export type AmqpDelivery = {
+    exchange: string;
+    routing_key: string;
+    redelivered: boolean;
+    payload: string;
+    properties: AmqpProperties;
+};
@@ -1,4 +1,4 @@
-import type { ServerType } from "./Server";
+import type { ResourceLimits, ServerType } from "./Server";
 
 
 export type Container = {
@@ -15,6 +15,13 @@ export type Container = {
     };
     networks: string[];
     type: ServerType;
+    exit_code: number;
+    finished_at: number;
+    orphaned: boolean;
+    expires_at: number;
+    limits?: ResourceLimits;
+    aliases?: string[];
+    snapshot?: string;
 }
 
 export { Container };
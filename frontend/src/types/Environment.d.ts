@@ -0,0 +1,20 @@
+import type { PresetConfiguration } from "./Preset";
+import type { ServerType } from "./Server";
+
+export type EnvironmentMember = {
+    name: string;
+    type: ServerType;
+    depends_on: string[];
+    configuration: PresetConfiguration;
+    container_id: string;
+}
+
+export type Environment = {
+    id: string;
+    name: string;
+    network: string;
+    members: EnvironmentMember[];
+    status: number;
+    error?: string;
+    created_at: number;
+}
@@ -0,0 +1,52 @@
+export type KafkaPartition = {
+    partition: number;
+    leader: number;
+    replicas: number[];
+    start_offset: number;
+    end_offset: number;
+};
+
+export type KafkaTopic = {
+    name: string;
+    internal: boolean;
+    partitions: KafkaPartition[];
+};
+
+export type KafkaPartitionLag = {
+    topic: string;
+    partition: number;
+    committed: number;
+    end: number;
+    lag: number;
+};
+
+export type KafkaConsumerGroup = {
+    group: string;
+    state: string;
+    members: number;
+    lag: number;
+    partitions: KafkaPartitionLag[];
+    error?: string;
+};
+
+export type KafkaHeader = {
+    key: string;
+    value: string;
+};
+
+export type KafkaProduceRequest = {
+    key?: string | null;
+    value: string;
+    headers?: KafkaHeader[];
+    partition?: number;
+};
+
+export type KafkaRecord = {
+    topic: string;
+    partition: number;
+    offset: number;
+    timestamp: string;
+    key: string | null;
+    value: string;
+    headers: KafkaHeader[];
+};
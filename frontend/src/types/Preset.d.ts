@@ -0,0 +1,33 @@
+import type { ResourceLimits, ServerType } from "./Server";
+
+export type PresetConfiguration = {
+    name?: string;
+    ports?: {
+        [hostPort: string]: number; // host port -> container port
+    }[];
+    env?: {
+        [key: string]: string;
+    };
+    volumes?: {
+        source?: string;
+        target: string;
+        read_only?: boolean;
+    }[];
+    network?: string;
+    aliases?: string[];
+    ttl_minutes?: number;
+    limits?: ResourceLimits;
+    snapshot?: string;
+    seed?: string;
+}
+
+export type Preset = {
+    id: string;
+    name: string;
+    type: ServerType;
+    description: string;
+    configuration: PresetConfiguration;
+    built_in: boolean;
+    created_at: number;
+    updated_at: number;
+}
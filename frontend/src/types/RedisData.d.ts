@@ -0,0 +1,31 @@
+export type RedisKeyType = 'string' | 'hash' | 'list' | 'set' | 'zset' | 'stream';
+
+export type RedisKeyInfo = {
+    key: string;
+    type: RedisKeyType;
+    ttl: number;
+};
+
+export type RedisKeyPage = {
+    keys: RedisKeyInfo[];
+    cursor: string;
+};
+
+export type RedisKeyValue = {
+    key: string;
+    type: RedisKeyType;
+    ttl: number;
+    length: number;
+    value: string
+        | { field: string; value: string }[]
+        | string[]
+        | { member: string; score: number }[]
+        | { id: string; values: Record<string, string> }[];
+    truncated: boolean;
+};
+
+export type RedisMessage = {
+    channel: string;
+    pattern: string;
+    payload: string;
+};
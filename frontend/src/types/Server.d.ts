@@ -2,13 +2,67 @@ import serverTypes from "@/lib/servers";
 
 export type ServerType = typeof serverTypes[number];
 
+export type ReadinessCheck = {
+    kind: 'tcp' | 'http' | 'mqtt' | 'smtp' | 'log';
+    port?: number;
+    path?: string;
+    pattern?: string;
+    timeout_seconds: number;
+};
+
+export type ResourceLimits = {
+    memory_mb?: number;
+    cpus?: number;
+    cpu_shares?: number;
+    pids_limit?: number;
+    ulimits?: {
+        name: string;
+        soft: number;
+        hard: number;
+    }[];
+};
+
+export type ConfigField = {
+    name: string;
+    type: 'string' | 'integer' | 'boolean' | 'port';
+    default?: string;
+    description?: string;
+    required?: boolean;
+    secret?: boolean;
+    enum?: string[];
+    port_range?: PortRange;
+};
+
+export type PortRange = {
+    min: number;
+    max: number;
+};
+
+export type ConfigSchema = {
+    env: ConfigField[];
+    ports: {
+        port: number;
+        description?: string;
+        port_range?: PortRange;
+    }[];
+    additional_env?: boolean;
+};
+
 export type ServerInformation = {
+    type: string;
     name: string;
     image: string;
+    build?: string;
     ports: number[];
     env: {
         [key: string]: string;
     };
+    volumes: string[];
+    readiness?: ReadinessCheck;
+    limits?: ResourceLimits;
+    exec_commands: string[];
+    schema: ConfigSchema;
+    capabilities: string[];
 };
 
 export { ServerType };
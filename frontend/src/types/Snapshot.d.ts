@@ -0,0 +1,13 @@
+import type { ServerType } from "./Server";
+
+export type Snapshot = {
+    name: string;
+    image: string;
+    type: ServerType;
+    container_id: string;
+    container_name: string;
+    volumes: {
+        [name: string]: string; // volume name -> container path
+    };
+    created_at: number;
+}
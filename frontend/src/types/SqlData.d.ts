@@ -0,0 +1,27 @@
+export type SqlTable = {
+    schema: string;
+    name: string;
+    type: string;
+};
+
+export type SqlColumn = {
+    name: string;
+    type: string;
+    nullable: boolean;
+    default: string | null;
+};
+
+export type SqlQueryRequest = {
+    database?: string;
+    query: string;
+    read_only?: boolean;
+    limit?: number;
+};
+
+export type SqlQueryResult = {
+    columns: string[];
+    rows: unknown[][];
+    rows_affected?: number;
+    truncated: boolean;
+    duration_ms: number;
+};
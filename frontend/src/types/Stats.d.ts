@@ -0,0 +1,20 @@
+export type ContainerStats = {
+    container_id: string;
+    name: string;
+    time: string; // RFC3339 timestamp
+    cpu_percent: number; // 100 per fully used CPU
+    memory_usage: number;
+    memory_limit: number;
+    memory_percent: number;
+    network_rx: number;
+    network_tx: number;
+    block_read: number;
+    block_write: number;
+    pids: number;
+}
+
+export type StatsSnapshot = {
+    time: string;
+    total: ContainerStats;
+    containers: ContainerStats[];
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/tim0-12432/simple-test-server/controllers"
 	"github.com/tim0-12432/simple-test-server/db"
 	"github.com/tim0-12432/simple-test-server/docker"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
 
 	// include migrations so they are registered and executed by PocketBase
 	_ "github.com/tim0-12432/simple-test-server/migrations"
@@ -28,8 +29,19 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	if err := servers.LoadCatalog(config.EnvConfig.CatalogDir); err != nil {
+		log.Fatalf("Server catalog: %v", err)
+	}
+
+	docker.InitializeRuntime()
+
 	db.InitializeDatabase()
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	docker.StartReconciler(backgroundCtx)
+	go docker.RunJanitor(backgroundCtx)
+	go docker.RunStatsCollector(backgroundCtx)
+
 	controllers.InitializeRoutes()
 
 	srv := &http.Server{
@@ -55,6 +67,8 @@ func main() {
 		log.Printf("Server Shutdown: %v", err)
 	}
 
+	// stop watching first so our own removals are not reported as orphans
+	stopBackground()
 	if err := docker.StopAllContainers(); err != nil {
 		log.Printf("Failed to stop Docker containers: %v", err)
 	}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// exit code, finish time and orphan flag are maintained by the container reconciler
+		coll, err := app.FindCollectionByNameOrId("containers")
+		if err != nil {
+			return err
+		}
+
+		coll.Fields.Add(&core.NumberField{Name: "exit_code", OnlyInt: true})
+		coll.Fields.Add(&core.NumberField{Name: "finished_at", OnlyInt: true})
+		coll.Fields.Add(&core.BoolField{Name: "orphaned"})
+
+		return app.Save(coll)
+	}, func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("containers")
+		if err != nil {
+			return err
+		}
+
+		coll.Fields.RemoveByName("exit_code")
+		coll.Fields.RemoveByName("finished_at")
+		coll.Fields.RemoveByName("orphaned")
+
+		return app.Save(coll)
+	}, "1760000000_add_container_state_fields.go")
+}
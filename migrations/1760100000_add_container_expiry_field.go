@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("containers")
+		if err != nil {
+			return err
+		}
+
+		coll.Fields.Add(&core.NumberField{Name: "expires_at", OnlyInt: true})
+
+		return app.Save(coll)
+	}, func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("containers")
+		if err != nil {
+			return err
+		}
+
+		coll.Fields.RemoveByName("expires_at")
+
+		return app.Save(coll)
+	}, "1760100000_add_container_expiry_field.go")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		coll := core.NewBaseCollection("snapshots")
+		coll.Fields.Add(
+			&core.TextField{Name: "name", Required: true},
+			&core.TextField{Name: "image", Required: true},
+			&core.TextField{Name: "type"},
+			&core.TextField{Name: "container_id"},
+			&core.TextField{Name: "container_name"},
+			&core.JSONField{Name: "volumes"},
+			&core.NumberField{Name: "created_at"},
+		)
+		coll.AddIndex("idx_snapshots_name", true, "name", "")
+
+		return app.Save(coll)
+	}, func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("snapshots")
+		if err != nil {
+			return err
+		}
+		return app.Delete(coll)
+	}, "1760300000_create_snapshots_collection.go")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		coll := core.NewBaseCollection("presets")
+		coll.Fields.Add(
+			&core.TextField{Name: "name", Required: true},
+			&core.TextField{Name: "type", Required: true},
+			&core.TextField{Name: "description"},
+			&core.JSONField{Name: "configuration"},
+			&core.NumberField{Name: "created_at"},
+			&core.NumberField{Name: "updated_at"},
+		)
+		// names are unique per type regardless of case
+		coll.AddIndex("idx_presets_type_name", true, "type, name COLLATE NOCASE", "")
+
+		return app.Save(coll)
+	}, func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("presets")
+		if err != nil {
+			return err
+		}
+		return app.Delete(coll)
+	}, "1760400000_create_presets_collection.go")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		coll := core.NewBaseCollection("environments")
+		coll.Fields.Add(
+			&core.TextField{Name: "name", Required: true},
+			&core.TextField{Name: "network"},
+			&core.JSONField{Name: "members"},
+			&core.TextField{Name: "status"},
+			&core.TextField{Name: "error"},
+			&core.NumberField{Name: "created_at"},
+		)
+		// a name can be used again once its environment is discarded
+		coll.AddIndex("idx_environments_name", true, "name", "status != 'discarded'")
+
+		return app.Save(coll)
+	}, func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("environments")
+		if err != nil {
+			return err
+		}
+		return app.Delete(coll)
+	}, "1760500000_create_environments_collection.go")
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("containers")
+		if err != nil {
+			return err
+		}
+
+		coll.Fields.Add(&core.JSONField{Name: "aliases"})
+		coll.Fields.Add(&core.TextField{Name: "snapshot"})
+
+		return app.Save(coll)
+	}, func(app core.App) error {
+		coll, err := app.FindCollectionByNameOrId("containers")
+		if err != nil {
+			return err
+		}
+
+		coll.Fields.RemoveByName("aliases")
+		coll.Fields.RemoveByName("snapshot")
+
+		return app.Save(coll)
+	}, "1760600000_add_container_aliases_and_snapshot_fields.go")
+}
@@ -21,7 +21,8 @@ var Default = &Hub{chans: map[string]chan Event{}}
 func (h *Hub) New(id string) chan Event {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	ch := make(chan Event, 16)
+	// large enough to hold the build output of a custom image until a client connects
+	ch := make(chan Event, 256)
 	if old, ok := h.chans[id]; ok {
 		close(old)
 	}
@@ -49,16 +50,29 @@ func (h *Hub) Remove(id string) {
 	}
 }
 
+// Send queues e for the stream id without blocking. When the stream is full, intermediate
+// events are dropped, while final events (errors and 100%) replace the oldest queued event,
+// so a client always learns how the request ended.
 func (h *Hub) Send(id string, e Event) {
+	// the lock keeps Remove from closing the channel during the send
 	h.mu.Lock()
+	defer h.mu.Unlock()
 	ch, ok := h.chans[id]
-	h.mu.Unlock()
 	if !ok {
 		return
 	}
-	select {
-	case ch <- e:
-	default:
-		// drop if full
+	for {
+		select {
+		case ch <- e:
+			return
+		default:
+		}
+		if !e.Error && e.Percent < 100 {
+			return
+		}
+		select {
+		case <-ch:
+		default:
+		}
 	}
 }
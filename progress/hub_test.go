@@ -0,0 +1,25 @@
+package progress
+
+import "testing"
+
+func TestSend_KeepsFinalEventsWhenFull(t *testing.T) {
+	h := &Hub{chans: map[string]chan Event{}}
+	ch := h.New("build")
+	defer h.Remove("build")
+
+	for i := 0; i < cap(ch)+10; i++ {
+		h.Send("build", Event{Percent: 30, Message: "Step"})
+	}
+	h.Send("build", Event{Percent: 100, Message: "Started"})
+
+	if len(ch) != cap(ch) {
+		t.Fatalf("expected a full stream, got %d events", len(ch))
+	}
+	var last Event
+	for len(ch) > 0 {
+		last = <-ch
+	}
+	if last.Percent != 100 || last.Message != "Started" {
+		t.Fatalf("expected the final event last, got %+v", last)
+	}
+}
@@ -0,0 +1,227 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
+)
+
+// requestTimeout bounds the requests of the exchange browser.
+const requestTimeout = 15 * time.Second
+
+func InitializeAmqpProtocolRoutes(root *gin.RouterGroup) {
+	amqp := root.Group("/amqp")
+	amqp.GET("/:id/exchanges", listExchangesHandler)
+	amqp.GET("/:id/queues", listQueuesHandler)
+	amqp.GET("/:id/queues/:queue/messages", peekHandler)
+	amqp.GET("/:id/bindings", listBindingsHandler)
+	amqp.POST("/:id/messages", publishHandler)
+	amqp.GET("/:id/messages", messagesHandler)
+}
+
+func listExchangesHandler(c *gin.Context) {
+	b, ok := getBroker(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	exchanges, err := b.management().ListExchanges(ctx, b.orDefault(c.Query("vhost")))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exchanges": exchanges})
+}
+
+func listQueuesHandler(c *gin.Context) {
+	b, ok := getBroker(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	queues, err := b.management().ListQueues(ctx, b.orDefault(c.Query("vhost")))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"queues": queues})
+}
+
+func listBindingsHandler(c *gin.Context) {
+	b, ok := getBroker(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	bindings, err := b.management().ListBindings(ctx, b.orDefault(c.Query("vhost")))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bindings": bindings})
+}
+
+// peekHandler returns the first count messages of a queue and leaves them in the queue.
+func peekHandler(c *gin.Context) {
+	// Validate query params first (before checking container existence)
+	count := DefaultPeekCount
+	if v := c.Query("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxPeekCount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid count parameter, expected 1 to %d", MaxPeekCount)})
+			return
+		}
+		count = n
+	}
+
+	b, ok := getBroker(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	messages, err := b.management().Peek(ctx, b.orDefault(c.Query("vhost")), c.Param("queue"), count)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+func publishHandler(c *gin.Context) {
+	var req PublishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	if _, err := toPublishing(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	b, ok := getBroker(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	result, err := publish(ctx, b, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// messagesHandler streams the messages routed by an exchange with the routing_key query
+// parameter, which defaults to #, over a WebSocket.
+func messagesHandler(c *gin.Context) {
+	// Validate query params first (before checking container existence)
+	exchange := c.Query("exchange")
+	if exchange == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exchange parameter is required, the default exchange cannot be bound"})
+		return
+	}
+	bindingKey := c.DefaultQuery("routing_key", "#")
+
+	b, ok := getBroker(c)
+	if !ok {
+		return
+	}
+	vhost := b.orDefault(c.Query("vhost"))
+
+	// check the exchange before upgrading, so the error can be returned as a response
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	_, err := b.management().GetExchange(ctx, vhost, exchange)
+	cancel()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	conn, err := common.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	streamCtx, streamCancel := context.WithCancel(context.Background())
+	defer streamCancel()
+
+	// mutex to protect websocket writes
+	var writeMutex sync.Mutex
+
+	stop, err := startAmqpSubscriber(streamCtx, b, vhost, exchange, bindingKey, func(message []byte) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			log.Printf("websocket write error: %v", err)
+			streamCancel()
+		}
+	})
+	if err != nil {
+		log.Printf("failed to start amqp subscriber: %v", err)
+		return
+	}
+	defer stop()
+
+	// reader to detect closure from client
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				streamCancel()
+				return
+			}
+		}
+	}()
+
+	<-streamCtx.Done()
+}
+
+// getBroker looks up the broker of the container of the request and answers with an error
+// if it does not exist or is not an amqp broker.
+func getBroker(c *gin.Context) (*broker, bool) {
+	container, err := services.GetContainer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+		return nil, false
+	}
+	b, err := brokerFor(container)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return b, true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUnauthorized):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
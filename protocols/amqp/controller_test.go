@@ -0,0 +1,78 @@
+package amqp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupRouter creates a test router with amqp protocol routes
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	group := router.Group("/protocols")
+	InitializeAmqpProtocolRoutes(group)
+	return router
+}
+
+func TestHandlers_ValidateParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"missing exchange", http.MethodGet, "/protocols/amqp/test-id/messages", "", "exchange parameter is required"},
+		{"invalid count", http.MethodGet, "/protocols/amqp/test-id/queues/orders/messages?count=x", "", "invalid count parameter"},
+		{"count too large", http.MethodGet, "/protocols/amqp/test-id/queues/orders/messages?count=101", "", "invalid count parameter"},
+		{"invalid body", http.MethodPost, "/protocols/amqp/test-id/messages", "{", "invalid request body"},
+		{"invalid delivery mode", http.MethodPost, "/protocols/amqp/test-id/messages", `{"properties":{"delivery_mode":3}}`, "invalid request"},
+		{"invalid expiration", http.MethodPost, "/protocols/amqp/test-id/messages", `{"properties":{"expiration":"1m"}}`, "invalid request"},
+	}
+	router := setupRouter()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if msg, _ := resp["error"].(string); !strings.HasPrefix(msg, tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, resp["error"])
+			}
+		})
+	}
+}
+
+func TestHandlers_UnknownContainer(t *testing.T) {
+	router := setupRouter()
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/protocols/amqp/missing/exchanges", ""},
+		{http.MethodGet, "/protocols/amqp/missing/queues", ""},
+		{http.MethodGet, "/protocols/amqp/missing/bindings", ""},
+		{http.MethodGet, "/protocols/amqp/missing/queues/orders/messages", ""},
+		{http.MethodGet, "/protocols/amqp/missing/messages?exchange=amq.topic", ""},
+		{http.MethodPost, "/protocols/amqp/missing/messages", `{"routing_key":"orders","payload":"x"}`},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s %s: expected status 404, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package amqp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// Capability is the catalog capability of the server types this package can browse.
+const Capability = "amqp"
+
+var (
+	ErrNotAmqp      = errors.New("container is not an amqp broker")
+	ErrNotFound     = errors.New("not found")
+	ErrInvalid      = errors.New("invalid request")
+	ErrUnauthorized = errors.New("broker rejected the credentials")
+)
+
+// broker holds the addresses and credentials of a broker container.
+type broker struct {
+	amqpAddress       string
+	managementAddress string
+	user              string
+	password          string
+	// vhost is the virtual host used when a request names none.
+	vhost string
+}
+
+// brokerFor returns the broker of container.
+func brokerFor(container *dtos.Container) (*broker, error) {
+	server, err := servers.GetServerByType(container.Type)
+	if err != nil || !slices.Contains(server.Capabilities, Capability) {
+		return nil, ErrNotAmqp
+	}
+	b := &broker{
+		user:     container.Environment["RABBITMQ_DEFAULT_USER"],
+		password: container.Environment["RABBITMQ_DEFAULT_PASS"],
+		vhost:    container.Environment["RABBITMQ_DEFAULT_VHOST"],
+	}
+	if b.user == "" {
+		b.user, b.password = "guest", "guest"
+	}
+	if b.vhost == "" {
+		b.vhost = "/"
+	}
+	for port, address := range map[int]*string{AmqpPort: &b.amqpAddress, ManagementPort: &b.managementAddress} {
+		hostPort, ok := container.Ports[port]
+		if !ok || hostPort == 0 {
+			return nil, fmt.Errorf("%w: port %d is not published", ErrNotAmqp, port)
+		}
+		*address = net.JoinHostPort("localhost", strconv.Itoa(hostPort))
+	}
+	return b, nil
+}
+
+// orDefault returns vhost or the default virtual host of the broker if it is empty.
+func (b *broker) orDefault(vhost string) string {
+	if vhost == "" {
+		return b.vhost
+	}
+	return vhost
+}
+
+// managementClient calls the management HTTP API of a broker.
+type managementClient struct {
+	baseURL  string
+	user     string
+	password string
+	client   *http.Client
+}
+
+func (b *broker) management() *managementClient {
+	return &managementClient{
+		baseURL:  "http://" + b.managementAddress,
+		user:     b.user,
+		password: b.password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// do sends a request to path below /api and decodes the JSON response into out.
+func (m *managementClient) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+"/api"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(m.user, m.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("management API: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("management API: unexpected status %d: %s", resp.StatusCode, msg)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ListExchanges returns the exchanges of vhost.
+func (m *managementClient) ListExchanges(ctx context.Context, vhost string) ([]Exchange, error) {
+	exchanges := []Exchange{}
+	if err := m.do(ctx, http.MethodGet, "/exchanges/"+url.PathEscape(vhost), nil, &exchanges); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+// GetExchange returns the exchange name of vhost.
+func (m *managementClient) GetExchange(ctx context.Context, vhost string, name string) (*Exchange, error) {
+	var exchange Exchange
+	if err := m.do(ctx, http.MethodGet, "/exchanges/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), nil, &exchange); err != nil {
+		return nil, err
+	}
+	return &exchange, nil
+}
+
+// ListQueues returns the queues of vhost.
+func (m *managementClient) ListQueues(ctx context.Context, vhost string) ([]Queue, error) {
+	queues := []Queue{}
+	if err := m.do(ctx, http.MethodGet, "/queues/"+url.PathEscape(vhost), nil, &queues); err != nil {
+		return nil, err
+	}
+	return queues, nil
+}
+
+// ListBindings returns the bindings of vhost.
+func (m *managementClient) ListBindings(ctx context.Context, vhost string) ([]Binding, error) {
+	bindings := []Binding{}
+	if err := m.do(ctx, http.MethodGet, "/bindings/"+url.PathEscape(vhost), nil, &bindings); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// Peek returns up to count messages from the head of queue without consuming them. The
+// messages are fetched and requeued, so they are marked as redelivered afterwards.
+func (m *managementClient) Peek(ctx context.Context, vhost string, queue string, count int) ([]PeekedMessage, error) {
+	body := map[string]any{
+		"count":    count,
+		"ackmode":  "ack_requeue_true",
+		"encoding": "auto",
+		"truncate": maxPeekBytes,
+	}
+	messages := []PeekedMessage{}
+	path := "/queues/" + url.PathEscape(vhost) + "/" + url.PathEscape(queue) + "/get"
+	if err := m.do(ctx, http.MethodPost, path, body, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// dial opens an AMQP connection to vhost of the broker.
+func (b *broker) dial(vhost string) (*amqp091.Connection, error) {
+	uri := url.URL{Scheme: "amqp", User: url.UserPassword(b.user, b.password), Host: b.amqpAddress}
+	conn, err := amqp091.DialConfig(uri.String(), amqp091.Config{Vhost: b.orDefault(vhost), Dial: amqp091.DefaultDial(10 * time.Second)})
+	if err != nil {
+		return nil, amqpError(err)
+	}
+	return conn, nil
+}
+
+// amqpError maps the errors of channel and connection exceptions to the errors of this package.
+func amqpError(err error) error {
+	var amqpErr *amqp091.Error
+	if !errors.As(err, &amqpErr) {
+		return err
+	}
+	switch amqpErr.Code {
+	case amqp091.NotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, amqpErr.Reason)
+	case amqp091.AccessRefused, amqp091.NotAllowed:
+		return fmt.Errorf("%w: %s", ErrUnauthorized, amqpErr.Reason)
+	case amqp091.PreconditionFailed, amqp091.CommandInvalid, amqp091.SyntaxError:
+		return fmt.Errorf("%w: %s", ErrInvalid, amqpErr.Reason)
+	}
+	return err
+}
+
+// publish publishes the message of req and waits for the broker to confirm it. Messages are
+// published as mandatory, so the result reports whether a queue received the message.
+func publish(ctx context.Context, b *broker, req PublishRequest) (*PublishResult, error) {
+	publishing, err := toPublishing(req)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := b.dial(req.Vhost)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, amqpError(err)
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return nil, amqpError(err)
+	}
+	returns := ch.NotifyReturn(make(chan amqp091.Return, 1))
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, req.Exchange, req.RoutingKey, true, false, publishing)
+	if err != nil {
+		return nil, amqpError(err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return nil, amqpError(err)
+	}
+	if !acked {
+		return nil, errors.New("broker rejected the message")
+	}
+	// the broker returns an unroutable message before it confirms it
+	select {
+	case <-returns:
+		return &PublishResult{Routed: false}, nil
+	default:
+		return &PublishResult{Routed: true}, nil
+	}
+}
+
+// toPublishing converts req to the message sent to the broker.
+func toPublishing(req PublishRequest) (amqp091.Publishing, error) {
+	p := req.Properties
+	if p.DeliveryMode > amqp091.Persistent {
+		return amqp091.Publishing{}, fmt.Errorf("%w: delivery_mode must be 1 or 2", ErrInvalid)
+	}
+	if p.Expiration != "" {
+		if n, err := strconv.ParseUint(p.Expiration, 10, 32); err != nil || n > math.MaxInt32 {
+			return amqp091.Publishing{}, fmt.Errorf("%w: expiration must be a number of milliseconds", ErrInvalid)
+		}
+	}
+	headers := toTable(p.Headers)
+	if err := headers.Validate(); err != nil {
+		return amqp091.Publishing{}, fmt.Errorf("%w: headers: %v", ErrInvalid, err)
+	}
+	publishing := amqp091.Publishing{
+		Headers:         headers,
+		ContentType:     p.ContentType,
+		ContentEncoding: p.ContentEncoding,
+		DeliveryMode:    p.DeliveryMode,
+		Priority:        p.Priority,
+		CorrelationId:   p.CorrelationID,
+		ReplyTo:         p.ReplyTo,
+		Expiration:      p.Expiration,
+		MessageId:       p.MessageID,
+		Type:            p.Type,
+		UserId:          p.UserID,
+		AppId:           p.AppID,
+		Body:            []byte(req.Payload),
+	}
+	if p.Timestamp != nil {
+		publishing.Timestamp = *p.Timestamp
+	}
+	return publishing, nil
+}
+
+// toTable converts decoded JSON to an AMQP table. Whole numbers become integers, as
+// consumers usually expect them for headers like x-delay.
+func toTable(m map[string]any) amqp091.Table {
+	if m == nil {
+		return nil
+	}
+	table := amqp091.Table{}
+	for k, v := range m {
+		table[k] = toFieldValue(v)
+	}
+	return table
+}
+
+func toFieldValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+		return v
+	case map[string]any:
+		return toTable(v)
+	case []any:
+		values := make([]any, len(v))
+		for i, item := range v {
+			values[i] = toFieldValue(item)
+		}
+		return values
+	}
+	return v
+}
+
+// toDelivery converts a message delivered by the broker for the stream.
+func toDelivery(d amqp091.Delivery) Delivery {
+	delivery := Delivery{
+		Exchange:    d.Exchange,
+		RoutingKey:  d.RoutingKey,
+		Redelivered: d.Redelivered,
+		Payload:     string(d.Body),
+		Properties: Properties{
+			ContentType:     d.ContentType,
+			ContentEncoding: d.ContentEncoding,
+			DeliveryMode:    d.DeliveryMode,
+			Priority:        d.Priority,
+			CorrelationID:   d.CorrelationId,
+			ReplyTo:         d.ReplyTo,
+			Expiration:      d.Expiration,
+			MessageID:       d.MessageId,
+			Type:            d.Type,
+			UserID:          d.UserId,
+			AppID:           d.AppId,
+		},
+	}
+	if len(d.Headers) > 0 {
+		delivery.Properties.Headers = map[string]any(d.Headers)
+	}
+	if !d.Timestamp.IsZero() {
+		delivery.Properties.Timestamp = &d.Timestamp
+	}
+	return delivery
+}
+
+// startAmqpSubscriber binds a temporary queue to exchange with bindingKey and invokes handler
+// with each delivered message as JSON. It returns a stop function which removes the queue.
+func startAmqpSubscriber(ctx context.Context, b *broker, vhost string, exchange string, bindingKey string, handler func(message []byte)) (func(), error) {
+	conn, err := b.dial(vhost)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (func(), error) {
+		conn.Close()
+		return nil, amqpError(err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return fail(err)
+	}
+	queue, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return fail(err)
+	}
+	if err := ch.QueueBind(queue.Name, bindingKey, exchange, false, nil); err != nil {
+		return fail(err)
+	}
+	deliveries, err := ch.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return fail(err)
+	}
+
+	go func() {
+		for d := range deliveries {
+			data, err := json.Marshal(toDelivery(d))
+			if err != nil {
+				log.Printf("Error marshaling AMQP message: %v", err)
+				continue
+			}
+			handler(data)
+		}
+	}()
+
+	stop := func() {
+		_ = conn.Close()
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return stop, nil
+}
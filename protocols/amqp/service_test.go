@@ -0,0 +1,189 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// useFakeManagement serves the management API of a broker with the default vhost, the queue
+// orders and the credentials test:test. It returns a client of it together with the bodies
+// of the peek requests it received.
+func useFakeManagement(t *testing.T) (*managementClient, *[]map[string]any) {
+	t.Helper()
+	peeks := []map[string]any{}
+	// the handlers match the escaped path, as the vhost / is sent as %2F
+	routes := map[string]http.HandlerFunc{
+		"GET /api/exchanges/%2F": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"name":"","vhost":"/","type":"direct","durable":true},{"name":"amq.topic","vhost":"/","type":"topic","durable":true}]`))
+		},
+		"GET /api/queues/%2F": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"name":"orders","vhost":"/","durable":true,"state":"running","messages":3,"messages_ready":2,"messages_unacknowledged":1,"consumers":1}]`))
+		},
+		"GET /api/bindings/%2F": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"source":"amq.topic","vhost":"/","destination":"orders","destination_type":"queue","routing_key":"orders.#"}]`))
+		},
+		"POST /api/queues/%2F/orders/get": func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode peek request: %v", err)
+			}
+			peeks = append(peeks, body)
+			w.Write([]byte(`[{"payload_bytes":7,"redelivered":true,"exchange":"amq.topic","routing_key":"orders.created","message_count":1,"properties":{"content_type":"text/plain"},"payload":"created","payload_encoding":"string"}]`))
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "test" || password != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler, ok := routes[r.Method+" "+r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Object Not Found","reason":"Not Found"}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return &managementClient{baseURL: server.URL, user: "test", password: "test", client: server.Client()}, &peeks
+}
+
+func TestManagement_ListsTheDefaultVhost(t *testing.T) {
+	m, _ := useFakeManagement(t)
+	ctx := context.Background()
+
+	exchanges, err := m.ListExchanges(ctx, "/")
+	if err != nil {
+		t.Fatalf("ListExchanges: %v", err)
+	}
+	if len(exchanges) != 2 || exchanges[1].Name != "amq.topic" || exchanges[1].Type != "topic" {
+		t.Fatalf("unexpected exchanges %+v", exchanges)
+	}
+	if _, err := m.ListExchanges(ctx, "staging"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown vhost, got %v", err)
+	}
+
+	queues, err := m.ListQueues(ctx, "/")
+	if err != nil {
+		t.Fatalf("ListQueues: %v", err)
+	}
+	if len(queues) != 1 || queues[0].MessagesReady != 2 || queues[0].Consumers != 1 {
+		t.Fatalf("unexpected queues %+v", queues)
+	}
+
+	bindings, err := m.ListBindings(ctx, "/")
+	if err != nil {
+		t.Fatalf("ListBindings: %v", err)
+	}
+	if len(bindings) != 1 || bindings[0].Destination != "orders" || bindings[0].RoutingKey != "orders.#" {
+		t.Fatalf("unexpected bindings %+v", bindings)
+	}
+
+	m.password = "wrong"
+	if _, err := m.ListQueues(ctx, "/"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestManagement_PeekRequeuesMessages(t *testing.T) {
+	m, peeks := useFakeManagement(t)
+	ctx := context.Background()
+
+	messages, err := m.Peek(ctx, "/", "orders", 5)
+	if err != nil {
+		t.Fatalf("Peek: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Payload != "created" || messages[0].RoutingKey != "orders.created" || messages[0].Properties["content_type"] != "text/plain" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+	if len(*peeks) != 1 || (*peeks)[0]["ackmode"] != "ack_requeue_true" || (*peeks)[0]["count"] != float64(5) {
+		t.Fatalf("expected a peek of 5 requeued messages, got %+v", *peeks)
+	}
+
+	if _, err := m.Peek(ctx, "/", "missing", 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestToPublishing(t *testing.T) {
+	var headers map[string]any
+	if err := json.Unmarshal([]byte(`{"x-delay":5000,"ratio":0.5,"nested":{"retries":[1,2]}}`), &headers); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	publishing, err := toPublishing(PublishRequest{
+		RoutingKey: "orders",
+		Payload:    "created",
+		Properties: Properties{ContentType: "text/plain", DeliveryMode: 2, Expiration: "60000", Headers: headers, Timestamp: &timestamp},
+	})
+	if err != nil {
+		t.Fatalf("toPublishing: %v", err)
+	}
+	if string(publishing.Body) != "created" || publishing.DeliveryMode != amqp091.Persistent || !publishing.Timestamp.Equal(timestamp) {
+		t.Fatalf("unexpected publishing %+v", publishing)
+	}
+	if publishing.Headers["x-delay"] != int64(5000) || publishing.Headers["ratio"] != 0.5 {
+		t.Fatalf("expected whole numbers as integers, got %+v", publishing.Headers)
+	}
+	nested, ok := publishing.Headers["nested"].(amqp091.Table)
+	if !ok || nested["retries"].([]any)[1] != int64(2) {
+		t.Fatalf("expected nested tables, got %+v", publishing.Headers["nested"])
+	}
+
+	for _, p := range []Properties{{DeliveryMode: 3}, {Expiration: "1m"}, {Expiration: "-1"}} {
+		if _, err := toPublishing(PublishRequest{Properties: p}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%+v: expected ErrInvalid, got %v", p, err)
+		}
+	}
+}
+
+func TestToDelivery(t *testing.T) {
+	delivery := toDelivery(amqp091.Delivery{
+		Exchange:    "amq.topic",
+		RoutingKey:  "orders.created",
+		Redelivered: true,
+		Body:        []byte("created"),
+		ContentType: "text/plain",
+		MessageId:   "1",
+		Headers:     amqp091.Table{"source": "test"},
+	})
+	if delivery.Payload != "created" || delivery.RoutingKey != "orders.created" || delivery.Properties.MessageID != "1" {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+	if delivery.Properties.Headers["source"] != "test" || delivery.Properties.Timestamp != nil {
+		t.Fatalf("unexpected properties %+v", delivery.Properties)
+	}
+}
+
+func TestBrokerFor(t *testing.T) {
+	b, err := brokerFor(&dtos.Container{
+		Type:        "AMQP",
+		Ports:       map[int]int{5672: 15672, 15672: 25672},
+		Environment: map[string]string{"RABBITMQ_DEFAULT_USER": "test", "RABBITMQ_DEFAULT_PASS": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("brokerFor: %v", err)
+	}
+	if b.amqpAddress != "localhost:15672" || b.managementAddress != "localhost:25672" || b.user != "test" || b.password != "secret" {
+		t.Fatalf("unexpected broker %+v", b)
+	}
+	if b.orDefault("") != "/" || b.orDefault("staging") != "staging" {
+		t.Fatalf("expected / as default vhost")
+	}
+
+	if _, err := brokerFor(&dtos.Container{Type: "AMQP", Ports: map[int]int{5672: 15672}}); !errors.Is(err, ErrNotAmqp) {
+		t.Fatalf("expected ErrNotAmqp without the management port, got %v", err)
+	}
+	if _, err := brokerFor(&dtos.Container{Type: "KAFKA", Ports: map[int]int{5672: 15672, 15672: 25672}}); !errors.Is(err, ErrNotAmqp) {
+		t.Fatalf("expected ErrNotAmqp, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package amqp
+
+import "time"
+
+const (
+	// AmqpPort is the container port of the AMQP 0-9-1 listener.
+	AmqpPort = 5672
+	// ManagementPort is the container port of the management HTTP API.
+	ManagementPort = 15672
+
+	// DefaultPeekCount is the number of messages peeked unless the request asks for another.
+	DefaultPeekCount = 10
+	// MaxPeekCount is the largest number of messages a peek may ask for.
+	MaxPeekCount = 100
+	// maxPeekBytes truncates the payloads of peeked messages.
+	maxPeekBytes = 50000
+)
+
+// Exchange is an exchange as reported by the management API.
+type Exchange struct {
+	Name       string         `json:"name"`
+	Vhost      string         `json:"vhost"`
+	Type       string         `json:"type"`
+	Durable    bool           `json:"durable"`
+	AutoDelete bool           `json:"auto_delete"`
+	Internal   bool           `json:"internal"`
+	Arguments  map[string]any `json:"arguments"`
+}
+
+// Queue is a queue as reported by the management API.
+type Queue struct {
+	Name                   string         `json:"name"`
+	Vhost                  string         `json:"vhost"`
+	Durable                bool           `json:"durable"`
+	AutoDelete             bool           `json:"auto_delete"`
+	Exclusive              bool           `json:"exclusive"`
+	State                  string         `json:"state"`
+	Messages               int64          `json:"messages"`
+	MessagesReady          int64          `json:"messages_ready"`
+	MessagesUnacknowledged int64          `json:"messages_unacknowledged"`
+	Consumers              int64          `json:"consumers"`
+	Arguments              map[string]any `json:"arguments"`
+}
+
+// Binding binds a queue or an exchange to an exchange as reported by the management API.
+type Binding struct {
+	Source          string         `json:"source"`
+	Vhost           string         `json:"vhost"`
+	Destination     string         `json:"destination"`
+	DestinationType string         `json:"destination_type"`
+	RoutingKey      string         `json:"routing_key"`
+	Arguments       map[string]any `json:"arguments"`
+}
+
+// Properties are the basic properties of a message.
+type Properties struct {
+	ContentType     string         `json:"content_type,omitempty"`
+	ContentEncoding string         `json:"content_encoding,omitempty"`
+	Headers         map[string]any `json:"headers,omitempty"`
+	// DeliveryMode is 1 for transient and 2 for persistent messages.
+	DeliveryMode  uint8      `json:"delivery_mode,omitempty"`
+	Priority      uint8      `json:"priority,omitempty"`
+	CorrelationID string     `json:"correlation_id,omitempty"`
+	ReplyTo       string     `json:"reply_to,omitempty"`
+	Expiration    string     `json:"expiration,omitempty"`
+	MessageID     string     `json:"message_id,omitempty"`
+	Timestamp     *time.Time `json:"timestamp,omitempty"`
+	Type          string     `json:"type,omitempty"`
+	UserID        string     `json:"user_id,omitempty"`
+	AppID         string     `json:"app_id,omitempty"`
+}
+
+// PublishRequest is the body of the publish endpoint. An empty exchange is the default
+// exchange, which routes to the queue named by the routing key.
+type PublishRequest struct {
+	Vhost      string     `json:"vhost"`
+	Exchange   string     `json:"exchange"`
+	RoutingKey string     `json:"routing_key"`
+	Payload    string     `json:"payload"`
+	Properties Properties `json:"properties"`
+}
+
+// PublishResult reports whether a published message reached at least one queue.
+type PublishResult struct {
+	Routed bool `json:"routed"`
+}
+
+// PeekedMessage is a message read from a queue and put back.
+type PeekedMessage struct {
+	Exchange    string `json:"exchange"`
+	RoutingKey  string `json:"routing_key"`
+	Redelivered bool   `json:"redelivered"`
+	// MessageCount is the number of messages left in the queue after this one.
+	MessageCount int    `json:"message_count"`
+	Payload      string `json:"payload"`
+	// PayloadEncoding is string or base64 for binary payloads.
+	PayloadEncoding string `json:"payload_encoding"`
+	// PayloadBytes is the size of the payload before truncation.
+	PayloadBytes int            `json:"payload_bytes"`
+	Properties   map[string]any `json:"properties"`
+}
+
+// Delivery is a message delivered to the WebSocket stream.
+type Delivery struct {
+	Exchange    string     `json:"exchange"`
+	RoutingKey  string     `json:"routing_key"`
+	Redelivered bool       `json:"redelivered"`
+	Payload     string     `json:"payload"`
+	Properties  Properties `json:"properties"`
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/tim0-12432/simple-test-server/config"
+)
+
+// Upgrader upgrades WebSocket connections whose origin is one of AllowedOrigins.
+var Upgrader = websocket.Upgrader{CheckOrigin: CheckOrigin}
+
+// AllowedOrigins returns the origins the frontend is served from: the configured host,
+// CORS_ALLOWED_ORIGINS and, in DEV mode, the Vite dev server.
+func AllowedOrigins() []string {
+	if config.EnvConfig == nil {
+		return nil
+	}
+	allowedOrigins := []string{
+		"http://" + config.EnvConfig.Host + ":" + config.EnvConfig.Port,
+	}
+	if config.EnvConfig.AllowedOrigins != nil {
+		allowedOrigins = append(allowedOrigins, config.EnvConfig.AllowedOrigins...)
+	}
+	if config.EnvConfig.Env == "DEV" {
+		allowedOrigins = append(allowedOrigins, "http://localhost:5173")
+	}
+	return allowedOrigins
+}
+
+// CheckOrigin reports whether the request origin is one of AllowedOrigins.
+func CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	for _, allowedOrigin := range AllowedOrigins() {
+		if origin == allowedOrigin {
+			return true
+		}
+	}
+	return false
+}
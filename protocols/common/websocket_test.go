@@ -0,0 +1,30 @@
+package common
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tim0-12432/simple-test-server/config"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	prev := config.EnvConfig
+	defer func() { config.EnvConfig = prev }()
+
+	req, _ := http.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Origin", "http://evil.test")
+
+	config.EnvConfig = nil
+	if CheckOrigin(req) {
+		t.Fatal("expected origins to be rejected without configuration")
+	}
+
+	config.InitializeEnvConfig()
+	if CheckOrigin(req) {
+		t.Fatal("expected unknown origin to be rejected")
+	}
+	req.Header.Set("Origin", "http://"+config.EnvConfig.Host+":"+config.EnvConfig.Port)
+	if !CheckOrigin(req) {
+		t.Fatalf("expected the configured host to be allowed")
+	}
+}
@@ -2,6 +2,7 @@ package ftp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -54,12 +55,11 @@ func InitializeFtpProtocolRoutes(root *gin.RouterGroup) {
 
 		entries, truncated, err := docker.ListFtpDir(ctx, container.Name, relPath, 1000)
 		if err != nil {
-			s := err.Error()
-			if strings.Contains(s, "container not found") {
+			if errors.Is(err, docker.ErrContainerNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
 				return
 			}
-			if strings.Contains(s, "must be relative") || strings.Contains(s, "must not contain") {
+			if errors.Is(err, docker.ErrInvalidPath) {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
 				return
 			}
@@ -152,8 +152,7 @@ func InitializeFtpProtocolRoutes(root *gin.RouterGroup) {
 
 		logs, err := docker.GetContainerLogs(ctx, container.Name, lines)
 		if err != nil {
-			s := err.Error()
-			if strings.Contains(s, "container not found") {
+			if errors.Is(err, docker.ErrContainerNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
 				return
 			}
@@ -0,0 +1,249 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// requestTimeout bounds the requests of the topic browser.
+const requestTimeout = 15 * time.Second
+
+func InitializeKafkaProtocolRoutes(root *gin.RouterGroup) {
+	kafka := root.Group("/kafka")
+	kafka.GET("/:id/topics", listTopicsHandler)
+	kafka.POST("/:id/topics", createTopicHandler)
+	kafka.DELETE("/:id/topics/:topic", deleteTopicHandler)
+	kafka.POST("/:id/topics/:topic/messages", produceHandler)
+	kafka.GET("/:id/topics/:topic/messages", messagesHandler)
+	kafka.GET("/:id/groups", listGroupsHandler)
+}
+
+func listTopicsHandler(c *gin.Context) {
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	topics, err := ListTopics(ctx, client, c.Query("internal") == "true")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"topics": topics})
+}
+
+func createTopicHandler(c *gin.Context) {
+	var req CreateTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	if err := CreateTopic(ctx, client, req); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"name": req.Name})
+}
+
+func deleteTopicHandler(c *gin.Context) {
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	if err := DeleteTopic(ctx, client, c.Param("topic")); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func produceHandler(c *gin.Context) {
+	var req ProduceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	result, err := Produce(ctx, client, c.Param("topic"), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func listGroupsHandler(c *gin.Context) {
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	groups, err := ListConsumerGroups(ctx, client)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+// messagesHandler streams the records of a topic over a WebSocket, starting at the offset
+// query parameter (earliest, latest or an offset) in every partition or only in partition.
+func messagesHandler(c *gin.Context) {
+	// Validate query params first (before checking container existence)
+	offset, err := ParseOffset(c.Query("offset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var partition *int32
+	if p := c.Query("partition"); p != "" {
+		n, err := strconv.ParseInt(p, 10, 32)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid partition parameter"})
+			return
+		}
+		p32 := int32(n)
+		partition = &p32
+	}
+
+	container, err := services.GetContainer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+		return
+	}
+	opts, err := connectionOptions(container)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// check the topic before upgrading, so the error can be returned as a response
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	partitions, err := partitionCount(ctx, client, c.Param("topic"))
+	cancel()
+	client.Close()
+	if err == nil && partition != nil && int(*partition) >= partitions {
+		err = fmt.Errorf("%w: topic %s has %d partitions", ErrInvalidPartition, c.Param("topic"), partitions)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	conn, err := common.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	streamCtx, streamCancel := context.WithCancel(context.Background())
+	defer streamCancel()
+
+	// mutex to protect websocket writes
+	var writeMutex sync.Mutex
+
+	stop, err := startKafkaConsumer(streamCtx, opts, c.Param("topic"), offset, partition, func(message []byte) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			log.Printf("websocket write error: %v", err)
+			streamCancel()
+		}
+	})
+	if err != nil {
+		log.Printf("failed to start kafka consumer: %v", err)
+		return
+	}
+	defer stop()
+
+	// reader to detect closure from client
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				streamCancel()
+				return
+			}
+		}
+	}()
+
+	<-streamCtx.Done()
+}
+
+// getClient connects to the container of the request and answers with an error if it does
+// not exist or is not a kafka broker.
+func getClient(c *gin.Context) (*kgo.Client, bool) {
+	container, err := services.GetContainer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+		return nil, false
+	}
+	opts, err := connectionOptions(container)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return client, true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrTopicNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTopicExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidTopic), errors.Is(err, ErrInvalidPartition), errors.Is(err, ErrInvalidOffset):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupRouter creates a test router with kafka protocol routes
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	group := router.Group("/protocols")
+	InitializeKafkaProtocolRoutes(group)
+	return router
+}
+
+func TestMessagesHandler_ValidatesParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"invalid offset", "/protocols/kafka/test-id/topics/orders/messages?offset=first", "invalid offset"},
+		{"negative offset", "/protocols/kafka/test-id/topics/orders/messages?offset=-5", "invalid offset"},
+		{"invalid partition", "/protocols/kafka/test-id/topics/orders/messages?partition=x", "invalid partition parameter"},
+	}
+	router := setupRouter()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if msg, _ := resp["error"].(string); !strings.HasPrefix(msg, tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, resp["error"])
+			}
+		})
+	}
+}
+
+func TestHandlers_UnknownContainer(t *testing.T) {
+	router := setupRouter()
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/protocols/kafka/missing/topics", ""},
+		{http.MethodGet, "/protocols/kafka/missing/groups", ""},
+		{http.MethodPost, "/protocols/kafka/missing/topics", `{"name":"orders"}`},
+		{http.MethodPost, "/protocols/kafka/missing/topics/orders/messages", `{"value":"x"}`},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s %s: expected status 404, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
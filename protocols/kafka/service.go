@@ -0,0 +1,308 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Capability is the catalog capability of the server types this package can browse.
+const Capability = "kafka"
+
+var (
+	ErrNotKafka         = errors.New("container is not a kafka broker")
+	ErrTopicNotFound    = errors.New("topic not found")
+	ErrTopicExists      = errors.New("topic already exists")
+	ErrInvalidTopic     = errors.New("invalid topic")
+	ErrInvalidPartition = errors.New("invalid partition")
+	ErrInvalidOffset    = errors.New("invalid offset")
+)
+
+// topicNamePattern matches the topic names Kafka accepts.
+var topicNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// connectionOptions returns the client options for the broker of container. Every connection
+// goes to the published port, which is correct as the server is a single node.
+func connectionOptions(container *dtos.Container) ([]kgo.Opt, error) {
+	server, err := servers.GetServerByType(container.Type)
+	if err != nil || !slices.Contains(server.Capabilities, Capability) {
+		return nil, ErrNotKafka
+	}
+	hostPort, ok := container.Ports[KafkaPort]
+	if !ok || hostPort == 0 {
+		return nil, fmt.Errorf("%w: port %d is not published", ErrNotKafka, KafkaPort)
+	}
+	address := net.JoinHostPort("localhost", strconv.Itoa(hostPort))
+	var dialer net.Dialer
+	return clientOptions(address, kgo.Dialer(func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	})), nil
+}
+
+// clientOptions returns the options of every client of this package for the broker at seed.
+func clientOptions(seed string, opts ...kgo.Opt) []kgo.Opt {
+	// Produce chooses the partitions itself, so records can be written to a given partition
+	return append([]kgo.Opt{kgo.SeedBrokers(seed), kgo.RecordPartitioner(kgo.ManualPartitioner())}, opts...)
+}
+
+// ListTopics returns the topics with their partitions and offsets, sorted by name. Internal
+// topics like __consumer_offsets are only included with internal set.
+func ListTopics(ctx context.Context, client *kgo.Client, internal bool) ([]Topic, error) {
+	adm := kadm.NewClient(client)
+	details, err := adm.ListTopicsWithInternal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list topics: %w", err)
+	}
+	if !internal {
+		details.FilterInternal()
+	}
+	topics := []Topic{}
+	if len(details) == 0 {
+		return topics, nil
+	}
+
+	names := details.Names()
+	starts, err := adm.ListStartOffsets(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("list start offsets: %w", err)
+	}
+	ends, err := adm.ListEndOffsets(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("list end offsets: %w", err)
+	}
+
+	for _, detail := range details.Sorted() {
+		topic := Topic{Name: detail.Topic, Internal: detail.IsInternal, Partitions: []Partition{}}
+		for _, p := range detail.Partitions.Sorted() {
+			partition := Partition{Partition: p.Partition, Leader: p.Leader, Replicas: p.Replicas}
+			if o, ok := starts.Lookup(detail.Topic, p.Partition); ok {
+				partition.StartOffset = o.Offset
+			}
+			if o, ok := ends.Lookup(detail.Topic, p.Partition); ok {
+				partition.EndOffset = o.Offset
+			}
+			topic.Partitions = append(topic.Partitions, partition)
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// partitionCount returns the number of partitions of topic.
+func partitionCount(ctx context.Context, client *kgo.Client, topic string) (int, error) {
+	details, err := kadm.NewClient(client).ListTopicsWithInternal(ctx, topic)
+	if err != nil {
+		return 0, fmt.Errorf("describe topic: %w", err)
+	}
+	detail, ok := details[topic]
+	if !ok || errors.Is(detail.Err, kerr.UnknownTopicOrPartition) {
+		return 0, fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+	}
+	if detail.Err != nil {
+		return 0, fmt.Errorf("describe topic: %w", detail.Err)
+	}
+	return len(detail.Partitions), nil
+}
+
+// ListConsumerGroups returns the consumer groups with their lag, sorted by name.
+func ListConsumerGroups(ctx context.Context, client *kgo.Client) ([]ConsumerGroup, error) {
+	lags, err := kadm.NewClient(client).Lag(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("describe groups: %w", err)
+	}
+	groups := []ConsumerGroup{}
+	for _, l := range lags {
+		group := ConsumerGroup{Group: l.Group, State: l.State, Members: len(l.Members), Partitions: []PartitionLag{}}
+		if err := l.Error(); err != nil {
+			group.Error = err.Error()
+		}
+		for _, p := range l.Lag.Sorted() {
+			group.Partitions = append(group.Partitions, PartitionLag{
+				Topic:     p.Topic,
+				Partition: p.Partition,
+				Committed: p.Commit.At,
+				End:       p.End.Offset,
+				Lag:       p.Lag,
+			})
+		}
+		group.Lag = l.Lag.Total()
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Group < groups[j].Group })
+	return groups, nil
+}
+
+// CreateTopic creates the topic of req.
+func CreateTopic(ctx context.Context, client *kgo.Client, req CreateTopicRequest) error {
+	if !topicNamePattern.MatchString(req.Name) || req.Name == "." || req.Name == ".." {
+		return fmt.Errorf("%w: %q, names consist of letters, digits, '.', '_' and '-'", ErrInvalidTopic, req.Name)
+	}
+	if req.Partitions == 0 {
+		req.Partitions = 1
+	}
+	if req.Partitions < 1 || req.Partitions > MaxPartitions {
+		return fmt.Errorf("%w: partitions must be between 1 and %d", ErrInvalidTopic, MaxPartitions)
+	}
+	if req.ReplicationFactor == 0 {
+		req.ReplicationFactor = 1
+	}
+	configs := map[string]*string{}
+	for k, v := range req.Configs {
+		configs[k] = &v
+	}
+
+	// the error of the topic is returned both as err and in the response
+	resp, err := kadm.NewClient(client).CreateTopic(ctx, req.Partitions, req.ReplicationFactor, configs, req.Name)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, kerr.TopicAlreadyExists):
+		return fmt.Errorf("%w: %s", ErrTopicExists, req.Name)
+	case errors.Is(err, kerr.InvalidReplicationFactor), errors.Is(err, kerr.InvalidConfig),
+		errors.Is(err, kerr.InvalidPartitions), errors.Is(err, kerr.InvalidTopicException):
+		return fmt.Errorf("%w: %s", ErrInvalidTopic, errorMessage(err, resp.ErrMessage))
+	default:
+		return fmt.Errorf("create topic: %s", errorMessage(err, resp.ErrMessage))
+	}
+}
+
+// DeleteTopic deletes topic.
+func DeleteTopic(ctx context.Context, client *kgo.Client, topic string) error {
+	resp, err := kadm.NewClient(client).DeleteTopic(ctx, topic)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, kerr.UnknownTopicOrPartition):
+		return fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+	default:
+		return fmt.Errorf("delete topic: %s", errorMessage(err, resp.ErrMessage))
+	}
+}
+
+// errorMessage adds the message the broker sent with err, if it is not part of err already.
+func errorMessage(err error, message string) string {
+	if message == "" || strings.Contains(err.Error(), message) {
+		return err.Error()
+	}
+	return err.Error() + ": " + message
+}
+
+// Produce writes the record of req to topic. Without a partition it is chosen from the key
+// like the Java client does, or spread over the partitions for records without key.
+func Produce(ctx context.Context, client *kgo.Client, topic string, req ProduceRequest) (*ProduceResult, error) {
+	partitions, err := partitionCount(ctx, client, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &kgo.Record{Topic: topic, Value: []byte(req.Value)}
+	if req.Key != nil {
+		record.Key = []byte(*req.Key)
+	}
+	for _, h := range req.Headers {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: h.Key, Value: []byte(h.Value)})
+	}
+	if req.Partition != nil {
+		if *req.Partition < 0 || int(*req.Partition) >= partitions {
+			return nil, fmt.Errorf("%w: topic %s has %d partitions", ErrInvalidPartition, topic, partitions)
+		}
+		record.Partition = *req.Partition
+	} else {
+		record.Partition = int32(kgo.StickyKeyPartitioner(nil).ForTopic(topic).Partition(record, partitions))
+	}
+
+	produced, err := client.ProduceSync(ctx, record).First()
+	if err != nil {
+		return nil, fmt.Errorf("produce: %w", err)
+	}
+	return &ProduceResult{Partition: produced.Partition, Offset: produced.Offset}, nil
+}
+
+// ParseOffset reads the start offset of a stream: earliest, latest or an offset.
+func ParseOffset(value string) (kgo.Offset, error) {
+	switch strings.ToLower(value) {
+	case "", "latest":
+		return kgo.NewOffset().AtEnd(), nil
+	case "earliest":
+		return kgo.NewOffset().AtStart(), nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return kgo.Offset{}, fmt.Errorf("%w: %q, expected earliest, latest or an offset", ErrInvalidOffset, value)
+	}
+	return kgo.NewOffset().At(n), nil
+}
+
+// startKafkaConsumer consumes topic from offset without joining a group and invokes handler
+// with each record as JSON. It returns a stop function which closes the client.
+func startKafkaConsumer(ctx context.Context, opts []kgo.Opt, topic string, offset kgo.Offset, partition *int32, handler func(message []byte)) (func(), error) {
+	if partition != nil {
+		opts = append(opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: {*partition: offset}}))
+	} else {
+		opts = append(opts, kgo.ConsumeTopics(topic), kgo.ConsumeResetOffset(offset))
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		for {
+			fetches := client.PollFetches(ctx)
+			if ctx.Err() != nil || fetches.IsClientClosed() {
+				return
+			}
+			fetches.EachError(func(topic string, partition int32, err error) {
+				log.Printf("Error fetching Kafka records of %s/%d: %v", topic, partition, err)
+			})
+			fetches.EachRecord(func(r *kgo.Record) {
+				data, err := json.Marshal(toRecord(r))
+				if err != nil {
+					log.Printf("Error marshaling Kafka record: %v", err)
+					return
+				}
+				handler(data)
+			})
+		}
+	}()
+
+	stop := func() {
+		cancel()
+		client.Close()
+	}
+	return stop, nil
+}
+
+func toRecord(r *kgo.Record) Record {
+	record := Record{
+		Topic:     r.Topic,
+		Partition: r.Partition,
+		Offset:    r.Offset,
+		Timestamp: r.Timestamp,
+		Value:     string(r.Value),
+		Headers:   []Header{},
+	}
+	if r.Key != nil {
+		key := string(r.Key)
+		record.Key = &key
+	}
+	for _, h := range r.Headers {
+		record.Headers = append(record.Headers, Header{Key: h.Key, Value: string(h.Value)})
+	}
+	return record
+}
@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// useFakeCluster starts an in-memory single broker cluster with the topic orders and returns
+// the options connecting to it together with a client.
+func useFakeCluster(t *testing.T) ([]kgo.Opt, *kgo.Client) {
+	t.Helper()
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(2, "orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Close)
+
+	opts := clientOptions(cluster.ListenAddrs()[0])
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return opts, client
+}
+
+func TestTopics_CreateListDelete(t *testing.T) {
+	_, client := useFakeCluster(t)
+	ctx := context.Background()
+
+	if err := CreateTopic(ctx, client, CreateTopicRequest{Name: "payments", Partitions: 3}); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if err := CreateTopic(ctx, client, CreateTopicRequest{Name: "payments"}); !errors.Is(err, ErrTopicExists) {
+		t.Fatalf("expected ErrTopicExists, got %v", err)
+	}
+	for _, req := range []CreateTopicRequest{{Name: "no spaces"}, {Name: ".."}, {Name: "x", Partitions: -1}} {
+		if err := CreateTopic(ctx, client, req); !errors.Is(err, ErrInvalidTopic) {
+			t.Fatalf("%+v: expected ErrInvalidTopic, got %v", req, err)
+		}
+	}
+
+	topics, err := ListTopics(ctx, client, false)
+	if err != nil {
+		t.Fatalf("ListTopics: %v", err)
+	}
+	if len(topics) != 2 || topics[0].Name != "orders" || topics[1].Name != "payments" || len(topics[1].Partitions) != 3 {
+		t.Fatalf("unexpected topics %+v", topics)
+	}
+
+	if err := DeleteTopic(ctx, client, "payments"); err != nil {
+		t.Fatalf("DeleteTopic: %v", err)
+	}
+	if err := DeleteTopic(ctx, client, "payments"); !errors.Is(err, ErrTopicNotFound) {
+		t.Fatalf("expected ErrTopicNotFound, got %v", err)
+	}
+}
+
+func TestProduceAndConsume(t *testing.T) {
+	opts, client := useFakeCluster(t)
+	ctx := context.Background()
+
+	key := "order-1"
+	first, err := Produce(ctx, client, "orders", ProduceRequest{Key: &key, Value: "created", Headers: []Header{{Key: "source", Value: "test"}}})
+	if err != nil {
+		t.Fatalf("Produce: %v", err)
+	}
+	second, err := Produce(ctx, client, "orders", ProduceRequest{Key: &key, Value: "paid"})
+	if err != nil {
+		t.Fatalf("Produce: %v", err)
+	}
+	if first.Partition != second.Partition || second.Offset != first.Offset+1 {
+		t.Fatalf("expected records with the same key in one partition, got %+v and %+v", first, second)
+	}
+	partition := int32(1 - first.Partition)
+	if r, err := Produce(ctx, client, "orders", ProduceRequest{Value: "manual", Partition: &partition}); err != nil || r.Partition != partition {
+		t.Fatalf("expected the record in partition %d, got %+v (%v)", partition, r, err)
+	}
+	invalid := int32(2)
+	if _, err := Produce(ctx, client, "orders", ProduceRequest{Partition: &invalid}); !errors.Is(err, ErrInvalidPartition) {
+		t.Fatalf("expected ErrInvalidPartition, got %v", err)
+	}
+	if _, err := Produce(ctx, client, "missing", ProduceRequest{}); !errors.Is(err, ErrTopicNotFound) {
+		t.Fatalf("expected ErrTopicNotFound, got %v", err)
+	}
+
+	topics, err := ListTopics(ctx, client, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end := topics[0].Partitions[first.Partition].EndOffset; end != 2 {
+		t.Fatalf("expected end offset 2, got %d", end)
+	}
+
+	offset, err := ParseOffset("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan []byte, 10)
+	stop, err := startKafkaConsumer(ctx, opts, "orders", offset, &first.Partition, func(message []byte) { received <- message })
+	if err != nil {
+		t.Fatalf("startKafkaConsumer: %v", err)
+	}
+	defer stop()
+
+	select {
+	case b := <-received:
+		var record Record
+		if err := json.Unmarshal(b, &record); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if record.Offset != 1 || record.Value != "paid" || record.Key == nil || *record.Key != key {
+			t.Fatalf("expected the record at offset 1, got %+v", record)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no record received")
+	}
+}
+
+func TestListConsumerGroups_ReportsLag(t *testing.T) {
+	opts, client := useFakeCluster(t)
+	ctx := context.Background()
+	partition := int32(0)
+	for _, v := range []string{"a", "b", "c"} {
+		if _, err := Produce(ctx, client, "orders", ProduceRequest{Value: v, Partition: &partition}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	consumer, err := kgo.NewClient(append(opts, kgo.ConsumerGroup("billing"), kgo.ConsumeTopics("orders"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()), kgo.DisableAutoCommit())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	pollCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	fetches := consumer.PollRecords(pollCtx, 1)
+	if err := fetches.Err(); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if err := consumer.CommitRecords(ctx, fetches.Records()[0]); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	groups, err := ListConsumerGroups(ctx, client)
+	if err != nil {
+		t.Fatalf("ListConsumerGroups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Group != "billing" || groups[0].Lag != 2 {
+		t.Fatalf("expected billing to lag 2 records behind, got %+v", groups)
+	}
+}
+
+func TestParseOffsetAndConnectionOptions(t *testing.T) {
+	for _, v := range []string{"", "latest", "earliest", "42"} {
+		if _, err := ParseOffset(v); err != nil {
+			t.Errorf("ParseOffset(%q): %v", v, err)
+		}
+	}
+	for _, v := range []string{"-1", "first"} {
+		if _, err := ParseOffset(v); !errors.Is(err, ErrInvalidOffset) {
+			t.Errorf("ParseOffset(%q): expected ErrInvalidOffset, got %v", v, err)
+		}
+	}
+
+	if _, err := connectionOptions(&dtos.Container{Type: "KAFKA", Ports: map[int]int{9092: 19092}}); err != nil {
+		t.Fatalf("connectionOptions: %v", err)
+	}
+	if _, err := connectionOptions(&dtos.Container{Type: "KAFKA", Ports: map[int]int{}}); !errors.Is(err, ErrNotKafka) {
+		t.Fatalf("expected ErrNotKafka without published port, got %v", err)
+	}
+	if _, err := connectionOptions(&dtos.Container{Type: "REDIS", Ports: map[int]int{9092: 19092}}); !errors.Is(err, ErrNotKafka) {
+		t.Fatalf("expected ErrNotKafka, got %v", err)
+	}
+}
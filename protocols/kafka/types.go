@@ -0,0 +1,90 @@
+package kafka
+
+import "time"
+
+const (
+	// KafkaPort is the container port of the broker listener.
+	KafkaPort = 9092
+	// MaxPartitions is the largest partition count a created topic may have.
+	MaxPartitions = 1000
+)
+
+// Topic is a topic of the broker.
+type Topic struct {
+	Name       string      `json:"name"`
+	Internal   bool        `json:"internal"`
+	Partitions []Partition `json:"partitions"`
+}
+
+// Partition is a partition of a topic with the range of offsets it holds.
+type Partition struct {
+	Partition   int32   `json:"partition"`
+	Leader      int32   `json:"leader"`
+	Replicas    []int32 `json:"replicas"`
+	StartOffset int64   `json:"start_offset"`
+	EndOffset   int64   `json:"end_offset"`
+}
+
+// ConsumerGroup is a consumer group with its lag behind the end of the partitions it consumes.
+type ConsumerGroup struct {
+	Group   string `json:"group"`
+	State   string `json:"state"`
+	Members int    `json:"members"`
+	// Lag is the total lag over all partitions.
+	Lag        int64          `json:"lag"`
+	Partitions []PartitionLag `json:"partitions"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// PartitionLag is the lag of a consumer group on a partition. Committed is -1 if the group
+// has not committed an offset yet.
+type PartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Committed int64  `json:"committed"`
+	End       int64  `json:"end"`
+	Lag       int64  `json:"lag"`
+}
+
+// CreateTopicRequest is the body of the create topic endpoint.
+type CreateTopicRequest struct {
+	Name string `json:"name"`
+	// Partitions defaults to 1.
+	Partitions int32 `json:"partitions"`
+	// ReplicationFactor defaults to 1, the only valid value on a single broker.
+	ReplicationFactor int16             `json:"replication_factor"`
+	Configs           map[string]string `json:"configs"`
+}
+
+// Header is a record header. Keys may repeat.
+type Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ProduceRequest is the body of the produce endpoint.
+type ProduceRequest struct {
+	// Key is nil for records without key.
+	Key     *string  `json:"key"`
+	Value   string   `json:"value"`
+	Headers []Header `json:"headers"`
+	// Partition is chosen from the key if nil.
+	Partition *int32 `json:"partition"`
+}
+
+// ProduceResult is where a produced record was written.
+type ProduceResult struct {
+	Partition int32 `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// Record is a consumed record.
+type Record struct {
+	Topic     string    `json:"topic"`
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+	Key       *string   `json:"key"`
+	Value     string    `json:"value"`
+	Headers   []Header  `json:"headers"`
+}
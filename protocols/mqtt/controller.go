@@ -11,31 +11,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"github.com/tim0-12432/simple-test-server/config"
 	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		var origin = r.Header.Get("Origin")
-		allowedOrigins := []string{
-			"http://" + config.EnvConfig.Host + ":" + config.EnvConfig.Port,
-		}
-		if config.EnvConfig.AllowedOrigins != nil {
-			allowedOrigins = append(allowedOrigins, config.EnvConfig.AllowedOrigins...)
-		}
-		if config.EnvConfig.Env == "DEV" {
-			allowedOrigins = append(allowedOrigins, "http://localhost:5173")
-		}
-		for _, allowedOrigin := range allowedOrigins {
-			if origin == allowedOrigin {
-				return true
-			}
-		}
-		return false
-	},
-}
-
 func InitializeMqttProtocolRoutes(root *gin.RouterGroup) {
 	mqtt := root.Group("/mqtt")
 
@@ -47,7 +26,7 @@ func InitializeMqttProtocolRoutes(root *gin.RouterGroup) {
 			return
 		}
 
-		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		conn, err := common.Upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
 			c.Status(http.StatusInternalServerError)
 			return
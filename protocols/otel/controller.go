@@ -11,6 +11,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/tim0-12432/simple-test-server/config"
 	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
 )
 
 var upgrader = websocket.Upgrader{
@@ -24,12 +25,7 @@ var upgrader = websocket.Upgrader{
 		if config.EnvConfig != nil && config.EnvConfig.Env == "DEV" {
 			return true
 		}
-		allowedOrigins := []string{
-			"http://" + config.EnvConfig.Host + ":" + config.EnvConfig.Port,
-		}
-		if config.EnvConfig.AllowedOrigins != nil {
-			allowedOrigins = append(allowedOrigins, config.EnvConfig.AllowedOrigins...)
-		}
+		allowedOrigins := common.AllowedOrigins()
 		// allow localhost origins
 		allowedOrigins = append(allowedOrigins, "http://localhost", "http://127.0.0.1")
 		for _, allowedOrigin := range allowedOrigins {
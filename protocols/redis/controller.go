@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/tim0-12432/simple-test-server/db/services"
+	"github.com/tim0-12432/simple-test-server/protocols/common"
+)
+
+// requestTimeout bounds the requests of the key browser.
+const requestTimeout = 10 * time.Second
+
+func InitializeRedisProtocolRoutes(root *gin.RouterGroup) {
+	redis := root.Group("/redis")
+	redis.GET("/:id/keys", scanKeysHandler)
+	redis.DELETE("/:id/keys", deleteKeyHandler)
+	redis.GET("/:id/keys/value", getValueHandler)
+	redis.PUT("/:id/keys/ttl", setTTLHandler)
+	redis.GET("/:id/messages", messagesHandler)
+}
+
+func scanKeysHandler(c *gin.Context) {
+	// Validate query params first (before checking container existence)
+	cursor, err := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor parameter"})
+		return
+	}
+	count, ok := intQuery(c, "count", DefaultScanCount, MaxScanCount)
+	if !ok {
+		return
+	}
+	keyType := c.Query("type")
+	if keyType != "" && !slices.Contains(keyTypes, keyType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("type must be one of %v", keyTypes)})
+		return
+	}
+
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	page, err := ScanKeys(ctx, client, cursor, c.DefaultQuery("match", "*"), keyType, count)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func getValueHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+	limit, ok := intQuery(c, "limit", DefaultValueLimit, MaxValueLimit)
+	if !ok {
+		return
+	}
+
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	value, err := GetValue(ctx, client, key, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+func setTTLHandler(c *gin.Context) {
+	var req TTLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	if req.Key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	ttl, err := SetTTL(ctx, client, req.Key, req.TTLSeconds)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"key": req.Key, "ttl": ttl})
+}
+
+func deleteKeyHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	if err := DeleteKey(ctx, client, key); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// messagesHandler streams the messages published on the channels matching the pattern query
+// parameter, all channels by default, over a WebSocket.
+func messagesHandler(c *gin.Context) {
+	client, ok := getClient(c)
+	if !ok {
+		return
+	}
+	defer client.Close()
+
+	conn, err := common.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// mutex to protect websocket writes
+	var writeMutex sync.Mutex
+
+	stop, err := startRedisSubscriber(ctx, client, c.DefaultQuery("pattern", "*"), func(message []byte) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			log.Printf("websocket write error: %v", err)
+			cancel()
+		}
+	})
+	if err != nil {
+		log.Printf("failed to start redis subscriber: %v", err)
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "subscribe failed"))
+		return
+	}
+	defer stop()
+
+	// reader to detect closure from client
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	<-ctx.Done()
+}
+
+// intQuery reads the integer query parameter name between 1 and max and answers with an
+// error if it is invalid.
+func intQuery(c *gin.Context, name string, def int64, max int64) (int64, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s parameter", name)})
+		return 0, false
+	}
+	if n < 1 || n > max {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be between 1 and %d", name, max)})
+		return 0, false
+	}
+	return n, true
+}
+
+// getClient connects to the container of the request and answers with an error if it does
+// not exist or is not a redis server.
+func getClient(c *gin.Context) (*goredis.Client, bool) {
+	container, err := services.GetContainer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+		return nil, false
+	}
+	client, err := newClient(container)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return client, true
+}
+
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, ErrKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupRouter creates a test router with redis protocol routes
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	group := router.Group("/protocols")
+	InitializeRedisProtocolRoutes(group)
+	return router
+}
+
+func TestHandlers_ValidateParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"invalid cursor", http.MethodGet, "/protocols/redis/test-id/keys?cursor=x", "", "invalid cursor parameter"},
+		{"count too high", http.MethodGet, "/protocols/redis/test-id/keys?count=1001", "", "count must be between 1 and 1000"},
+		{"unknown type", http.MethodGet, "/protocols/redis/test-id/keys?type=json", "", "type must be one of"},
+		{"missing key", http.MethodGet, "/protocols/redis/test-id/keys/value", "", "key is required"},
+		{"invalid limit", http.MethodGet, "/protocols/redis/test-id/keys/value?key=a&limit=0", "", "limit must be between 1 and 1000"},
+		{"ttl without key", http.MethodPut, "/protocols/redis/test-id/keys/ttl", `{"ttl_seconds":10}`, "key is required"},
+		{"delete without key", http.MethodDelete, "/protocols/redis/test-id/keys", "", "key is required"},
+	}
+	router := setupRouter()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if msg, _ := resp["error"].(string); !strings.HasPrefix(msg, tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, resp["error"])
+			}
+		})
+	}
+}
+
+func TestHandlers_UnknownContainer(t *testing.T) {
+	router := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/protocols/redis/missing/keys", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+}
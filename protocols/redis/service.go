@@ -0,0 +1,283 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// Capability is the catalog capability of the server types this package can browse.
+const Capability = "redis"
+
+var (
+	ErrNotRedis    = errors.New("container is not a redis server")
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// newClient returns a client for the redis server of container, authenticated with its
+// REDIS_PASSWORD.
+func newClient(container *dtos.Container) (*goredis.Client, error) {
+	server, err := servers.GetServerByType(container.Type)
+	if err != nil || !slices.Contains(server.Capabilities, Capability) {
+		return nil, ErrNotRedis
+	}
+	hostPort, ok := container.Ports[RedisPort]
+	if !ok || hostPort == 0 {
+		return nil, fmt.Errorf("%w: port %d is not published", ErrNotRedis, RedisPort)
+	}
+	return goredis.NewClient(&goredis.Options{
+		Addr:     net.JoinHostPort("localhost", strconv.Itoa(hostPort)),
+		Password: container.Environment["REDIS_PASSWORD"],
+	}), nil
+}
+
+// ttlSeconds converts the result of TTL to seconds, keeping -1 for keys without expiry and
+// -2 for missing keys.
+func ttlSeconds(ttl time.Duration) int64 {
+	if ttl < 0 {
+		return int64(ttl)
+	}
+	return int64(ttl / time.Second)
+}
+
+// ScanKeys returns the page of keys at cursor matching match and, unless empty, of keyType.
+// count is the COUNT hint of SCAN, so a page may hold fewer or more keys.
+func ScanKeys(ctx context.Context, client *goredis.Client, cursor uint64, match string, keyType string, count int64) (*KeyPage, error) {
+	var keys []string
+	var next uint64
+	var err error
+	if keyType != "" {
+		keys, next, err = client.ScanType(ctx, cursor, match, count, keyType).Result()
+	} else {
+		keys, next, err = client.Scan(ctx, cursor, match, count).Result()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan keys: %w", err)
+	}
+	sort.Strings(keys)
+
+	types := make([]*goredis.StatusCmd, len(keys))
+	ttls := make([]*goredis.DurationCmd, len(keys))
+	if len(keys) > 0 {
+		_, err = client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for i, key := range keys {
+				types[i] = pipe.Type(ctx, key)
+				ttls[i] = pipe.TTL(ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("inspect keys: %w", err)
+		}
+	}
+
+	page := &KeyPage{Keys: []KeyInfo{}, Cursor: strconv.FormatUint(next, 10)}
+	for i, key := range keys {
+		// the key expired or was deleted since the scan
+		if types[i].Val() == "none" {
+			continue
+		}
+		page.Keys = append(page.Keys, KeyInfo{Key: key, Type: types[i].Val(), TTL: ttlSeconds(ttls[i].Val())})
+	}
+	return page, nil
+}
+
+// GetValue returns the value of key. Collections are cut to limit elements and strings to
+// maxStringBytes.
+func GetValue(ctx context.Context, client *goredis.Client, key string, limit int64) (*KeyValue, error) {
+	keyType, err := client.Type(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get type: %w", err)
+	}
+	if keyType == "none" {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	ttl, err := client.TTL(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get ttl: %w", err)
+	}
+
+	value := &KeyValue{Key: key, Type: keyType, TTL: ttlSeconds(ttl)}
+	var returned int64
+	switch keyType {
+	case "string":
+		s, err := client.GetRange(ctx, key, 0, maxStringBytes-1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("get string: %w", err)
+		}
+		value.Length, err = client.StrLen(ctx, key).Result()
+		value.Value, returned = s, int64(len(s))
+	case "hash":
+		value.Length, err = client.HLen(ctx, key).Result()
+		if err == nil {
+			var fields []HashField
+			fields, err = scanHash(ctx, client, key, limit)
+			value.Value, returned = fields, int64(len(fields))
+		}
+	case "list":
+		value.Length, err = client.LLen(ctx, key).Result()
+		if err == nil {
+			var items []string
+			items, err = client.LRange(ctx, key, 0, limit-1).Result()
+			value.Value, returned = items, int64(len(items))
+		}
+	case "set":
+		value.Length, err = client.SCard(ctx, key).Result()
+		if err == nil {
+			var members []string
+			members, err = scanSet(ctx, client, key, limit)
+			value.Value, returned = members, int64(len(members))
+		}
+	case "zset":
+		value.Length, err = client.ZCard(ctx, key).Result()
+		if err == nil {
+			var zs []goredis.Z
+			zs, err = client.ZRangeWithScores(ctx, key, 0, limit-1).Result()
+			members := make([]ZMember, 0, len(zs))
+			for _, z := range zs {
+				members = append(members, ZMember{Member: fmt.Sprint(z.Member), Score: z.Score})
+			}
+			value.Value, returned = members, int64(len(members))
+		}
+	case "stream":
+		value.Length, err = client.XLen(ctx, key).Result()
+		if err == nil {
+			var messages []goredis.XMessage
+			messages, err = client.XRangeN(ctx, key, "-", "+", limit).Result()
+			entries := make([]StreamEntry, 0, len(messages))
+			for _, m := range messages {
+				entries = append(entries, StreamEntry{ID: m.ID, Values: m.Values})
+			}
+			value.Value, returned = entries, int64(len(entries))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", keyType, err)
+	}
+	value.Truncated = value.Length > returned
+	return value, nil
+}
+
+// scanHash returns up to limit fields of the hash key, sorted by field.
+func scanHash(ctx context.Context, client *goredis.Client, key string, limit int64) ([]HashField, error) {
+	fields := []HashField{}
+	var cursor uint64
+	for {
+		pairs, next, err := client.HScan(ctx, key, cursor, "", limit).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(pairs) && int64(len(fields)) < limit; i += 2 {
+			fields = append(fields, HashField{Field: pairs[i], Value: pairs[i+1]})
+		}
+		cursor = next
+		if cursor == 0 || int64(len(fields)) >= limit {
+			break
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return fields, nil
+}
+
+// scanSet returns up to limit members of the set key, sorted.
+func scanSet(ctx context.Context, client *goredis.Client, key string, limit int64) ([]string, error) {
+	members := []string{}
+	var cursor uint64
+	for {
+		page, next, err := client.SScan(ctx, key, cursor, "", limit).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range page {
+			if int64(len(members)) < limit {
+				members = append(members, m)
+			}
+		}
+		cursor = next
+		if cursor == 0 || int64(len(members)) >= limit {
+			break
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// SetTTL sets the time to live of key in seconds and returns the new TTL. A TTL of zero or
+// less removes the expiry.
+func SetTTL(ctx context.Context, client *goredis.Client, key string, ttlSeconds int64) (int64, error) {
+	exists, err := client.Exists(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("check key: %w", err)
+	}
+	if exists == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	if ttlSeconds > 0 {
+		err = client.Expire(ctx, key, time.Duration(ttlSeconds)*time.Second).Err()
+	} else {
+		err = client.Persist(ctx, key).Err()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("set ttl: %w", err)
+	}
+	if ttlSeconds > 0 {
+		return ttlSeconds, nil
+	}
+	return -1, nil
+}
+
+// DeleteKey deletes key.
+func DeleteKey(ctx context.Context, client *goredis.Client, key string) error {
+	n, err := client.Del(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("delete key: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return nil
+}
+
+// startRedisSubscriber subscribes to the channels matching pattern and invokes handler with
+// each message as JSON. It returns a stop function which unsubscribes.
+func startRedisSubscriber(ctx context.Context, client *goredis.Client, pattern string, handler func(message []byte)) (func(), error) {
+	pubsub := client.PSubscribe(ctx, pattern)
+	// wait for the confirmation, so errors like a wrong password are reported to the caller
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			data, err := json.Marshal(Message{Channel: msg.Channel, Pattern: msg.Pattern, Payload: msg.Payload})
+			if err != nil {
+				log.Printf("Error marshaling Redis message: %v", err)
+				continue
+			}
+			handler(data)
+		}
+	}()
+
+	stop := func() {
+		_ = pubsub.Close()
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return stop, nil
+}
@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+)
+
+// useMiniredis starts an in-memory redis server and returns it with a connected client.
+func useMiniredis(t *testing.T) (*miniredis.Miniredis, *goredis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func TestScanKeys(t *testing.T) {
+	server, client := useMiniredis(t)
+	ctx := context.Background()
+	server.Set("user:1", "ada")
+	server.SetTTL("user:1", 90*time.Second)
+	server.HSet("user:2", "name", "bob")
+	server.Lpush("queue", "job")
+
+	page, err := ScanKeys(ctx, client, 0, "user:*", "", 100)
+	if err != nil {
+		t.Fatalf("ScanKeys: %v", err)
+	}
+	want := []KeyInfo{{Key: "user:1", Type: "string", TTL: 90}, {Key: "user:2", Type: "hash", TTL: -1}}
+	if !reflect.DeepEqual(page.Keys, want) || page.Cursor != "0" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	page, err = ScanKeys(ctx, client, 0, "*", "list", 100)
+	if err != nil || len(page.Keys) != 1 || page.Keys[0].Key != "queue" {
+		t.Fatalf("expected the list only, got %+v (%v)", page, err)
+	}
+}
+
+func TestGetValue_ByType(t *testing.T) {
+	server, client := useMiniredis(t)
+	ctx := context.Background()
+	server.Set("greeting", "hello")
+	server.HSet("user", "name", "ada")
+	server.HSet("user", "age", "36")
+	server.Lpush("queue", "b")
+	server.Lpush("queue", "a")
+	server.SetAdd("tags", "x", "y", "z")
+	server.ZAdd("scores", 2, "bob")
+	server.ZAdd("scores", 1, "ada")
+	if _, err := server.XAdd("events", "1-1", []string{"kind", "created"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key       string
+		limit     int64
+		want      any
+		length    int64
+		truncated bool
+	}{
+		{"greeting", 10, "hello", 5, false},
+		{"user", 10, []HashField{{"age", "36"}, {"name", "ada"}}, 2, false},
+		{"queue", 1, []string{"a"}, 2, true},
+		{"tags", 10, []string{"x", "y", "z"}, 3, false},
+		{"scores", 10, []ZMember{{"ada", 1}, {"bob", 2}}, 2, false},
+		{"events", 10, []StreamEntry{{ID: "1-1", Values: map[string]any{"kind": "created"}}}, 1, false},
+	}
+	for _, tc := range tests {
+		value, err := GetValue(ctx, client, tc.key, tc.limit)
+		if err != nil {
+			t.Fatalf("%s: GetValue: %v", tc.key, err)
+		}
+		if !reflect.DeepEqual(value.Value, tc.want) || value.Length != tc.length || value.Truncated != tc.truncated || value.TTL != -1 {
+			t.Errorf("%s: unexpected value %+v", tc.key, value)
+		}
+	}
+
+	if _, err := GetValue(ctx, client, "missing", 10); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestSetTTLAndDeleteKey(t *testing.T) {
+	server, client := useMiniredis(t)
+	ctx := context.Background()
+	server.Set("session", "abc")
+
+	if ttl, err := SetTTL(ctx, client, "session", 60); err != nil || ttl != 60 || server.TTL("session") != time.Minute {
+		t.Fatalf("expected a TTL of 60s, got %d %s (%v)", ttl, server.TTL("session"), err)
+	}
+	if ttl, err := SetTTL(ctx, client, "session", 0); err != nil || ttl != -1 || server.TTL("session") != 0 {
+		t.Fatalf("expected the expiry to be removed, got %d %s (%v)", ttl, server.TTL("session"), err)
+	}
+	if _, err := SetTTL(ctx, client, "missing", 10); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	if err := DeleteKey(ctx, client, "session"); err != nil || server.Exists("session") {
+		t.Fatalf("expected the key to be deleted (%v)", err)
+	}
+	if err := DeleteKey(ctx, client, "session"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestStartRedisSubscriber(t *testing.T) {
+	server, client := useMiniredis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan []byte, 1)
+	stop, err := startRedisSubscriber(ctx, client, "orders.*", func(message []byte) { received <- message })
+	if err != nil {
+		t.Fatalf("startRedisSubscriber: %v", err)
+	}
+	defer stop()
+
+	server.Publish("other", "ignored")
+	server.Publish("orders.created", "42")
+	select {
+	case b := <-received:
+		var msg Message
+		if err := json.Unmarshal(b, &msg); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if msg != (Message{Channel: "orders.created", Pattern: "orders.*", Payload: "42"}) {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	client, err := newClient(&dtos.Container{Type: "REDIS", Ports: map[int]int{6379: 16379}, Environment: map[string]string{"REDIS_PASSWORD": "secret"}})
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	defer client.Close()
+	if opts := client.Options(); opts.Addr != "localhost:16379" || opts.Password != "secret" {
+		t.Fatalf("unexpected options %s %s", opts.Addr, opts.Password)
+	}
+	if _, err := newClient(&dtos.Container{Type: "MQTT", Ports: map[int]int{1883: 1883}}); !errors.Is(err, ErrNotRedis) {
+		t.Fatalf("expected ErrNotRedis, got %v", err)
+	}
+}
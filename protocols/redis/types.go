@@ -0,0 +1,80 @@
+package redis
+
+const (
+	// RedisPort is the container port the server listens on.
+	RedisPort = 6379
+
+	// DefaultScanCount is the COUNT hint of a key browser page unless the request asks for another.
+	DefaultScanCount = 100
+	// MaxScanCount is the largest COUNT hint a page may ask for.
+	MaxScanCount = 1000
+	// DefaultValueLimit is the number of elements of a collection returned by default.
+	DefaultValueLimit = 100
+	// MaxValueLimit is the largest number of elements a request may ask for.
+	MaxValueLimit = 1000
+	// maxStringBytes bounds the part of a string value that is returned.
+	maxStringBytes = 1 << 20
+)
+
+// keyTypes are the value types the key browser can display and filter by.
+var keyTypes = []string{"string", "hash", "list", "set", "zset", "stream"}
+
+// KeyInfo is a key of a key browser page.
+type KeyInfo struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// TTL is the remaining time to live in seconds, -1 for keys without expiry.
+	TTL int64 `json:"ttl"`
+}
+
+// KeyPage is a page of a SCAN over the keys.
+type KeyPage struct {
+	Keys []KeyInfo `json:"keys"`
+	// Cursor continues the scan; "0" once all keys were returned. It is a string as cursors
+	// exceed the integers JavaScript represents exactly.
+	Cursor string `json:"cursor"`
+}
+
+// KeyValue is the value of a key, shaped by its type: a string, a list of HashField, a list
+// of strings for lists and sets, a list of ZMember or a list of StreamEntry.
+type KeyValue struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	TTL  int64  `json:"ttl"`
+	// Length is the size of the value: bytes of a string, elements of a collection.
+	Length int64 `json:"length"`
+	Value  any   `json:"value"`
+	// Truncated is set when only a part of the value is returned.
+	Truncated bool `json:"truncated"`
+}
+
+// HashField is a field of a hash.
+type HashField struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// ZMember is a member of a sorted set.
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// StreamEntry is an entry of a stream.
+type StreamEntry struct {
+	ID     string         `json:"id"`
+	Values map[string]any `json:"values"`
+}
+
+// TTLRequest is the body of the TTL endpoint. A TTL of zero or less removes the expiry.
+type TTLRequest struct {
+	Key        string `json:"key"`
+	TTLSeconds int64  `json:"ttl_seconds"`
+}
+
+// Message is a message received by the pub/sub viewer.
+type Message struct {
+	Channel string `json:"channel"`
+	Pattern string `json:"pattern"`
+	Payload string `json:"payload"`
+}
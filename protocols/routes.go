@@ -2,11 +2,15 @@ package protocols
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/protocols/amqp"
 	"github.com/tim0-12432/simple-test-server/protocols/ftp"
+	"github.com/tim0-12432/simple-test-server/protocols/kafka"
 	"github.com/tim0-12432/simple-test-server/protocols/mail"
 	"github.com/tim0-12432/simple-test-server/protocols/mqtt"
 	"github.com/tim0-12432/simple-test-server/protocols/otel"
+	"github.com/tim0-12432/simple-test-server/protocols/redis"
 	"github.com/tim0-12432/simple-test-server/protocols/smb"
+	"github.com/tim0-12432/simple-test-server/protocols/sql"
 	"github.com/tim0-12432/simple-test-server/protocols/web"
 )
 
@@ -19,4 +23,8 @@ func InitializeProtocolRoutes(root *gin.RouterGroup) {
 	smb.InitializeSmbProtocolRoutes(protocols)
 	mail.InitializeMailProtocolRoutes(protocols)
 	otel.InitializeOtelProtocolRoutes(protocols)
+	sql.InitializeSqlProtocolRoutes(protocols)
+	redis.InitializeRedisProtocolRoutes(protocols)
+	kafka.InitializeKafkaProtocolRoutes(protocols)
+	amqp.InitializeAmqpProtocolRoutes(protocols)
 }
@@ -2,6 +2,7 @@ package smb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -54,12 +55,11 @@ func InitializeSmbProtocolRoutes(root *gin.RouterGroup) {
 
 		entries, truncated, err := docker.ListSmbDir(ctx, container.Name, relPath, 1000)
 		if err != nil {
-			s := err.Error()
-			if strings.Contains(s, "container not found") {
+			if errors.Is(err, docker.ErrContainerNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
 				return
 			}
-			if strings.Contains(s, "must be relative") || strings.Contains(s, "must not contain") {
+			if errors.Is(err, docker.ErrInvalidPath) {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
 				return
 			}
@@ -158,8 +158,7 @@ func InitializeSmbProtocolRoutes(root *gin.RouterGroup) {
 
 		logs, err := docker.GetContainerLogs(ctx, container.Name, lines)
 		if err != nil {
-			s := err.Error()
-			if strings.Contains(s, "container not found") {
+			if errors.Is(err, docker.ErrContainerNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
 				return
 			}
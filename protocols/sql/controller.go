@@ -0,0 +1,135 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/db/services"
+)
+
+// queryTimeout bounds the requests of the SQL console.
+const queryTimeout = 30 * time.Second
+
+func InitializeSqlProtocolRoutes(root *gin.RouterGroup) {
+	sql := root.Group("/sql")
+	sql.GET("/:id/databases", listDatabasesHandler)
+	sql.GET("/:id/databases/:db/tables", listTablesHandler)
+	sql.GET("/:id/databases/:db/tables/:table/columns", listColumnsHandler)
+	sql.POST("/:id/query", queryHandler)
+}
+
+func listDatabasesHandler(c *gin.Context) {
+	container, ok := getDatabaseContainer(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), queryTimeout)
+	defer cancel()
+
+	databases, err := ListDatabases(ctx, container)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"databases": databases})
+}
+
+func listTablesHandler(c *gin.Context) {
+	container, ok := getDatabaseContainer(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), queryTimeout)
+	defer cancel()
+
+	tables, err := ListTables(ctx, container, c.Param("db"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tables": tables})
+}
+
+func listColumnsHandler(c *gin.Context) {
+	container, ok := getDatabaseContainer(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), queryTimeout)
+	defer cancel()
+
+	columns, err := ListColumns(ctx, container, c.Param("db"), c.Param("table"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"columns": columns})
+}
+
+func queryHandler(c *gin.Context) {
+	// Validate the body first (before checking container existence)
+	var req QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrEmptyQuery.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultLimit
+	}
+	if req.Limit < 1 || req.Limit > MaxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", MaxLimit)})
+		return
+	}
+
+	container, ok := getDatabaseContainer(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), queryTimeout)
+	defer cancel()
+
+	result, err := Query(ctx, container, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getDatabaseContainer loads the container of the request and answers with an error if it
+// does not exist or is not a database server.
+func getDatabaseContainer(c *gin.Context) (*dtos.Container, bool) {
+	container, err := services.GetContainer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
+		return nil, false
+	}
+	if container.Status != dtos.Running {
+		c.JSON(http.StatusConflict, gin.H{"error": "container not running"})
+		return nil, false
+	}
+	if _, _, err := dialectFor(container); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return container, true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEmptyQuery), errors.Is(err, ErrReadOnly), errors.Is(err, ErrQueryFailed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
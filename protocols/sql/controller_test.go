@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupRouter creates a test router with sql protocol routes
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	group := router.Group("/protocols")
+	InitializeSqlProtocolRoutes(group)
+	return router
+}
+
+func TestQueryHandler_ValidatesBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"invalid json", `{`, "invalid request body"},
+		{"empty query", `{"query":"  "}`, "query is empty"},
+		{"limit too high", `{"query":"SELECT 1","limit":1001}`, "limit must be between 1 and 1000"},
+		{"negative limit", `{"query":"SELECT 1","limit":-1}`, "limit must be between 1 and 1000"},
+	}
+	router := setupRouter()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/protocols/sql/test-id/query", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if msg, _ := resp["error"].(string); !strings.HasPrefix(msg, tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, resp["error"])
+			}
+		})
+	}
+}
+
+func TestHandlers_UnknownContainer(t *testing.T) {
+	router := setupRouter()
+	for _, path := range []string{
+		"/protocols/sql/missing/databases",
+		"/protocols/sql/missing/databases/test/tables",
+		"/protocols/sql/missing/databases/test/tables/users/columns",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package sql
+
+import (
+	"context"
+	gosql "database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/tim0-12432/simple-test-server/db/dtos"
+	"github.com/tim0-12432/simple-test-server/docker"
+	"github.com/tim0-12432/simple-test-server/docker/servers"
+)
+
+// Capability is the catalog capability of the server types this package can query.
+const Capability = "sql"
+
+// seedTimeout bounds connecting to a freshly started server and running its seed script.
+const seedTimeout = 2 * time.Minute
+
+var (
+	ErrNotDatabase = errors.New("container is not a database server")
+	ErrEmptyQuery  = errors.New("query is empty")
+	ErrReadOnly    = errors.New("statement does not return rows, disable read_only to run it")
+	ErrQueryFailed = errors.New("query failed")
+)
+
+func init() {
+	docker.RegisterSeeder(Capability, Seed)
+}
+
+// dialect describes how to reach and inspect one kind of database server.
+type dialect struct {
+	// port is the container port the server listens on; it identifies the dialect.
+	port   int
+	driver string
+	// dsn returns the data source name for database with the credentials of env.
+	dsn func(env map[string]string, hostPort int, database string, multiStatements bool) string
+	// defaultDatabase is the database created on the first start.
+	defaultDatabase func(env map[string]string) string
+	// splitTable returns the schema and name of a table given as [schema.]name.
+	splitTable func(database string, table string) (string, string)
+	// listDatabases returns one name per row.
+	listDatabases string
+	// listTables takes the database and returns schema, name and type.
+	listTables string
+	// listColumns takes schema and table and returns name, type, nullable and default.
+	listColumns string
+}
+
+var dialects = []dialect{
+	{
+		port:   5432,
+		driver: "pgx",
+		dsn: func(env map[string]string, hostPort int, database string, _ bool) string {
+			// the simple protocol used for statements without arguments accepts multiple statements
+			u := url.URL{
+				Scheme:   "postgres",
+				User:     url.UserPassword(postgresUser(env), env["POSTGRES_PASSWORD"]),
+				Host:     net.JoinHostPort("localhost", strconv.Itoa(hostPort)),
+				Path:     "/" + database,
+				RawQuery: "sslmode=disable",
+			}
+			return u.String()
+		},
+		defaultDatabase: func(env map[string]string) string {
+			if db := env["POSTGRES_DB"]; db != "" {
+				return db
+			}
+			return postgresUser(env)
+		},
+		splitTable: func(_ string, table string) (string, string) {
+			if schema, name, ok := strings.Cut(table, "."); ok {
+				return schema, name
+			}
+			return "public", table
+		},
+		listDatabases: `SELECT datname::text FROM pg_database WHERE NOT datistemplate ORDER BY datname`,
+		listTables: `SELECT table_schema::text, table_name::text, table_type::text FROM information_schema.tables
+			WHERE table_catalog = $1 AND table_schema NOT IN ('pg_catalog', 'information_schema')
+			ORDER BY table_schema, table_name`,
+		listColumns: `SELECT column_name::text, data_type::text, is_nullable = 'YES', column_default::text
+			FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`,
+	},
+	{
+		port:   3306,
+		driver: "mysql",
+		dsn: func(env map[string]string, hostPort int, database string, multiStatements bool) string {
+			cfg := mysql.NewConfig()
+			cfg.User = "root"
+			cfg.Passwd = env["MYSQL_ROOT_PASSWORD"]
+			cfg.Net = "tcp"
+			cfg.Addr = net.JoinHostPort("localhost", strconv.Itoa(hostPort))
+			cfg.DBName = database
+			cfg.MultiStatements = multiStatements
+			return cfg.FormatDSN()
+		},
+		defaultDatabase: func(env map[string]string) string {
+			return env["MYSQL_DATABASE"]
+		},
+		splitTable: func(database string, table string) (string, string) {
+			return database, table
+		},
+		listDatabases: `SELECT schema_name FROM information_schema.schemata ORDER BY schema_name`,
+		listTables: `SELECT table_schema, table_name, table_type FROM information_schema.tables
+			WHERE table_schema = ? ORDER BY table_name`,
+		listColumns: `SELECT column_name, column_type, is_nullable = 'YES', column_default
+			FROM information_schema.columns WHERE table_schema = ? AND table_name = ? ORDER BY ordinal_position`,
+	},
+}
+
+func postgresUser(env map[string]string) string {
+	if user := env["POSTGRES_USER"]; user != "" {
+		return user
+	}
+	return "postgres"
+}
+
+// dialectFor returns the dialect and host port of a database container.
+func dialectFor(container *dtos.Container) (*dialect, int, error) {
+	server, err := servers.GetServerByType(container.Type)
+	if err != nil || !slices.Contains(server.Capabilities, Capability) {
+		return nil, 0, ErrNotDatabase
+	}
+	for i := range dialects {
+		if hostPort := container.Ports[dialects[i].port]; hostPort != 0 {
+			return &dialects[i], hostPort, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("%w: no known database port is published", ErrNotDatabase)
+}
+
+// open connects to database on container, the default database if it is empty.
+func open(container *dtos.Container, database string, multiStatements bool) (*gosql.DB, *dialect, error) {
+	d, hostPort, err := dialectFor(container)
+	if err != nil {
+		return nil, nil, err
+	}
+	if database == "" {
+		database = d.defaultDatabase(container.Environment)
+	}
+	db, err := gosql.Open(d.driver, d.dsn(container.Environment, hostPort, database, multiStatements))
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, d, nil
+}
+
+// ListDatabases returns the names of the databases of container.
+func ListDatabases(ctx context.Context, container *dtos.Container) ([]string, error) {
+	db, d, err := open(container, "", false)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, d.listDatabases)
+	if err != nil {
+		return nil, fmt.Errorf("list databases: %w", err)
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list databases: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// ListTables returns the tables and views of database.
+func ListTables(ctx context.Context, container *dtos.Container, database string) ([]Table, error) {
+	db, d, err := open(container, database, false)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, d.listTables, database)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer rows.Close()
+	tables := []Table{}
+	for rows.Next() {
+		var t Table
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Type); err != nil {
+			return nil, fmt.Errorf("list tables: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// ListColumns returns the columns of table, given as [schema.]name, in database.
+func ListColumns(ctx context.Context, container *dtos.Container, database string, table string) ([]Column, error) {
+	db, d, err := open(container, database, false)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, name := d.splitTable(database, table)
+	rows, err := db.QueryContext(ctx, d.listColumns, schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("list columns: %w", err)
+	}
+	defer rows.Close()
+	columns := []Column{}
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default); err != nil {
+			return nil, fmt.Errorf("list columns: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// Query runs req against container. Read-only queries run in a read-only transaction that is
+// rolled back afterwards, others are committed.
+func Query(ctx context.Context, container *dtos.Container, req QueryRequest) (*QueryResult, error) {
+	db, _, err := open(container, req.Database, false)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return runQuery(ctx, db, req.Query, req.ReadOnly == nil || *req.ReadOnly, req.Limit)
+}
+
+// runQuery runs query in a transaction on db and returns at most limit rows.
+func runQuery(ctx context.Context, db *gosql.DB, query string, readOnly bool, limit int) (*QueryResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptyQuery
+	}
+	rowQuery := returnsRows(query)
+	// a read-only transaction does not stop statements with an implicit commit, e.g. DDL in MySQL
+	if readOnly && !rowQuery {
+		return nil, ErrReadOnly
+	}
+
+	tx, err := db.BeginTx(ctx, &gosql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	start := time.Now()
+	result := &QueryResult{Columns: []string{}, Rows: [][]any{}}
+	if rowQuery {
+		if err := readRows(ctx, tx, query, limit, result); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrQueryFailed, err)
+		}
+	} else {
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrQueryFailed, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			result.RowsAffected = &n
+		}
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	if !readOnly {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("%w: commit: %v", ErrQueryFailed, err)
+		}
+	}
+	return result, nil
+}
+
+// readRows reads up to limit rows of query into result and marks it truncated if there are more.
+func readRows(ctx context.Context, tx *gosql.Tx, query string, limit int, result *QueryResult) error {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	result.Columns = columns
+	for rows.Next() {
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		targets := make([]any, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			// drivers return text columns as bytes; binary data stays bytes and is base64 encoded
+			if b, ok := v.([]byte); ok && utf8.Valid(b) {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return rows.Err()
+}
+
+// rowKeywords start the statements that return rows.
+var rowKeywords = []string{"SELECT", "WITH", "SHOW", "VALUES", "TABLE", "EXPLAIN", "DESCRIBE", "DESC"}
+
+var returningPattern = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// returnsRows reports whether query is a statement that returns rows, e.g. SELECT or an
+// INSERT with a RETURNING clause.
+func returnsRows(query string) bool {
+	return slices.Contains(rowKeywords, firstKeyword(query)) || returningPattern.MatchString(query)
+}
+
+// firstKeyword returns the first word of query in upper case, skipping comments and parentheses.
+func firstKeyword(query string) string {
+	q := query
+	for {
+		q = strings.TrimLeftFunc(q, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+		switch {
+		case strings.HasPrefix(q, "--"), strings.HasPrefix(q, "#"):
+			_, rest, _ := strings.Cut(q, "\n")
+			q = rest
+		case strings.HasPrefix(q, "/*"):
+			_, rest, _ := strings.Cut(q, "*/")
+			q = rest
+		default:
+			end := strings.IndexFunc(q, func(r rune) bool { return !unicode.IsLetter(r) })
+			if end < 0 {
+				end = len(q)
+			}
+			return strings.ToUpper(q[:end])
+		}
+	}
+}
+
+// Seed runs script on the default database of container. It is the docker.Seeder of database
+// servers and retries connecting, as the server may accept connections a moment after it
+// reported readiness.
+func Seed(ctx context.Context, container *dtos.Container, script string) error {
+	db, _, err := open(container, "", true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, seedTimeout)
+	defer cancel()
+	for {
+		err = db.PingContext(ctx)
+		if err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connect: %w", err)
+		case <-time.After(time.Second):
+		}
+	}
+	if _, err := db.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("run seed: %w", err)
+	}
+	return nil
+}
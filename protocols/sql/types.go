@@ -0,0 +1,44 @@
+package sql
+
+const (
+	// DefaultLimit is the number of rows a query returns unless the request asks for another limit.
+	DefaultLimit = 100
+	// MaxLimit is the largest row limit a query may ask for.
+	MaxLimit = 1000
+)
+
+// QueryRequest is the body of the query endpoint.
+type QueryRequest struct {
+	// Database to run the query in; the database created on the first start if empty.
+	Database string `json:"database"`
+	Query    string `json:"query"`
+	// ReadOnly runs the query in a read-only transaction that is rolled back. Defaults to true.
+	ReadOnly *bool `json:"read_only"`
+	// Limit is the maximum number of rows returned, DefaultLimit if zero.
+	Limit int `json:"limit"`
+}
+
+// QueryResult is the outcome of a query. Statements that return no rows report RowsAffected instead.
+type QueryResult struct {
+	Columns      []string `json:"columns"`
+	Rows         [][]any  `json:"rows"`
+	RowsAffected *int64   `json:"rows_affected,omitempty"`
+	// Truncated is set when the statement returned more rows than the limit.
+	Truncated  bool  `json:"truncated"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Table is a table or view of a database.
+type Table struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+}
+
+// Column is a column of a table.
+type Column struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default"`
+}
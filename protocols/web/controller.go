@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -57,12 +58,11 @@ func InitializeWebProtocolRoutes(root *gin.RouterGroup) {
 		entries, truncated, err := docker.ListContainerDir(ctx, container.Name, relPath, 1000)
 		if err != nil {
 			// map known errors
-			s := err.Error()
-			if strings.Contains(s, "container not found") {
+			if errors.Is(err, docker.ErrContainerNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "container not found"})
 				return
 			}
-			if strings.Contains(s, "must be relative") || strings.Contains(s, "must not contain") {
+			if errors.Is(err, docker.ErrInvalidPath) {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
 				return
 			}